@@ -1,14 +1,24 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 		
 	"github.com/personal-blog/config"
 	"github.com/personal-blog/database"
+	"github.com/personal-blog/pkg/tracing"
 	"github.com/personal-blog/repository/mysql"
 	"github.com/personal-blog/repository/redis"
 	"github.com/personal-blog/router"
 	"github.com/personal-blog/service"
+	"github.com/spf13/pflag"
 	
 	// 确保导入你的docs包
 	_ "github.com/personal-blog/docs"  
@@ -29,25 +39,71 @@ import (
 // @host localhost:8080
 // @BasePath /api/v1
 func main() {
+	// Parse command line flags
+	flags := pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+	config.RegisterFlags(flags)
+	_ = flags.Parse(os.Args[1:])
+
 	// Load configuration
-	if err := config.InitConfig(); err != nil {
+	if err := config.InitConfig(flags); err != nil {
 		log.Fatalf("Error loading config: %v", err)
 	}
 
+	// Initialize tracing
+	shutdownTracer, err := tracing.InitTracer(config.Get().Tracing)
+	if err != nil {
+		log.Fatalf("Error initializing tracer: %v", err)
+	}
+
 	// Initialize database
-	if err := database.InitMySQL(); err != nil {
-		log.Fatalf("Error initializing MySQL: %v", err)
+	if err := database.InitDB(); err != nil {
+		log.Fatalf("Error initializing database: %v", err)
 	}
 
-	if err := database.InitRedis(); err != nil {
-		log.Fatalf("Error initializing Redis: %v", err)
+	// migrate 和 role 子命令只操作数据库，执行后退出
+	if args := flags.Args(); len(args) > 0 {
+		var err error
+		switch args[0] {
+		case "migrate":
+			if err = runMigrate(context.Background(), args[1:]); err != nil {
+				err = fmt.Errorf("migration failed: %w", err)
+			}
+		case "role":
+			err = runRole(context.Background(), args[1:])
+		default:
+			err = fmt.Errorf("unknown command %q", args[0])
+		}
+		database.CloseDB()
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		return
+	}
+
+	// 只有启动服务时才监听配置文件变化
+	config.Watch()
+
+	if config.Get().Database.AutoMigrate {
+		if err := database.RunMigrations(context.Background()); err != nil {
+			log.Fatalf("Error running migrations: %v", err)
+		}
 	}
 
 	// Create MySQL factory
 	mysqlFactory := mysql.NewFactory(database.DB)
 
-	// Create Redis factory
-	redisFactory := redis.NewFactory(database.RedisClient)
+	// Create cache factory
+	var redisFactory redis.Factory
+	if cacheCfg := config.Get().Cache; cacheCfg.Driver == "memory" {
+		log.Printf("Using in-memory cache (max %d entries)", cacheCfg.MaxEntries)
+		redisFactory = redis.NewMemoryFactory(cacheCfg.MaxEntries)
+	} else {
+		if err := database.InitRedis(); err != nil {
+			database.CloseDB()
+			log.Fatalf("Error initializing Redis: %v", err)
+		}
+		redisFactory = redis.NewFactory(database.RedisClient)
+	}
 
 	// Create service factory
 	factory := service.NewFactory(mysqlFactory, redisFactory)
@@ -55,8 +111,65 @@ func main() {
 	// Set up the router
 	r := router.SetupRouter(factory)
 
+	serverCfg := config.Get().Server
+	srv := &http.Server{
+		Addr:         fmt.Sprintf(":%d", serverCfg.Port),
+		Handler:      r,
+		ReadTimeout:  time.Duration(serverCfg.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(serverCfg.WriteTimeout) * time.Second,
+		IdleTimeout:  time.Duration(serverCfg.IdleTimeout) * time.Second,
+	}
+
+	// Start background workers
+	factory.StartWorkers()
+
 	// Start the server
-	if err := r.Run(":8080"); err != nil {
-		log.Fatal("Failed to start the server:", err)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server listening on %s", srv.Addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	// 服务启动失败（如端口被占用）时同样执行清理，最后以非零状态退出，便于进程管理器发现失败
+	failed := false
+	select {
+	case <-ctx.Done():
+		log.Println("Shutdown signal received")
+	case err := <-serverErr:
+		log.Printf("Failed to start the server: %v", err)
+		failed = true
+	}
+	stop()
+
+	// Graceful shutdown
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(serverCfg.ShutdownTimeout)*time.Second)
+	defer cancel()
+
+	// 停止接收新请求并等待处理中的请求完成
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error shutting down server: %v", err)
+	}
+
+	// 停止后台任务并刷新缓冲的浏览量
+	if err := factory.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error stopping background workers: %v", err)
+	}
+
+	database.CloseRedis()
+	database.CloseDB()
+
+	if err := shutdownTracer(shutdownCtx); err != nil {
+		log.Printf("Error shutting down tracer: %v", err)
+	}
+
+	if failed {
+		log.Println("Server exited with error")
+		os.Exit(1)
 	}
+	log.Println("Server exited")
 }
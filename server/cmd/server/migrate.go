@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/personal-blog/database"
+)
+
+const migrateUsage = `usage: server [flags] migrate <command>
+
+commands:
+  up            执行所有未执行的迁移
+  down [n]      回滚最近的n个迁移（默认1）
+  status        查看迁移状态
+  to <version>  迁移到指定版本（0表示回滚全部）`
+
+// runMigrate 执行 migrate 子命令
+func runMigrate(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing migrate command\n%s", migrateUsage)
+	}
+
+	migrator, err := database.NewMigrator(database.DB)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		count, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("applied %d migration(s)\n", count)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid step count: %s", args[1])
+			}
+		}
+		count, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("reverted %d migration(s)\n", count)
+
+	case "to":
+		if len(args) < 2 {
+			return fmt.Errorf("missing target version\n%s", migrateUsage)
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid version: %s", args[1])
+		}
+		count, err := migrator.To(ctx, version)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("migrated to version %d (%d change(s))\n", version, count)
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, s := range statuses {
+			status, appliedAt := "pending", "-"
+			if s.Applied {
+				status = "applied"
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", s.Version, s.Name, status, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], migrateUsage)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/personal-blog/database"
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/repository/mysql"
+)
+
+const roleUsage = `usage: server [flags] role <username> <admin|editor|user>
+
+注册的用户均为普通用户，用于指定第一个管理员；之后由管理员通过 PUT /users/:id/role 分配角色
+用户重新登录后新角色生效`
+
+// runRole 执行 role 子命令
+func runRole(ctx context.Context, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("missing username or role\n%s", roleUsage)
+	}
+	username, role := args[0], args[1]
+	switch role {
+	case models.RoleAdmin, models.RoleEditor, models.RoleUser:
+	default:
+		return fmt.Errorf("invalid role %q\n%s", role, roleUsage)
+	}
+
+	repo := mysql.NewUserRepository(database.DB)
+	user, err := repo.FindByUsername(ctx, username)
+	if err != nil {
+		return fmt.Errorf("find user %q: %w", username, err)
+	}
+	if err := repo.Update(ctx, &models.User{ID: user.ID, Role: role}); err != nil {
+		return err
+	}
+	fmt.Printf("user %s is now %s\n", username, role)
+	return nil
+}
@@ -2,7 +2,12 @@ package config
 
 import (
 	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -11,21 +16,41 @@ type Config struct {
 	Database DatabaseConfig `mapstructure:"database"`
 	Redis    RedisConfig    `mapstructure:"redis"`
 	JWT      JWTConfig      `mapstructure:"jwt"`
+	Tracing  TracingConfig  `mapstructure:"tracing"`
+
+	// 以下配置支持热更新
+	Log       LogConfig       `mapstructure:"log"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	Comment   CommentConfig   `mapstructure:"comment"`
+	Cache     CacheConfig     `mapstructure:"cache"`
+	View      ViewConfig      `mapstructure:"view"`
+	Trash     TrashConfig     `mapstructure:"trash"`
+	Pin       PinConfig       `mapstructure:"pin"`
+	Reaction  ReactionConfig  `mapstructure:"reaction"`
 }
 
 type ServerConfig struct {
-	Port    int    `mapstructure:"port"`
-	Mode    string `mapstructure:"mode"`
-	LogPath string `mapstructure:"log_path"`
+	Port            int    `mapstructure:"port"`
+	Mode            string `mapstructure:"mode"`
+	LogPath         string `mapstructure:"log_path"`
+	ReadTimeout     int    `mapstructure:"read_timeout"`     // 读超时（秒）
+	WriteTimeout    int    `mapstructure:"write_timeout"`    // 写超时（秒）
+	IdleTimeout     int    `mapstructure:"idle_timeout"`     // 空闲连接超时（秒）
+	ShutdownTimeout int    `mapstructure:"shutdown_timeout"` // 优雅关闭等待时间（秒）
+	ReadyTimeout    int    `mapstructure:"ready_timeout"`    // 就绪检查中单个依赖的超时（秒）
 }
 
 type DatabaseConfig struct {
+	Driver   string `mapstructure:"driver"` // mysql/postgres/sqlite
 	Host     string `mapstructure:"host"`
 	Port     int    `mapstructure:"port"`
 	Username string `mapstructure:"username"`
 	Password string `mapstructure:"password"`
-	DBName   string `mapstructure:"dbname"`
+	DBName   string `mapstructure:"dbname"` // SQLite时为数据库文件路径，:memory: 为进程内数据库
 	Charset  string `mapstructure:"charset"`
+	SSLMode  string `mapstructure:"sslmode"` // 仅PostgreSQL
+
+	AutoMigrate bool `mapstructure:"auto_migrate"` // 启动时自动执行未执行的迁移
 }
 
 type RedisConfig struct {
@@ -40,23 +65,241 @@ type JWTConfig struct {
 	ExpireTime int    `mapstructure:"expire_time"` // 过期时间（小时）
 }
 
-var GlobalConfig Config
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	ServiceName string  `mapstructure:"service_name"`
+	Exporter    string  `mapstructure:"exporter"`     // otlp/stdout
+	Endpoint    string  `mapstructure:"endpoint"`     // OTLP HTTP地址，如 localhost:4318
+	Insecure    bool    `mapstructure:"insecure"`     // 不使用TLS
+	SampleRatio float64 `mapstructure:"sample_ratio"` // 采样率 0~1
+}
 
-// InitConfig 初始化配置
-func InitConfig() error {
-	viper.SetConfigName("config")
-	viper.SetConfigType("yaml")
-	viper.AddConfigPath("./config")
+type LogConfig struct {
+	Level string `mapstructure:"level"` // debug/info/warn/error
+}
 
-	if err := viper.ReadInConfig(); err != nil {
-		return err
+type RateLimitConfig struct {
+	Enabled bool    `mapstructure:"enabled"`
+	Rate    float64 `mapstructure:"rate"`  // 每个IP每秒允许的请求数
+	Burst   int     `mapstructure:"burst"` // 突发请求数
+}
+
+type CommentConfig struct {
+	Moderation string `mapstructure:"moderation"` // none:直接发布 all:全部先审后发
+}
+
+type CacheConfig struct {
+	Driver      string  `mapstructure:"driver"`       // redis:使用Redis memory:进程内LRU缓存，无需外部服务
+	MaxEntries  int     `mapstructure:"max_entries"`  // memory驱动下最多缓存的条目数
+	PostTTL     int     `mapstructure:"post_ttl"`     // 文章缓存时间（秒）
+	UserTTL     int     `mapstructure:"user_ttl"`     // 用户缓存时间（秒）
+	CategoryTTL int     `mapstructure:"category_ttl"` // 分类缓存时间（秒）
+	TagTTL      int     `mapstructure:"tag_ttl"`      // 标签缓存时间（秒）
+	CommentTTL  int     `mapstructure:"comment_ttl"`  // 评论缓存时间（秒）
+	CountTTL    int     `mapstructure:"count_ttl"`    // 游标分页近似总数的缓存时间（秒），期间新增或删除的记录不会反映在总数中
+	StaleTTL    int     `mapstructure:"stale_ttl"`    // 过期后仍可返回旧值并后台刷新的时间（秒），0表示关闭
+	NegativeTTL int     `mapstructure:"negative_ttl"` // 记录不存在时的负缓存时间（秒），0表示关闭
+	TTLJitter   float64 `mapstructure:"ttl_jitter"`   // 缓存时间随机抖动比例，避免大量key同时过期
+}
+
+type ViewConfig struct {
+	DedupWindow   int `mapstructure:"dedup_window"`   // 同一访客重复浏览不计数的时间窗口（秒）
+	FlushInterval int `mapstructure:"flush_interval"` // 浏览量从缓存写入数据库的间隔（秒）
+}
+
+type TrashConfig struct {
+	RetentionDays int `mapstructure:"retention_days"` // 回收站中的文章和评论保留天数，超过后彻底删除
+	PurgeInterval int `mapstructure:"purge_interval"` // 清理回收站的间隔（秒）
+}
+
+type PinConfig struct {
+	ExpireInterval int `mapstructure:"expire_interval"` // 检查置顶是否到期的间隔（秒），到期的置顶最多延迟这么久才会取消
+}
+
+type ReactionConfig struct {
+	Types         []string `mapstructure:"types"`          // 可用的反应类型，like 始终可用
+	FlushInterval int      `mapstructure:"flush_interval"` // 反应数从缓存写入数据库的间隔（秒）
+	AnonymousTTL  int      `mapstructure:"anonymous_ttl"`  // 匿名访客反应的去重记录保留时间（秒），过期后可以再次反应
+}
+
+// envPrefix 环境变量前缀，如 BLOG_DATABASE_PASSWORD 对应 database.password
+const envPrefix = "BLOG"
+
+// setDefaults 设置所有配置项的默认值
+// 只有注册过默认值的键才能被环境变量覆盖
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("server.port", 8080)
+	v.SetDefault("server.mode", "development")
+	v.SetDefault("server.log_path", "./logs")
+	v.SetDefault("server.read_timeout", 10)
+	v.SetDefault("server.write_timeout", 30)
+	v.SetDefault("server.idle_timeout", 120)
+	v.SetDefault("server.shutdown_timeout", 15)
+	v.SetDefault("server.ready_timeout", 2)
+
+	v.SetDefault("database.driver", "mysql")
+	v.SetDefault("database.host", "127.0.0.1")
+	v.SetDefault("database.port", 3306)
+	v.SetDefault("database.username", "root")
+	v.SetDefault("database.password", "")
+	v.SetDefault("database.dbname", "")
+	v.SetDefault("database.charset", "utf8mb4")
+	v.SetDefault("database.sslmode", "disable")
+	v.SetDefault("database.auto_migrate", true)
+
+	v.SetDefault("redis.host", "127.0.0.1")
+	v.SetDefault("redis.port", 6379)
+	v.SetDefault("redis.password", "")
+	v.SetDefault("redis.db", 0)
+
+	v.SetDefault("jwt.secret", "")
+	v.SetDefault("jwt.expire_time", 24)
+
+	v.SetDefault("tracing.enabled", false)
+	v.SetDefault("tracing.service_name", "personal-blog")
+	v.SetDefault("tracing.exporter", "stdout")
+	v.SetDefault("tracing.endpoint", "localhost:4318")
+	v.SetDefault("tracing.insecure", true)
+	v.SetDefault("tracing.sample_ratio", 1.0)
+
+	v.SetDefault("log.level", "info")
+
+	v.SetDefault("rate_limit.enabled", true)
+	v.SetDefault("rate_limit.rate", 20)
+	v.SetDefault("rate_limit.burst", 40)
+
+	v.SetDefault("comment.moderation", "none")
+
+	v.SetDefault("cache.driver", "redis")
+	v.SetDefault("cache.max_entries", 10000)
+	v.SetDefault("cache.post_ttl", 3600)
+	v.SetDefault("cache.user_ttl", 86400)
+	v.SetDefault("cache.category_ttl", 43200)
+	v.SetDefault("cache.tag_ttl", 43200)
+	v.SetDefault("cache.comment_ttl", 21600)
+	v.SetDefault("cache.count_ttl", 300)
+	v.SetDefault("cache.stale_ttl", 60)
+	v.SetDefault("cache.negative_ttl", 30)
+	v.SetDefault("cache.ttl_jitter", 0.1)
+	v.SetDefault("view.dedup_window", 1800)
+	v.SetDefault("view.flush_interval", 30)
+
+	v.SetDefault("trash.retention_days", 30)
+	v.SetDefault("trash.purge_interval", 3600)
+
+	v.SetDefault("pin.expire_interval", 60)
+
+	v.SetDefault("reaction.types", []string{"like", "love", "laugh", "wow", "sad"})
+	v.SetDefault("reaction.flush_interval", 30)
+	v.SetDefault("reaction.anonymous_ttl", 365*24*3600)
+}
+
+// RegisterFlags 注册配置相关的命令行参数
+func RegisterFlags(fs *pflag.FlagSet) {
+	fs.String("config", "", "配置文件路径，默认查找 ./config/config.yaml")
+	fs.String("env", "", "运行环境，会额外加载同目录下的 config.<env>.yaml（也可通过 BLOG_ENV 设置）")
+	fs.Int("port", 0, "HTTP监听端口，覆盖 server.port")
+	fs.String("mode", "", "运行模式，覆盖 server.mode")
+}
+
+// flagBindings 命令行参数与配置键的对应关系
+var flagBindings = map[string]string{
+	"port": "server.port",
+	"mode": "server.mode",
+}
+
+// loadOptions 记录配置来源，热更新时按相同来源重新加载
+type loadOptions struct {
+	configFile string
+	env        string
+	overrides  map[string]string
+}
+
+// InitConfig 初始化配置
+// 加载顺序（后者覆盖前者）：默认值 → 基础配置文件 → 环境配置文件 → BLOG_* 环境变量 → 命令行参数
+func InitConfig(fs *pflag.FlagSet) error {
+	opts := loadOptions{
+		configFile: os.Getenv(envPrefix + "_CONFIG"),
+		env:        os.Getenv(envPrefix + "_ENV"),
+		overrides:  make(map[string]string),
+	}
+	if fs != nil {
+		if f := fs.Lookup("config"); f != nil && f.Changed {
+			opts.configFile = f.Value.String()
+		}
+		if f := fs.Lookup("env"); f != nil && f.Changed {
+			opts.env = f.Value.String()
+		}
+		// 命令行参数，仅显式指定时生效
+		for name, key := range flagBindings {
+			if f := fs.Lookup(name); f != nil && f.Changed {
+				opts.overrides[key] = f.Value.String()
+			}
+		}
 	}
 
-	if err := viper.Unmarshal(&GlobalConfig); err != nil {
+	cfg, files, err := load(opts)
+	if err != nil {
 		return err
 	}
 
-	fmt.Println("GlobalConfig: ", GlobalConfig)
+	defaultProvider = newProvider(cfg, opts, files)
+	log.Printf("Config loaded from %s: %s", strings.Join(files, ", "), cfg)
 
 	return nil
 }
+
+// load 按来源读取并校验配置，返回配置及实际读取的文件列表
+func load(opts loadOptions) (*Config, []string, error) {
+	v := viper.New()
+	setDefaults(v)
+
+	// 基础配置文件
+	if opts.configFile != "" {
+		v.SetConfigFile(opts.configFile)
+	} else {
+		v.SetConfigName("config")
+		v.SetConfigType("yaml")
+		v.AddConfigPath("./config")
+	}
+	if err := v.ReadInConfig(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	files := []string{v.ConfigFileUsed()}
+
+	// 环境配置文件，不存在时忽略
+	if opts.env != "" {
+		base := files[0]
+		ext := filepath.Ext(base)
+		envFile := strings.TrimSuffix(base, ext) + "." + opts.env + ext
+		if _, err := os.Stat(envFile); err == nil {
+			v.SetConfigFile(envFile)
+			if err := v.MergeInConfig(); err != nil {
+				return nil, nil, fmt.Errorf("failed to merge config file %s: %v", envFile, err)
+			}
+			files = append(files, envFile)
+		}
+	}
+
+	// 环境变量
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	// 命令行参数
+	for key, value := range opts.overrides {
+		v.Set(key, value)
+	}
+
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config: %v", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	return &cfg, files, nil
+}
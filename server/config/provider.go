@@ -0,0 +1,147 @@
+package config
+
+import (
+	"log"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// ChangeFunc 配置变更回调，old和new均为只读快照
+type ChangeFunc func(old, new *Config)
+
+// Provider 配置提供者
+// 通过原子指针保存配置快照，读取方拿到的始终是完整一致的配置
+type Provider struct {
+	current  atomic.Pointer[Config]
+	opts     loadOptions
+	files    []string
+	watchers []*viper.Viper
+	subs     []ChangeFunc
+	mu       sync.Mutex
+}
+
+var defaultProvider *Provider
+
+func newProvider(cfg *Config, opts loadOptions, files []string) *Provider {
+	p := &Provider{opts: opts, files: files}
+	p.current.Store(cfg)
+	return p
+}
+
+// Get 返回当前配置快照，调用方不应修改返回值
+func Get() *Config {
+	if defaultProvider == nil {
+		return &Config{}
+	}
+	return defaultProvider.Get()
+}
+
+// Subscribe 订阅配置变更
+func Subscribe(fn ChangeFunc) {
+	if defaultProvider == nil {
+		return
+	}
+	defaultProvider.Subscribe(fn)
+}
+
+// Watch 监听配置文件变化并热更新
+func Watch() {
+	if defaultProvider == nil {
+		return
+	}
+	defaultProvider.Watch()
+}
+
+// Get 返回当前配置快照
+func (p *Provider) Get() *Config {
+	return p.current.Load()
+}
+
+// Subscribe 订阅配置变更
+func (p *Provider) Subscribe(fn ChangeFunc) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subs = append(p.subs, fn)
+}
+
+// Watch 基于viper的文件监听，任一配置文件变化时按原来源重新加载
+func (p *Provider) Watch() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.watchers) > 0 {
+		return
+	}
+
+	for _, file := range p.files {
+		w := viper.New()
+		w.SetConfigFile(file)
+		if err := w.ReadInConfig(); err != nil {
+			log.Printf("Config watch skipped for %s: %v", file, err)
+			continue
+		}
+		w.OnConfigChange(func(e fsnotify.Event) {
+			log.Printf("Config file changed: %s", e.Name)
+			p.reload()
+		})
+		w.WatchConfig()
+		p.watchers = append(p.watchers, w)
+	}
+}
+
+// reload 重新加载配置，校验失败时保留旧配置
+func (p *Provider) reload() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	next, _, err := load(p.opts)
+	if err != nil {
+		log.Printf("Config reload rejected: %v", err)
+		return
+	}
+
+	old := p.current.Load()
+	rejectImmutableChanges(old, next)
+	if reflect.DeepEqual(old, next) {
+		return
+	}
+
+	p.current.Store(next)
+	log.Printf("Config reloaded: %s", next)
+
+	for _, fn := range p.subs {
+		fn(old, next)
+	}
+}
+
+// rejectImmutableChanges 运行期间不能安全修改的配置项保持原值并记录警告
+func rejectImmutableChanges(old, next *Config) {
+	if !reflect.DeepEqual(old.Server, next.Server) {
+		log.Printf("Config warning: server settings (port, mode, timeouts) cannot be changed at runtime, restart required")
+		next.Server = old.Server
+	}
+	if !reflect.DeepEqual(old.Database, next.Database) {
+		log.Printf("Config warning: database settings cannot be changed at runtime, restart required")
+		next.Database = old.Database
+	}
+	if !reflect.DeepEqual(old.Redis, next.Redis) {
+		log.Printf("Config warning: redis settings cannot be changed at runtime, restart required")
+		next.Redis = old.Redis
+	}
+	if !reflect.DeepEqual(old.JWT, next.JWT) {
+		log.Printf("Config warning: jwt settings cannot be changed at runtime, restart required")
+		next.JWT = old.JWT
+	}
+	if !reflect.DeepEqual(old.Tracing, next.Tracing) {
+		log.Printf("Config warning: tracing settings cannot be changed at runtime, restart required")
+		next.Tracing = old.Tracing
+	}
+	if old.Cache.Driver != next.Cache.Driver || old.Cache.MaxEntries != next.Cache.MaxEntries {
+		log.Printf("Config warning: cache driver and size cannot be changed at runtime, restart required")
+		next.Cache.Driver = old.Cache.Driver
+		next.Cache.MaxEntries = old.Cache.MaxEntries
+	}
+}
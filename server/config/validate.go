@@ -0,0 +1,163 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// minJWTSecretLength JWT密钥最小长度
+const minJWTSecretLength = 32
+
+// reactionTypePattern 反应类型的格式，与数据库列的长度一致
+var reactionTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,19}$`)
+
+// redactedValue 敏感字段脱敏后的占位符
+const redactedValue = "******"
+
+// Validate 校验配置，返回所有不合法的配置项
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Server.Port <= 0 || c.Server.Port > 65535 {
+		errs = append(errs, fmt.Errorf("server.port must be between 1 and 65535, got %d", c.Server.Port))
+	}
+	switch c.Server.Mode {
+	case "development", "production", "debug", "test":
+	default:
+		errs = append(errs, fmt.Errorf("server.mode must be one of development/production/debug/test, got %q", c.Server.Mode))
+	}
+	if c.Server.ReadTimeout <= 0 || c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 {
+		errs = append(errs, errors.New("server read/write/idle timeouts must be positive"))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout must be positive"))
+	}
+
+	switch c.Database.Driver {
+	case "mysql", "postgres":
+		if c.Database.Host == "" {
+			errs = append(errs, errors.New("database.host is required"))
+		}
+		if c.Database.Port <= 0 || c.Database.Port > 65535 {
+			errs = append(errs, fmt.Errorf("database.port must be between 1 and 65535, got %d", c.Database.Port))
+		}
+	case "sqlite":
+	default:
+		errs = append(errs, fmt.Errorf("database.driver must be one of mysql/postgres/sqlite, got %q", c.Database.Driver))
+	}
+	if c.Database.DBName == "" {
+		errs = append(errs, errors.New("database.dbname is required"))
+	}
+
+	if c.Redis.Host == "" {
+		errs = append(errs, errors.New("redis.host is required"))
+	}
+	if c.Redis.Port <= 0 || c.Redis.Port > 65535 {
+		errs = append(errs, fmt.Errorf("redis.port must be between 1 and 65535, got %d", c.Redis.Port))
+	}
+
+	if c.JWT.Secret == "" {
+		errs = append(errs, errors.New("jwt.secret is required"))
+	} else if len(c.JWT.Secret) < minJWTSecretLength {
+		errs = append(errs, fmt.Errorf("jwt.secret must be at least %d characters", minJWTSecretLength))
+	}
+	if c.JWT.ExpireTime <= 0 {
+		errs = append(errs, errors.New("jwt.expire_time must be positive"))
+	}
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing.sample_ratio must be between 0 and 1, got %v", c.Tracing.SampleRatio))
+	}
+
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		errs = append(errs, fmt.Errorf("log.level must be one of debug/info/warn/error, got %q", c.Log.Level))
+	}
+
+	if c.RateLimit.Enabled && (c.RateLimit.Rate <= 0 || c.RateLimit.Burst <= 0) {
+		errs = append(errs, errors.New("rate_limit.rate and rate_limit.burst must be positive when enabled"))
+	}
+
+	switch c.Comment.Moderation {
+	case "none", "all":
+	default:
+		errs = append(errs, fmt.Errorf("comment.moderation must be one of none/all, got %q", c.Comment.Moderation))
+	}
+
+	switch c.Cache.Driver {
+	case "redis":
+	case "memory":
+		if c.Cache.MaxEntries <= 0 {
+			errs = append(errs, errors.New("cache.max_entries must be positive for memory cache"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("cache.driver must be one of redis/memory, got %q", c.Cache.Driver))
+	}
+	if c.Cache.PostTTL < 0 || c.Cache.UserTTL < 0 || c.Cache.CategoryTTL < 0 || c.Cache.TagTTL < 0 || c.Cache.CommentTTL < 0 || c.Cache.CountTTL < 0 {
+		errs = append(errs, errors.New("cache ttl must not be negative"))
+	}
+	if c.Cache.StaleTTL < 0 || c.Cache.NegativeTTL < 0 {
+		errs = append(errs, errors.New("cache.stale_ttl and cache.negative_ttl must not be negative"))
+	}
+	if c.Cache.TTLJitter < 0 || c.Cache.TTLJitter >= 1 {
+		errs = append(errs, fmt.Errorf("cache.ttl_jitter must be in [0, 1), got %v", c.Cache.TTLJitter))
+	}
+
+	if c.View.DedupWindow < 0 {
+		errs = append(errs, errors.New("view.dedup_window must not be negative"))
+	}
+	if c.View.FlushInterval <= 0 {
+		errs = append(errs, errors.New("view.flush_interval must be positive"))
+	}
+
+	if c.Trash.RetentionDays <= 0 {
+		errs = append(errs, errors.New("trash.retention_days must be positive"))
+	}
+	if c.Trash.PurgeInterval <= 0 {
+		errs = append(errs, errors.New("trash.purge_interval must be positive"))
+	}
+
+	if c.Pin.ExpireInterval <= 0 {
+		errs = append(errs, errors.New("pin.expire_interval must be positive"))
+	}
+
+	for _, t := range c.Reaction.Types {
+		if !reactionTypePattern.MatchString(t) {
+			errs = append(errs, fmt.Errorf("reaction.types: invalid type %q, must match %s", t, reactionTypePattern))
+		}
+	}
+	if c.Reaction.FlushInterval <= 0 {
+		errs = append(errs, errors.New("reaction.flush_interval must be positive"))
+	}
+	if c.Reaction.AnonymousTTL <= 0 {
+		errs = append(errs, errors.New("reaction.anonymous_ttl must be positive"))
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// Redacted 返回敏感字段已脱敏的配置副本，用于日志输出
+func (c Config) Redacted() Config {
+	if c.Database.Password != "" {
+		c.Database.Password = redactedValue
+	}
+	if c.Redis.Password != "" {
+		c.Redis.Password = redactedValue
+	}
+	if c.JWT.Secret != "" {
+		c.JWT.Secret = redactedValue
+	}
+	return c
+}
+
+// String 以脱敏后的形式输出配置，避免误打印密钥
+func (c Config) String() string {
+	// 转换为不带方法的类型，避免%+v递归调用String
+	type plain Config
+	return fmt.Sprintf("%+v", plain(c.Redacted()))
+}
@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/personal-blog/config"
+	"github.com/personal-blog/pkg/tracing"
+)
+
+var DB *gorm.DB
+
+// InitDB 根据 database.driver 初始化数据库连接
+func InitDB() error {
+	gormConfig := &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Info),
+	}
+
+	if config.Get().Server.Mode == "production" {
+		gormConfig.Logger = logger.Default.LogMode(logger.Error)
+	}
+
+	db, err := Open(config.Get().Database, gormConfig)
+	if err != nil {
+		return err
+	}
+	DB = db
+	return nil
+}
+
+// Open 按 cfg.Driver 选择方言打开数据库连接，注册链路追踪插件并设置连接池
+// 测试中可以传入 driver 为 sqlite 的配置使用进程内数据库
+func Open(cfg config.DatabaseConfig, gormConfig *gorm.Config) (*gorm.DB, error) {
+	d, err := dialectFor(cfg.Driver)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(d.open(cfg), gormConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %v", err)
+	}
+
+	// 注册链路追踪插件
+	if err := db.Use(tracing.NewGormPlugin()); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database instance: %v", err)
+	}
+
+	// 设置连接池
+	sqlDB.SetMaxIdleConns(10)
+	sqlDB.SetMaxOpenConns(100)
+	sqlDB.SetConnMaxLifetime(time.Hour)
+	if d.name() == "sqlite" {
+		// SQLite同一时间只允许一个写入者，单连接可避免 database is locked
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+	}
+	return db, nil
+}
+
+// RunMigrations 执行所有未执行的迁移
+func RunMigrations(ctx context.Context) error {
+	migrator, err := NewMigrator(DB)
+	if err != nil {
+		return err
+	}
+	count, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		log.Printf("Applied %d migration(s), schema version %d", count, migrator.Latest())
+	}
+	return nil
+}
+
+// PingDB 检查数据库连接是否可用
+func PingDB(ctx context.Context) error {
+	if DB == nil {
+		return errors.New("database not initialized")
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// CloseDB 关闭数据库连接
+func CloseDB() {
+	if DB != nil {
+		sqlDB, err := DB.DB()
+		if err != nil {
+			log.Printf("Error getting database instance: %v", err)
+			return
+		}
+		err = sqlDB.Close()
+		if err != nil {
+			log.Printf("Error closing database connection: %v", err)
+		}
+	}
+}
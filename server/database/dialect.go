@@ -0,0 +1,195 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"github.com/personal-blog/config"
+)
+
+// dialect 数据库方言，封装连接方式以及迁移所需的方言相关SQL
+type dialect interface {
+	// name 方言名称，同时也是迁移文件所在的子目录
+	name() string
+	// open 根据配置创建GORM Dialector
+	open(cfg config.DatabaseConfig) gorm.Dialector
+	// migrationTableDDL 创建迁移记录表的语句
+	migrationTableDDL(table string) string
+	// rebind 将 ? 占位符转换为方言对应的占位符
+	rebind(query string) string
+	// lock 在conn上获取迁移锁，unlock的参数表示迁移是否成功
+	lock(ctx context.Context, conn *sql.Conn) (unlock func(ok bool) error, err error)
+	// txPerMigration 是否将每个迁移脚本和它的版本记录放在同一个事务中执行
+	txPerMigration() bool
+}
+
+// dialectFor 根据driver配置返回方言
+func dialectFor(driver string) (dialect, error) {
+	switch driver {
+	case "", "mysql":
+		return mysqlDialect{}, nil
+	case "postgres":
+		return postgresDialect{}, nil
+	case "sqlite":
+		return sqliteDialect{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported database driver: %s", driver)
+	}
+}
+
+// mysqlDialect MySQL方言，迁移锁使用 GET_LOCK 命名锁
+type mysqlDialect struct{}
+
+func (mysqlDialect) name() string { return "mysql" }
+
+func (mysqlDialect) open(cfg config.DatabaseConfig) gorm.Dialector {
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local",
+		cfg.Username,
+		cfg.Password,
+		cfg.Host,
+		cfg.Port,
+		cfg.DBName,
+		cfg.Charset,
+	)
+	return mysql.Open(dsn)
+}
+
+func (mysqlDialect) migrationTableDDL(table string) string {
+	return "CREATE TABLE IF NOT EXISTS " + table + ` (
+		version BIGINT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at DATETIME(3) NOT NULL
+	)`
+}
+
+func (mysqlDialect) rebind(query string) string { return query }
+
+// MySQL的DDL会隐式提交，无法与版本记录放在同一事务中
+func (mysqlDialect) txPerMigration() bool { return false }
+
+func (mysqlDialect) lock(ctx context.Context, conn *sql.Conn) (func(bool) error, error) {
+	var got sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", migrationLockName, migrationLockWait).Scan(&got); err != nil {
+		return nil, err
+	}
+	if !got.Valid || got.Int64 != 1 {
+		return nil, errors.New("timeout")
+	}
+	return func(bool) error {
+		// 使用独立的context，确保ctx取消后锁仍能释放
+		_, err := conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", migrationLockName)
+		return err
+	}, nil
+}
+
+// postgresDialect PostgreSQL方言，迁移锁使用会话级advisory lock
+type postgresDialect struct{}
+
+func (postgresDialect) name() string { return "postgres" }
+
+func (postgresDialect) open(cfg config.DatabaseConfig) gorm.Dialector {
+	sslMode := cfg.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Host,
+		cfg.Port,
+		cfg.Username,
+		cfg.Password,
+		cfg.DBName,
+		sslMode,
+	)
+	return postgres.Open(dsn)
+}
+
+func (postgresDialect) migrationTableDDL(table string) string {
+	return "CREATE TABLE IF NOT EXISTS " + table + ` (
+		version BIGINT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMPTZ NOT NULL
+	)`
+}
+
+func (postgresDialect) rebind(query string) string {
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// PostgreSQL的DDL支持事务，脚本与版本记录一起提交，中途退出不会留下执行了一半的迁移
+func (postgresDialect) txPerMigration() bool { return true }
+
+func (postgresDialect) lock(ctx context.Context, conn *sql.Conn) (func(bool) error, error) {
+	// pg_advisory_lock 会一直阻塞，通过ctx控制等待时间
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock(hashtext($1))", migrationLockName); err != nil {
+		return nil, err
+	}
+	return func(bool) error {
+		_, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", migrationLockName)
+		return err
+	}, nil
+}
+
+// sqliteDialect SQLite方言
+// SQLite的DDL支持事务，迁移在 BEGIN IMMEDIATE 事务中执行，写锁同时起到迁移锁的作用
+type sqliteDialect struct{}
+
+func (sqliteDialect) name() string { return "sqlite" }
+
+func (sqliteDialect) open(cfg config.DatabaseConfig) gorm.Dialector {
+	// SQLite时 dbname 为数据库文件路径，:memory: 表示进程内数据库
+	path := cfg.DBName
+	if path == ":memory:" {
+		path = "file::memory:?cache=shared"
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return sqlite.Open(path + sep + "_foreign_keys=on&_busy_timeout=5000")
+}
+
+func (sqliteDialect) migrationTableDDL(table string) string {
+	return "CREATE TABLE IF NOT EXISTS " + table + ` (
+		version INTEGER NOT NULL PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	)`
+}
+
+func (sqliteDialect) rebind(query string) string { return query }
+
+// 整个迁移过程已经在 lock 开启的事务中，不能再嵌套事务
+func (sqliteDialect) txPerMigration() bool { return false }
+
+func (sqliteDialect) lock(ctx context.Context, conn *sql.Conn) (func(bool) error, error) {
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return nil, err
+	}
+	return func(ok bool) error {
+		stmt := "COMMIT"
+		if !ok {
+			stmt = "ROLLBACK"
+		}
+		_, err := conn.ExecContext(context.Background(), stmt)
+		return err
+	}, nil
+}
@@ -0,0 +1,389 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/personal-blog/database/migrations"
+)
+
+const (
+	migrationTable    = "schema_migrations"
+	migrationLockName = "personal_blog:schema_migrations"
+	migrationLockWait = 60 // 获取迁移锁的最长等待时间（秒）
+)
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_([\w-]+)\.(up|down)\.sql$`)
+
+// Migration 一个版本的迁移
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus 迁移状态
+type MigrationStatus struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+// Migrator 版本化迁移执行器
+// 迁移记录保存在 schema_migrations 表中，通过数据库级别的锁避免多个实例同时执行
+type Migrator struct {
+	db         *gorm.DB
+	dialect    dialect
+	migrations []Migration
+}
+
+// NewMigrator 创建迁移执行器，使用内嵌迁移文件中与当前数据库方言对应的目录
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	d, err := dialectFor(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	sub, err := fs.Sub(migrations.FS, d.name())
+	if err != nil {
+		return nil, err
+	}
+	list, err := loadMigrations(sub)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: d, migrations: list}, nil
+}
+
+// loadMigrations 读取并按版本号排序迁移文件
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		m := migrationFileRe.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %s: %v", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(content)
+		} else {
+			mig.Down = string(content)
+		}
+	}
+
+	list := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", mig.Version, mig.Name)
+		}
+		list = append(list, *mig)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// Latest 返回最新的迁移版本号
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up 执行所有未执行的迁移，返回本次执行的数量
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	return m.migrate(ctx, m.Latest())
+}
+
+// To 迁移到指定版本，高于当前版本时向上迁移，低于时回滚
+func (m *Migrator) To(ctx context.Context, version int64) (int, error) {
+	if version != 0 && m.find(version) == nil {
+		return 0, fmt.Errorf("unknown migration version %d", version)
+	}
+	return m.migrate(ctx, version)
+}
+
+// Down 回滚最近执行的steps个迁移
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	if steps <= 0 {
+		return 0, nil
+	}
+
+	var count int
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		versions := sortedVersions(applied)
+		for i := len(versions) - 1; i >= 0 && count < steps; i-- {
+			if err := m.rollback(ctx, conn, versions[i]); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Status 返回所有迁移的执行状态
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var result []MigrationStatus
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		if err := m.ensureTable(ctx, conn); err != nil {
+			return err
+		}
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mig := range m.migrations {
+			status := MigrationStatus{Version: mig.Version, Name: mig.Name}
+			if at, ok := applied[mig.Version]; ok {
+				at := at
+				status.Applied = true
+				status.AppliedAt = &at
+			}
+			result = append(result, status)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// migrate 在迁移锁内将数据库迁移到目标版本
+func (m *Migrator) migrate(ctx context.Context, target int64) (int, error) {
+	var count int
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		// 回滚高于目标版本的迁移
+		versions := sortedVersions(applied)
+		for i := len(versions) - 1; i >= 0; i-- {
+			if versions[i] <= target {
+				break
+			}
+			if err := m.rollback(ctx, conn, versions[i]); err != nil {
+				return err
+			}
+			count++
+		}
+
+		// 执行不高于目标版本且未执行的迁移
+		for _, mig := range m.migrations {
+			if mig.Version > target {
+				break
+			}
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			log.Printf("Applying migration %d_%s", mig.Version, mig.Name)
+			err := m.step(ctx, conn, func() error {
+				if err := execScript(ctx, conn, mig.Up); err != nil {
+					return fmt.Errorf("migration %d_%s failed: %v", mig.Version, mig.Name, err)
+				}
+				_, err := conn.ExecContext(ctx,
+					m.dialect.rebind("INSERT INTO "+migrationTable+" (version, name, applied_at) VALUES (?, ?, ?)"),
+					mig.Version, mig.Name, time.Now())
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// rollback 回滚单个版本
+func (m *Migrator) rollback(ctx context.Context, conn *sql.Conn, version int64) error {
+	mig := m.find(version)
+	if mig == nil {
+		return fmt.Errorf("applied migration %d not found in migration files", version)
+	}
+	if mig.Down == "" {
+		return fmt.Errorf("migration %d_%s is irreversible", mig.Version, mig.Name)
+	}
+
+	log.Printf("Reverting migration %d_%s", mig.Version, mig.Name)
+	return m.step(ctx, conn, func() error {
+		if err := execScript(ctx, conn, mig.Down); err != nil {
+			return fmt.Errorf("rollback %d_%s failed: %v", mig.Version, mig.Name, err)
+		}
+		_, err := conn.ExecContext(ctx, m.dialect.rebind("DELETE FROM "+migrationTable+" WHERE version = ?"), version)
+		return err
+	})
+}
+
+// step 执行单个迁移及其版本记录的变更，方言支持时放在同一个事务中
+func (m *Migrator) step(ctx context.Context, conn *sql.Conn, fn func() error) error {
+	if !m.dialect.txPerMigration() {
+		return fn()
+	}
+	if _, err := conn.ExecContext(ctx, "BEGIN"); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		if _, rbErr := conn.ExecContext(context.Background(), "ROLLBACK"); rbErr != nil {
+			log.Printf("Error rolling back migration: %v", rbErr)
+		}
+		return err
+	}
+	_, err := conn.ExecContext(ctx, "COMMIT")
+	return err
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// applied 查询已执行的迁移版本
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM "+migrationTable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		result[version] = appliedAt
+	}
+	return result, rows.Err()
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, m.dialect.migrationTableDDL(migrationTable))
+	return err
+}
+
+// withConn 获取一个独占连接，迁移语句与锁必须在同一连接上执行
+func (m *Migrator) withConn(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return fn(conn)
+}
+
+// withLock 在数据库级别的迁移锁内执行，防止多个副本同时启动时重复迁移
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	return m.withConn(ctx, func(conn *sql.Conn) error {
+		lockCtx, cancel := context.WithTimeout(ctx, migrationLockWait*time.Second)
+		defer cancel()
+
+		unlock, err := m.dialect.lock(lockCtx, conn)
+		if err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %v", err)
+		}
+
+		err = m.ensureTable(ctx, conn)
+		if err == nil {
+			err = fn(conn)
+		}
+		if unlockErr := unlock(err == nil); unlockErr != nil {
+			log.Printf("Error releasing migration lock: %v", unlockErr)
+			if err == nil {
+				err = unlockErr
+			}
+		}
+		return err
+	})
+}
+
+// execScript 按分号拆分并依次执行SQL脚本
+// MySQL的DDL无法回滚，脚本中途失败时需要人工处理；PostgreSQL由 step 为每个迁移开启事务，
+// SQLite的整个迁移过程在 BEGIN IMMEDIATE 事务中，失败时脚本和版本记录一起回滚
+func execScript(ctx context.Context, conn *sql.Conn, script string) error {
+	for _, stmt := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("%v\n%s", err, stmt)
+		}
+	}
+	return nil
+}
+
+// splitStatements 以行尾分号作为语句结束，忽略 -- 开头的注释行
+func splitStatements(script string) []string {
+	var stmts []string
+	var buf strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		buf.WriteString(line)
+		buf.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSpace(buf.String()))
+			buf.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(buf.String()); rest != "" {
+		stmts = append(stmts, rest)
+	}
+	return stmts
+}
+
+func sortedVersions(applied map[int64]time.Time) []int64 {
+	versions := make([]int64, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
@@ -0,0 +1,13 @@
+// Package migrations 存放按版本排序的SQL迁移文件
+//
+// 每种数据库方言一个子目录（mysql、postgres、sqlite），版本号在各目录间保持一致。
+// 文件命名格式为 <版本号>_<名称>.up.sql 与 <版本号>_<名称>.down.sql，
+// 版本号只增不改，已发布的迁移文件不应再修改。
+package migrations
+
+import "embed"
+
+// FS 内嵌的迁移文件
+//
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var FS embed.FS
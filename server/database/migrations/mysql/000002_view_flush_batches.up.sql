@@ -0,0 +1,8 @@
+-- 记录已写入数据库的浏览量批次，保证每个批次只被应用一次
+
+CREATE TABLE `view_flush_batches` (
+  `batch_id` varchar(64) NOT NULL,
+  `applied_at` datetime(3) NOT NULL,
+  PRIMARY KEY (`batch_id`),
+  KEY `idx_view_flush_batches_applied_at` (`applied_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `post_campaign_stats`;
+DROP TABLE IF EXISTS `post_referrer_stats`;
+DROP TABLE IF EXISTS `post_view_buckets`;
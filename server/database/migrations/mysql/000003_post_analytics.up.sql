@@ -0,0 +1,28 @@
+-- 文章浏览统计：按小时/按天的浏览量、来源域名和UTM推广活动
+
+CREATE TABLE `post_view_buckets` (
+  `post_id` bigint unsigned NOT NULL,
+  `granularity` varchar(8) NOT NULL,
+  `bucket_start` datetime(3) NOT NULL,
+  `views` bigint NOT NULL DEFAULT 0,
+  PRIMARY KEY (`post_id`,`granularity`,`bucket_start`),
+  KEY `idx_post_view_buckets_range` (`granularity`,`bucket_start`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `post_referrer_stats` (
+  `post_id` bigint unsigned NOT NULL,
+  `day` datetime(3) NOT NULL,
+  `domain` varchar(255) NOT NULL,
+  `views` bigint NOT NULL DEFAULT 0,
+  PRIMARY KEY (`post_id`,`day`,`domain`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `post_campaign_stats` (
+  `post_id` bigint unsigned NOT NULL,
+  `day` datetime(3) NOT NULL,
+  `source` varchar(100) NOT NULL,
+  `medium` varchar(100) NOT NULL,
+  `campaign` varchar(100) NOT NULL,
+  `views` bigint NOT NULL DEFAULT 0,
+  PRIMARY KEY (`post_id`,`day`,`source`,`medium`,`campaign`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `series_posts`;
+DROP TABLE IF EXISTS `series`;
@@ -0,0 +1,23 @@
+-- 系列文章：有序的多篇文章合集，一篇文章最多属于一个系列
+
+CREATE TABLE `series` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `title` varchar(200) NOT NULL,
+  `slug` varchar(100) NOT NULL,
+  `description` varchar(1000) DEFAULT NULL,
+  `cover` varchar(255) DEFAULT NULL,
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uni_series_slug` (`slug`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `series_posts` (
+  `series_id` bigint unsigned NOT NULL,
+  `post_id` bigint unsigned NOT NULL,
+  `position` bigint NOT NULL,
+  PRIMARY KEY (`series_id`,`post_id`),
+  UNIQUE KEY `uni_series_posts_post_id` (`post_id`),
+  CONSTRAINT `fk_series_posts_series` FOREIGN KEY (`series_id`) REFERENCES `series` (`id`),
+  CONSTRAINT `fk_series_posts_post` FOREIGN KEY (`post_id`) REFERENCES `posts` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
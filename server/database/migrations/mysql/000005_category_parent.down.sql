@@ -0,0 +1,4 @@
+ALTER TABLE `categories`
+  DROP FOREIGN KEY `fk_categories_children`,
+  DROP KEY `idx_categories_parent_id`,
+  DROP COLUMN `parent_id`;
@@ -0,0 +1,6 @@
+-- 分类支持父分类，parent_id 为空表示顶级分类
+
+ALTER TABLE `categories`
+  ADD COLUMN `parent_id` bigint unsigned DEFAULT NULL,
+  ADD KEY `idx_categories_parent_id` (`parent_id`),
+  ADD CONSTRAINT `fk_categories_children` FOREIGN KEY (`parent_id`) REFERENCES `categories` (`id`);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS `tag_aliases`;
+ALTER TABLE `tags`
+  DROP KEY `idx_tags_normalized_name`,
+  DROP COLUMN `normalized_name`;
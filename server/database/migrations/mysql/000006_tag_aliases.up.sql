@@ -0,0 +1,25 @@
+-- 标签名规范化与别名
+-- normalized_name 为去除首尾空白、全角转半角并折叠大小写后的名称，用于识别同一标签的不同写法。
+-- 已有数据按 LOWER(TRIM(name)) 回填，规范化后重名的标签保留ID最小的一个，
+-- 其余标签的 normalized_name 追加 #ID 以满足唯一约束，可通过合并标签接口清理。
+
+ALTER TABLE `tags` ADD COLUMN `normalized_name` varchar(80) DEFAULT NULL;
+
+UPDATE `tags` SET `normalized_name` = CONCAT(LOWER(TRIM(`name`)), '#', `id`);
+UPDATE `tags` SET `normalized_name` = LOWER(TRIM(`name`))
+WHERE `id` IN (SELECT `id` FROM (SELECT MIN(`id`) AS `id` FROM `tags` GROUP BY LOWER(TRIM(`name`))) AS `canonical`);
+
+ALTER TABLE `tags`
+  MODIFY COLUMN `normalized_name` varchar(80) NOT NULL,
+  ADD UNIQUE KEY `idx_tags_normalized_name` (`normalized_name`);
+
+CREATE TABLE `tag_aliases` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `tag_id` bigint unsigned NOT NULL,
+  `alias` varchar(80) NOT NULL,
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_tag_aliases_alias` (`alias`),
+  KEY `idx_tag_aliases_tag_id` (`tag_id`),
+  CONSTRAINT `fk_tags_aliases` FOREIGN KEY (`tag_id`) REFERENCES `tags` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
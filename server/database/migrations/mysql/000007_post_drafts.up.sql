@@ -0,0 +1,18 @@
+-- 文章草稿快照：编辑器自动保存的内容，每个用户对每篇已有文章最多一份，新文章的草稿不关联文章
+
+CREATE TABLE `post_drafts` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `user_id` bigint unsigned NOT NULL,
+  `post_id` bigint unsigned DEFAULT NULL,
+  `title` varchar(200) DEFAULT NULL,
+  `content` text,
+  `category_id` bigint unsigned DEFAULT NULL,
+  `tags` text,
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_post_drafts_user_post` (`user_id`,`post_id`),
+  KEY `idx_post_drafts_post_id` (`post_id`),
+  CONSTRAINT `fk_post_drafts_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`),
+  CONSTRAINT `fk_post_drafts_post` FOREIGN KEY (`post_id`) REFERENCES `posts` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
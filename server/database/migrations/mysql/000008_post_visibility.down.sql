@@ -0,0 +1,4 @@
+ALTER TABLE `posts`
+  DROP KEY `idx_posts_visibility`,
+  DROP COLUMN `password_hash`,
+  DROP COLUMN `visibility`;
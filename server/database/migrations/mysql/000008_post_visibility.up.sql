@@ -0,0 +1,6 @@
+-- 文章可见性：public 公开，unlisted 不出现在列表中但可通过链接访问，password 需要密码才能阅读
+
+ALTER TABLE `posts`
+  ADD COLUMN `visibility` varchar(20) NOT NULL DEFAULT 'public',
+  ADD COLUMN `password_hash` varchar(100) DEFAULT NULL,
+  ADD KEY `idx_posts_visibility` (`visibility`);
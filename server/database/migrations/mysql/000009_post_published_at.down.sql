@@ -0,0 +1,3 @@
+ALTER TABLE `posts`
+  DROP KEY `idx_posts_published_at`,
+  DROP COLUMN `published_at`;
@@ -0,0 +1,7 @@
+-- 文章首次发布的时间，用于按发布时间排序和归档；已发布的文章以创建时间作为发布时间
+
+ALTER TABLE `posts`
+  ADD COLUMN `published_at` datetime(3) DEFAULT NULL,
+  ADD KEY `idx_posts_published_at` (`published_at`);
+
+UPDATE `posts` SET `published_at` = `created_at` WHERE `status` = 1;
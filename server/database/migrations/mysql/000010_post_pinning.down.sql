@@ -0,0 +1,6 @@
+ALTER TABLE `posts`
+  DROP KEY `idx_posts_featured`,
+  DROP KEY `idx_posts_pinned_until`,
+  DROP COLUMN `featured`,
+  DROP COLUMN `pinned_until`,
+  DROP COLUMN `pin_order`;
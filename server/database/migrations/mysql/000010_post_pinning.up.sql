@@ -0,0 +1,8 @@
+-- 置顶顺序和到期时间，以及与置顶相互独立的精选标记（用于首页轮播）
+
+ALTER TABLE `posts`
+  ADD COLUMN `pin_order` int NOT NULL DEFAULT 0,
+  ADD COLUMN `pinned_until` datetime(3) DEFAULT NULL,
+  ADD COLUMN `featured` tinyint(1) NOT NULL DEFAULT 0,
+  ADD KEY `idx_posts_pinned_until` (`pinned_until`),
+  ADD KEY `idx_posts_featured` (`featured`);
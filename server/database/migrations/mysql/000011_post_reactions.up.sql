@@ -0,0 +1,21 @@
+-- 文章反应：登录用户的每种反应各一条记录；各类反应的总数先在缓存中累加，再按批次写入 post_reaction_counts
+
+CREATE TABLE `post_reactions` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `post_id` bigint unsigned NOT NULL,
+  `user_id` bigint unsigned NOT NULL,
+  `type` varchar(20) NOT NULL,
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_post_reactions_post_user_type` (`post_id`,`user_id`,`type`),
+  KEY `idx_post_reactions_user_type` (`user_id`,`type`,`created_at`),
+  CONSTRAINT `fk_post_reactions_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`),
+  CONSTRAINT `fk_post_reactions_post` FOREIGN KEY (`post_id`) REFERENCES `posts` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `post_reaction_counts` (
+  `post_id` bigint unsigned NOT NULL,
+  `type` varchar(20) NOT NULL,
+  `count` bigint NOT NULL DEFAULT 0,
+  PRIMARY KEY (`post_id`,`type`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
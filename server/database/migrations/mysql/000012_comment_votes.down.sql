@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS `comment_votes`;
+ALTER TABLE `comments`
+  DROP KEY `idx_comments_post_pinned`,
+  DROP COLUMN `is_pinned`,
+  DROP COLUMN `upvote_count`;
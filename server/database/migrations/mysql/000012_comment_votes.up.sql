@@ -0,0 +1,17 @@
+-- 评论点赞和置顶：每个用户对每条评论最多点赞一次，点赞数冗余在 comments.upvote_count 中用于排序；每篇文章最多置顶一条评论
+
+ALTER TABLE `comments`
+  ADD COLUMN `upvote_count` bigint NOT NULL DEFAULT 0,
+  ADD COLUMN `is_pinned` tinyint(1) NOT NULL DEFAULT 0,
+  ADD KEY `idx_comments_post_pinned` (`post_id`,`is_pinned`);
+
+CREATE TABLE `comment_votes` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `comment_id` bigint unsigned NOT NULL,
+  `user_id` bigint unsigned NOT NULL,
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_comment_votes_comment_user` (`comment_id`,`user_id`),
+  CONSTRAINT `fk_comment_votes_user` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`),
+  CONSTRAINT `fk_comment_votes_comment` FOREIGN KEY (`comment_id`) REFERENCES `comments` (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+ALTER TABLE `posts`
+  DROP KEY `idx_posts_comment_count`,
+  DROP COLUMN `comment_count`;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS "comments";
+DROP TABLE IF EXISTS "post_tags";
+DROP TABLE IF EXISTS "posts";
+DROP TABLE IF EXISTS "tags";
+DROP TABLE IF EXISTS "categories";
+DROP TABLE IF EXISTS "users";
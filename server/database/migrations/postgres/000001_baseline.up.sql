@@ -0,0 +1,77 @@
+-- 与原 AutoMigrate 生成的表结构一致
+
+CREATE TABLE IF NOT EXISTS "users" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar(50) NOT NULL,
+  "password" varchar(100) NOT NULL,
+  "email" varchar(100) NOT NULL,
+  "nickname" varchar(50),
+  "avatar" varchar(255),
+  "role" varchar(20) DEFAULT 'user',
+  "bio" varchar(500),
+  "status" bigint DEFAULT 1,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  CONSTRAINT "uni_users_username" UNIQUE ("username"),
+  CONSTRAINT "uni_users_email" UNIQUE ("email")
+);
+
+CREATE TABLE IF NOT EXISTS "categories" (
+  "id" bigserial PRIMARY KEY,
+  "name" varchar(50) NOT NULL,
+  "description" varchar(200),
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  CONSTRAINT "uni_categories_name" UNIQUE ("name")
+);
+
+CREATE TABLE IF NOT EXISTS "tags" (
+  "id" bigserial PRIMARY KEY,
+  "name" varchar(50) NOT NULL,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  CONSTRAINT "uni_tags_name" UNIQUE ("name")
+);
+
+CREATE TABLE IF NOT EXISTS "posts" (
+  "id" bigserial PRIMARY KEY,
+  "title" varchar(200) NOT NULL,
+  "content" text,
+  "summary" varchar(500),
+  "cover" varchar(255),
+  "status" bigint DEFAULT 1,
+  "is_top" boolean DEFAULT false,
+  "view_count" bigint DEFAULT 0,
+  "user_id" bigint,
+  "category_id" bigint,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  CONSTRAINT "fk_posts_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+  CONSTRAINT "fk_categories_posts" FOREIGN KEY ("category_id") REFERENCES "categories"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_posts_deleted_at" ON "posts"("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "post_tags" (
+  "post_id" bigint NOT NULL,
+  "tag_id" bigint NOT NULL,
+  PRIMARY KEY ("post_id", "tag_id"),
+  CONSTRAINT "fk_post_tags_post" FOREIGN KEY ("post_id") REFERENCES "posts"("id"),
+  CONSTRAINT "fk_post_tags_tag" FOREIGN KEY ("tag_id") REFERENCES "tags"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "comments" (
+  "id" bigserial PRIMARY KEY,
+  "content" varchar(1000) NOT NULL,
+  "post_id" bigint,
+  "user_id" bigint,
+  "parent_id" bigint,
+  "status" bigint DEFAULT 1,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  "deleted_at" timestamptz,
+  CONSTRAINT "fk_posts_comments" FOREIGN KEY ("post_id") REFERENCES "posts"("id"),
+  CONSTRAINT "fk_comments_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+  CONSTRAINT "fk_comments_children" FOREIGN KEY ("parent_id") REFERENCES "comments"("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_comments_deleted_at" ON "comments"("deleted_at");
@@ -0,0 +1,29 @@
+-- 文章浏览统计：按小时/按天的浏览量、来源域名和UTM推广活动
+
+CREATE TABLE "post_view_buckets" (
+  "post_id" bigint NOT NULL,
+  "granularity" varchar(8) NOT NULL,
+  "bucket_start" timestamptz NOT NULL,
+  "views" bigint NOT NULL DEFAULT 0,
+  PRIMARY KEY ("post_id","granularity","bucket_start")
+);
+
+CREATE INDEX "idx_post_view_buckets_range" ON "post_view_buckets" ("granularity","bucket_start");
+
+CREATE TABLE "post_referrer_stats" (
+  "post_id" bigint NOT NULL,
+  "day" timestamptz NOT NULL,
+  "domain" varchar(255) NOT NULL,
+  "views" bigint NOT NULL DEFAULT 0,
+  PRIMARY KEY ("post_id","day","domain")
+);
+
+CREATE TABLE "post_campaign_stats" (
+  "post_id" bigint NOT NULL,
+  "day" timestamptz NOT NULL,
+  "source" varchar(100) NOT NULL,
+  "medium" varchar(100) NOT NULL,
+  "campaign" varchar(100) NOT NULL,
+  "views" bigint NOT NULL DEFAULT 0,
+  PRIMARY KEY ("post_id","day","source","medium","campaign")
+);
@@ -0,0 +1,22 @@
+-- 系列文章：有序的多篇文章合集，一篇文章最多属于一个系列
+
+CREATE TABLE "series" (
+  "id" bigserial PRIMARY KEY,
+  "title" varchar(200) NOT NULL,
+  "slug" varchar(100) NOT NULL,
+  "description" varchar(1000),
+  "cover" varchar(255),
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  CONSTRAINT "uni_series_slug" UNIQUE ("slug")
+);
+
+CREATE TABLE "series_posts" (
+  "series_id" bigint NOT NULL,
+  "post_id" bigint NOT NULL,
+  "position" bigint NOT NULL,
+  PRIMARY KEY ("series_id","post_id"),
+  CONSTRAINT "uni_series_posts_post_id" UNIQUE ("post_id"),
+  CONSTRAINT "fk_series_posts_series" FOREIGN KEY ("series_id") REFERENCES "series"("id"),
+  CONSTRAINT "fk_series_posts_post" FOREIGN KEY ("post_id") REFERENCES "posts"("id")
+);
@@ -0,0 +1,5 @@
+-- 分类支持父分类，parent_id 为空表示顶级分类
+
+ALTER TABLE "categories" ADD COLUMN "parent_id" bigint;
+ALTER TABLE "categories" ADD CONSTRAINT "fk_categories_children" FOREIGN KEY ("parent_id") REFERENCES "categories"("id");
+CREATE INDEX "idx_categories_parent_id" ON "categories" ("parent_id");
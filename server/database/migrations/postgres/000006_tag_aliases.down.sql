@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "tag_aliases";
+DROP INDEX IF EXISTS "idx_tags_normalized_name";
+ALTER TABLE "tags" DROP COLUMN IF EXISTS "normalized_name";
@@ -0,0 +1,17 @@
+-- 文章草稿快照：编辑器自动保存的内容，每个用户对每篇已有文章最多一份，新文章的草稿不关联文章
+
+CREATE TABLE "post_drafts" (
+  "id" bigserial PRIMARY KEY,
+  "user_id" bigint NOT NULL,
+  "post_id" bigint,
+  "title" varchar(200),
+  "content" text,
+  "category_id" bigint,
+  "tags" text,
+  "created_at" timestamptz,
+  "updated_at" timestamptz,
+  CONSTRAINT "fk_post_drafts_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+  CONSTRAINT "fk_post_drafts_post" FOREIGN KEY ("post_id") REFERENCES "posts"("id")
+);
+CREATE UNIQUE INDEX "idx_post_drafts_user_post" ON "post_drafts"("user_id","post_id");
+CREATE INDEX "idx_post_drafts_post_id" ON "post_drafts"("post_id");
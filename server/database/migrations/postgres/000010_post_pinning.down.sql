@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "idx_posts_featured";
+DROP INDEX IF EXISTS "idx_posts_pinned_until";
+ALTER TABLE "posts" DROP COLUMN IF EXISTS "featured";
+ALTER TABLE "posts" DROP COLUMN IF EXISTS "pinned_until";
+ALTER TABLE "posts" DROP COLUMN IF EXISTS "pin_order";
@@ -0,0 +1,7 @@
+-- 置顶顺序和到期时间，以及与置顶相互独立的精选标记（用于首页轮播）
+
+ALTER TABLE "posts" ADD COLUMN "pin_order" integer NOT NULL DEFAULT 0;
+ALTER TABLE "posts" ADD COLUMN "pinned_until" timestamptz;
+ALTER TABLE "posts" ADD COLUMN "featured" boolean NOT NULL DEFAULT false;
+CREATE INDEX "idx_posts_pinned_until" ON "posts" ("pinned_until");
+CREATE INDEX "idx_posts_featured" ON "posts" ("featured");
@@ -0,0 +1,20 @@
+-- 文章反应：登录用户的每种反应各一条记录；各类反应的总数先在缓存中累加，再按批次写入 post_reaction_counts
+
+CREATE TABLE "post_reactions" (
+  "id" bigserial PRIMARY KEY,
+  "post_id" bigint NOT NULL,
+  "user_id" bigint NOT NULL,
+  "type" varchar(20) NOT NULL,
+  "created_at" timestamptz,
+  CONSTRAINT "fk_post_reactions_user" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+  CONSTRAINT "fk_post_reactions_post" FOREIGN KEY ("post_id") REFERENCES "posts"("id")
+);
+CREATE UNIQUE INDEX "idx_post_reactions_post_user_type" ON "post_reactions"("post_id","user_id","type");
+CREATE INDEX "idx_post_reactions_user_type" ON "post_reactions"("user_id","type","created_at");
+
+CREATE TABLE "post_reaction_counts" (
+  "post_id" bigint NOT NULL,
+  "type" varchar(20) NOT NULL,
+  "count" bigint NOT NULL DEFAULT 0,
+  PRIMARY KEY ("post_id","type")
+);
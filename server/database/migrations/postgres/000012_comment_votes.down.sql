@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "comment_votes";
+DROP INDEX IF EXISTS "idx_comments_post_pinned";
+ALTER TABLE "comments" DROP COLUMN IF EXISTS "is_pinned";
+ALTER TABLE "comments" DROP COLUMN IF EXISTS "upvote_count";
@@ -0,0 +1,77 @@
+-- 与原 AutoMigrate 生成的表结构一致
+
+CREATE TABLE IF NOT EXISTS `users` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `username` text NOT NULL,
+  `password` text NOT NULL,
+  `email` text NOT NULL,
+  `nickname` text,
+  `avatar` text,
+  `role` text DEFAULT 'user',
+  `bio` text,
+  `status` integer DEFAULT 1,
+  `created_at` datetime,
+  `updated_at` datetime,
+  CONSTRAINT `uni_users_username` UNIQUE (`username`),
+  CONSTRAINT `uni_users_email` UNIQUE (`email`)
+);
+
+CREATE TABLE IF NOT EXISTS `categories` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `name` text NOT NULL,
+  `description` text,
+  `created_at` datetime,
+  `updated_at` datetime,
+  CONSTRAINT `uni_categories_name` UNIQUE (`name`)
+);
+
+CREATE TABLE IF NOT EXISTS `tags` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `name` text NOT NULL,
+  `created_at` datetime,
+  `updated_at` datetime,
+  CONSTRAINT `uni_tags_name` UNIQUE (`name`)
+);
+
+CREATE TABLE IF NOT EXISTS `posts` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `title` text NOT NULL,
+  `content` text,
+  `summary` text,
+  `cover` text,
+  `status` integer DEFAULT 1,
+  `is_top` numeric DEFAULT false,
+  `view_count` integer DEFAULT 0,
+  `user_id` integer,
+  `category_id` integer,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  CONSTRAINT `fk_posts_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+  CONSTRAINT `fk_categories_posts` FOREIGN KEY (`category_id`) REFERENCES `categories`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_posts_deleted_at` ON `posts`(`deleted_at`);
+
+CREATE TABLE IF NOT EXISTS `post_tags` (
+  `post_id` integer NOT NULL,
+  `tag_id` integer NOT NULL,
+  PRIMARY KEY (`post_id`, `tag_id`),
+  CONSTRAINT `fk_post_tags_post` FOREIGN KEY (`post_id`) REFERENCES `posts`(`id`),
+  CONSTRAINT `fk_post_tags_tag` FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `comments` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `content` text NOT NULL,
+  `post_id` integer,
+  `user_id` integer,
+  `parent_id` integer,
+  `status` integer DEFAULT 1,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime,
+  CONSTRAINT `fk_posts_comments` FOREIGN KEY (`post_id`) REFERENCES `posts`(`id`),
+  CONSTRAINT `fk_comments_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+  CONSTRAINT `fk_comments_children` FOREIGN KEY (`parent_id`) REFERENCES `comments`(`id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_comments_deleted_at` ON `comments`(`deleted_at`);
@@ -0,0 +1,8 @@
+-- 记录已写入数据库的浏览量批次，保证每个批次只被应用一次
+
+CREATE TABLE `view_flush_batches` (
+  `batch_id` text PRIMARY KEY,
+  `applied_at` datetime NOT NULL
+);
+
+CREATE INDEX `idx_view_flush_batches_applied_at` ON `view_flush_batches` (`applied_at`);
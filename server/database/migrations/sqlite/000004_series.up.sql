@@ -0,0 +1,22 @@
+-- 系列文章：有序的多篇文章合集，一篇文章最多属于一个系列
+
+CREATE TABLE `series` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `title` text NOT NULL,
+  `slug` text NOT NULL,
+  `description` text,
+  `cover` text,
+  `created_at` datetime,
+  `updated_at` datetime,
+  CONSTRAINT `uni_series_slug` UNIQUE (`slug`)
+);
+
+CREATE TABLE `series_posts` (
+  `series_id` integer NOT NULL,
+  `post_id` integer NOT NULL,
+  `position` integer NOT NULL,
+  PRIMARY KEY (`series_id`,`post_id`),
+  CONSTRAINT `uni_series_posts_post_id` UNIQUE (`post_id`),
+  CONSTRAINT `fk_series_posts_series` FOREIGN KEY (`series_id`) REFERENCES `series`(`id`),
+  CONSTRAINT `fk_series_posts_post` FOREIGN KEY (`post_id`) REFERENCES `posts`(`id`)
+);
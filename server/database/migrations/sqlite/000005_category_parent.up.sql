@@ -0,0 +1,4 @@
+-- 分类支持父分类，parent_id 为空表示顶级分类
+
+ALTER TABLE `categories` ADD COLUMN `parent_id` integer REFERENCES `categories`(`id`);
+CREATE INDEX `idx_categories_parent_id` ON `categories` (`parent_id`);
@@ -0,0 +1,17 @@
+-- 文章草稿快照：编辑器自动保存的内容，每个用户对每篇已有文章最多一份，新文章的草稿不关联文章
+
+CREATE TABLE `post_drafts` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `user_id` integer NOT NULL,
+  `post_id` integer,
+  `title` text,
+  `content` text,
+  `category_id` integer,
+  `tags` text,
+  `created_at` datetime,
+  `updated_at` datetime,
+  CONSTRAINT `fk_post_drafts_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+  CONSTRAINT `fk_post_drafts_post` FOREIGN KEY (`post_id`) REFERENCES `posts`(`id`)
+);
+CREATE UNIQUE INDEX `idx_post_drafts_user_post` ON `post_drafts`(`user_id`,`post_id`);
+CREATE INDEX `idx_post_drafts_post_id` ON `post_drafts`(`post_id`);
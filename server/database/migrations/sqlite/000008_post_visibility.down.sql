@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS `idx_posts_visibility`;
+ALTER TABLE `posts` DROP COLUMN `password_hash`;
+ALTER TABLE `posts` DROP COLUMN `visibility`;
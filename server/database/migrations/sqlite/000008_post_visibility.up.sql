@@ -0,0 +1,5 @@
+-- 文章可见性：public 公开，unlisted 不出现在列表中但可通过链接访问，password 需要密码才能阅读
+
+ALTER TABLE `posts` ADD COLUMN `visibility` text NOT NULL DEFAULT 'public';
+ALTER TABLE `posts` ADD COLUMN `password_hash` text;
+CREATE INDEX `idx_posts_visibility` ON `posts` (`visibility`);
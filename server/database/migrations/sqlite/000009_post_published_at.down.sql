@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS `idx_posts_published_at`;
+ALTER TABLE `posts` DROP COLUMN `published_at`;
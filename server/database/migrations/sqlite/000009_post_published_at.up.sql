@@ -0,0 +1,6 @@
+-- 文章首次发布的时间，用于按发布时间排序和归档；已发布的文章以创建时间作为发布时间
+
+ALTER TABLE `posts` ADD COLUMN `published_at` datetime;
+CREATE INDEX `idx_posts_published_at` ON `posts` (`published_at`);
+
+UPDATE `posts` SET `published_at` = `created_at` WHERE `status` = 1;
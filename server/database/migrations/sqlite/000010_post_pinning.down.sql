@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS `idx_posts_featured`;
+DROP INDEX IF EXISTS `idx_posts_pinned_until`;
+ALTER TABLE `posts` DROP COLUMN `featured`;
+ALTER TABLE `posts` DROP COLUMN `pinned_until`;
+ALTER TABLE `posts` DROP COLUMN `pin_order`;
@@ -0,0 +1,15 @@
+-- 评论点赞和置顶：每个用户对每条评论最多点赞一次，点赞数冗余在 comments.upvote_count 中用于排序；每篇文章最多置顶一条评论
+
+ALTER TABLE `comments` ADD COLUMN `upvote_count` integer NOT NULL DEFAULT 0;
+ALTER TABLE `comments` ADD COLUMN `is_pinned` numeric NOT NULL DEFAULT false;
+CREATE INDEX `idx_comments_post_pinned` ON `comments` (`post_id`,`is_pinned`);
+
+CREATE TABLE `comment_votes` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `comment_id` integer NOT NULL,
+  `user_id` integer NOT NULL,
+  `created_at` datetime,
+  CONSTRAINT `fk_comment_votes_user` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`),
+  CONSTRAINT `fk_comment_votes_comment` FOREIGN KEY (`comment_id`) REFERENCES `comments`(`id`)
+);
+CREATE UNIQUE INDEX `idx_comment_votes_comment_user` ON `comment_votes`(`comment_id`,`user_id`);
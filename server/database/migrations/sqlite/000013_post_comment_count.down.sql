@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS `idx_posts_comment_count`;
+ALTER TABLE `posts` DROP COLUMN `comment_count`;
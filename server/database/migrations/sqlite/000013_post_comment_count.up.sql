@@ -0,0 +1,9 @@
+-- 文章评论数：冗余在 posts.comment_count 中，与评论的发表、删除、恢复和彻底删除在同一事务中更新，只统计未删除且已通过审核的评论
+
+ALTER TABLE `posts` ADD COLUMN `comment_count` integer NOT NULL DEFAULT 0;
+CREATE INDEX `idx_posts_comment_count` ON `posts` (`comment_count`,`id`);
+
+UPDATE `posts` SET `comment_count` = (
+  SELECT COUNT(*) FROM `comments`
+  WHERE `comments`.`post_id` = `posts`.`id` AND `comments`.`deleted_at` IS NULL AND `comments`.`status` = 1
+);
@@ -1,87 +0,0 @@
-package database
-
-import (
-	"fmt"
-	"log"
-	"time"
-
-	"gorm.io/driver/mysql"
-	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
-
-	"github.com/personal-blog/config"
-	"github.com/personal-blog/models"
-)
-
-var DB *gorm.DB
-
-// InitMySQL 初始化MySQL连接
-func InitMySQL() error {
-	cfg := config.GlobalConfig.Database
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local",
-		cfg.Username,
-		cfg.Password,
-		cfg.Host,
-		cfg.Port,
-		cfg.DBName,
-		cfg.Charset,
-	)
-
-	gormConfig := &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
-	}
-
-	if config.GlobalConfig.Server.Mode == "production" {
-		gormConfig.Logger = logger.Default.LogMode(logger.Error)
-	}
-
-	db, err := gorm.Open(mysql.Open(dsn), gormConfig)
-	if err != nil {
-		return fmt.Errorf("failed to connect to database: %v", err)
-	}
-
-	sqlDB, err := db.DB()
-	if err != nil {
-		return fmt.Errorf("failed to get database instance: %v", err)
-	}
-
-	// 设置连接池
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
-
-	// 自动迁移数据库表
-	err = autoMigrate(db)
-	if err != nil {
-		return fmt.Errorf("failed to auto migrate: %v", err)
-	}
-
-	DB = db
-	return nil
-}
-
-// autoMigrate 自动迁移数据库表
-func autoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
-		&models.User{},
-		&models.Post{},
-		&models.Category{},
-		&models.Tag{},
-		&models.Comment{},
-	)
-}
-
-// CloseDB 关闭数据库连接
-func CloseDB() {
-	if DB != nil {
-		sqlDB, err := DB.DB()
-		if err != nil {
-			log.Printf("Error getting database instance: %v", err)
-			return
-		}
-		err = sqlDB.Close()
-		if err != nil {
-			log.Printf("Error closing database connection: %v", err)
-		}
-	}
-}
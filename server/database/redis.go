@@ -2,24 +2,27 @@ package database
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/redis/go-redis/v9"
 
 	"github.com/personal-blog/config"
+	"github.com/personal-blog/pkg/tracing"
 )
 
 var RedisClient *redis.Client
 
 // InitRedis 初始化Redis连接
 func InitRedis() error {
-	cfg := config.GlobalConfig.Redis
+	cfg := config.Get().Redis
 
 	RedisClient = redis.NewClient(&redis.Options{
 		Addr:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
 		Password: cfg.Password,
 		DB:       cfg.DB,
 	})
+	RedisClient.AddHook(tracing.NewRedisHook())
 
 	// 测试连接
 	ctx := context.Background()
@@ -31,6 +34,14 @@ func InitRedis() error {
 	return nil
 }
 
+// PingRedis 检查Redis连接是否可用
+func PingRedis(ctx context.Context) error {
+	if RedisClient == nil {
+		return errors.New("redis not initialized")
+	}
+	return RedisClient.Ping(ctx).Err()
+}
+
 // CloseRedis 关闭Redis连接
 func CloseRedis() {
 	if RedisClient != nil {
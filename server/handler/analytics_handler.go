@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/personal-blog/handler/request"
+	"github.com/personal-blog/handler/response"
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/service"
+)
+
+const (
+	defaultAnalyticsLimit = 10
+	dateLayout            = "2006-01-02"
+)
+
+// AnalyticsHandler 浏览统计处理器
+type AnalyticsHandler struct {
+	analyticsService service.AnalyticsService
+}
+
+// NewAnalyticsHandler 创建浏览统计处理器实例
+func NewAnalyticsHandler(analyticsService service.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsService: analyticsService,
+	}
+}
+
+// PostViews 获取文章浏览量时间序列（管理员）
+func (h *AnalyticsHandler) PostViews(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return
+	}
+
+	var req request.ViewSeriesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+	from, to, ok := parseDateRange(c, &req.AnalyticsRangeRequest)
+	if !ok {
+		return
+	}
+	if req.Granularity == "" {
+		req.Granularity = models.GranularityDay
+	}
+
+	series, err := h.analyticsService.ViewSeries(c.Request.Context(), uint(id), req.Granularity, from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", series))
+}
+
+// PostReferrers 获取文章来源域名排行（管理员）
+func (h *AnalyticsHandler) PostReferrers(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return
+	}
+
+	var req request.AnalyticsRangeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+	from, to, ok := parseDateRange(c, &req)
+	if !ok {
+		return
+	}
+
+	referrers, err := h.analyticsService.TopReferrers(c.Request.Context(), uint(id), from, to, analyticsLimit(req.Limit))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", referrers))
+}
+
+// PostCampaigns 获取文章UTM推广活动排行（管理员）
+func (h *AnalyticsHandler) PostCampaigns(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return
+	}
+
+	var req request.AnalyticsRangeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+	from, to, ok := parseDateRange(c, &req)
+	if !ok {
+		return
+	}
+
+	campaigns, err := h.analyticsService.TopCampaigns(c.Request.Context(), uint(id), from, to, analyticsLimit(req.Limit))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", campaigns))
+}
+
+// TopPosts 获取指定日期范围内的热门文章（管理员）
+func (h *AnalyticsHandler) TopPosts(c *gin.Context) {
+	var req request.AnalyticsRangeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+	from, to, ok := parseDateRange(c, &req)
+	if !ok {
+		return
+	}
+
+	posts, err := h.analyticsService.TopPosts(c.Request.Context(), from, to, analyticsLimit(req.Limit))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", posts))
+}
+
+// Popular 获取本周最多阅读的文章
+func (h *AnalyticsHandler) Popular(c *gin.Context) {
+	var req request.PopularPostsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+
+	posts, err := h.analyticsService.MostReadThisWeek(c.Request.Context(), analyticsLimit(req.Limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", posts))
+}
+
+// parseDateRange 将包含起止两天的日期范围转换为 [from, to) 的UTC时间
+func parseDateRange(c *gin.Context, req *request.AnalyticsRangeRequest) (time.Time, time.Time, bool) {
+	from, err := time.Parse(dateLayout, req.From)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return time.Time{}, time.Time{}, false
+	}
+	to, err := time.Parse(dateLayout, req.To)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to.AddDate(0, 0, 1), true
+}
+
+func analyticsLimit(limit int) int {
+	if limit <= 0 {
+		return defaultAnalyticsLimit
+	}
+	return limit
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/personal-blog/handler/request"
 	"github.com/personal-blog/handler/response"
 	"github.com/personal-blog/models"
+	"github.com/personal-blog/repository/mysql"
 	"github.com/personal-blog/service"
 )
 
@@ -34,10 +36,11 @@ func (h *CategoryHandler) Create(c *gin.Context) {
 	category := &models.Category{
 		Name:        req.Name,
 		Description: req.Description,
+		ParentID:    req.ParentID,
 	}
 
-	if err := h.categoryService.CreateCategory(c, category); err != nil {
-		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+	if err := h.categoryService.CreateCategory(c.Request.Context(), category); err != nil {
+		h.parentError(c, err)
 		return
 	}
 
@@ -62,10 +65,11 @@ func (h *CategoryHandler) Update(c *gin.Context) {
 		ID:          uint(id),
 		Name:        req.Name,
 		Description: req.Description,
+		ParentID:    req.ParentID,
 	}
 
-	if err := h.categoryService.UpdateCategory(c, category); err != nil {
-		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+	if err := h.categoryService.UpdateCategory(c.Request.Context(), category); err != nil {
+		h.parentError(c, err)
 		return
 	}
 
@@ -80,14 +84,36 @@ func (h *CategoryHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.categoryService.DeleteCategory(c.Request.Context(), uint(id)); err != nil {
-		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+	var req request.DeleteCategoryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+
+	if err := h.categoryService.DeleteCategory(c.Request.Context(), uint(id), req.ReassignTo); err != nil {
+		if errors.Is(err, mysql.ErrCategoryNotEmpty) {
+			c.JSON(http.StatusConflict, response.NewResponse(http.StatusConflict, err.Error(), nil))
+			return
+		}
+		h.parentError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "删除成功", nil))
 }
 
+// parentError 返回父分类或转移目标校验失败的错误，其他错误按服务器错误处理
+func (h *CategoryHandler) parentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrParentNotFound):
+		c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "父分类或目标分类不存在", nil))
+	case errors.Is(err, service.ErrCategoryCycle):
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "不能设置为分类自身或其子分类", nil))
+	default:
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+	}
+}
+
 // Get 获取分类详情
 func (h *CategoryHandler) Get(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
@@ -122,6 +148,17 @@ func (h *CategoryHandler) List(c *gin.Context) {
 	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", response.NewPaginationResponse(categories, total, req.Page, req.PageSize)))
 }
 
+// Tree 获取分类树及各分类的文章数
+func (h *CategoryHandler) Tree(c *gin.Context) {
+	tree, err := h.categoryService.Tree(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", tree))
+}
+
 // UpdateStatus 更新分类状态
 func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
@@ -140,10 +177,11 @@ func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 		ID:     uint(id),
 		Name:   req.Name,
 		Description: req.Description,
+		ParentID: req.ParentID,
 	}
 
-	if err := h.categoryService.UpdateCategory(c, category); err != nil {
-		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+	if err := h.categoryService.UpdateCategory(c.Request.Context(), category); err != nil {
+		h.parentError(c, err)
 		return
 	}
 
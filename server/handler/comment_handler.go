@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -8,16 +9,20 @@ import (
 	"github.com/personal-blog/handler/request"
 	"github.com/personal-blog/handler/response"
 	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/utils"
 	"github.com/personal-blog/service"
+	"gorm.io/gorm"
 )
 
 type CommentHandler struct {
 	commentService service.CommentService
+	postService    service.PostService
 }
 
-func NewCommentHandler(commentService service.CommentService) *CommentHandler {
+func NewCommentHandler(commentService service.CommentService, postService service.PostService) *CommentHandler {
 	return &CommentHandler{
 		commentService: commentService,
+		postService:    postService,
 	}
 }
 
@@ -29,37 +34,75 @@ func (h *CommentHandler) Create(c *gin.Context) {
 		return
 	}
 
+	// 只能评论有权阅读的文章
+	if _, ok := visiblePost(c, h.postService, req.PostID); !ok {
+		return
+	}
+
 	userID, _ := c.Get("userID")
 	comment := &models.Comment{
-		PostID:   req.PostID,
-		UserID:   userID.(uint),
-		Content:  req.Content,
-		ParentID: &req.ParentID,
+		PostID:  req.PostID,
+		UserID:  userID.(uint),
+		Content: req.Content,
+	}
+	if req.ParentID != 0 {
+		comment.ParentID = &req.ParentID
 	}
 
-	if err := h.commentService.CreateComment(c, comment); err != nil {
+	if err := h.commentService.CreateComment(c.Request.Context(), comment); err != nil {
 		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
 		return
 	}
 
-	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "评论成功", comment))
+	msg := "评论成功"
+	if comment.Status == models.CommentStatusPending {
+		msg = "评论已提交，审核通过后显示"
+	}
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, msg, comment))
 }
 
 // ListByPost 获取文章评论列表
+// 传入limit或cursor时使用游标分页，否则使用page和page_size分页
 func (h *CommentHandler) ListByPost(c *gin.Context) {
-	postID, err := strconv.ParseUint(c.Param("postID"), 10, 64)
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
 		return
 	}
 
+	// 只能查看有权阅读的文章的评论
+	if _, ok := visiblePost(c, h.postService, uint(postID)); !ok {
+		return
+	}
+
+	if useCursor(c) {
+		var req request.ListCommentsCursorRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+			return
+		}
+
+		page, err := h.commentService.ListCommentsByPostCursor(c.Request.Context(), uint(postID), commentSort(&req.CommentSortRequest), cursorQuery(&req.CursorRequest))
+		if err != nil {
+			if errors.Is(err, utils.ErrInvalidCursor) {
+				c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "无效的分页游标", nil))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+			return
+		}
+
+		c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", response.NewCursorResponse(page.Comments, page.NextCursor, page.Total)))
+		return
+	}
+
 	var req request.ListCommentsRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
 		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
 		return
 	}
 
-	comments, total, err := h.commentService.ListCommentsByPost(c, uint(postID), req.Page, req.PageSize)
+	comments, total, err := h.commentService.ListCommentsByPost(c.Request.Context(), uint(postID), commentSort(&req.CommentSortRequest), req.Page, req.PageSize)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
 		return
@@ -68,6 +111,14 @@ func (h *CommentHandler) ListByPost(c *gin.Context) {
 	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", response.NewPaginationResponse(comments, total, req.Page, req.PageSize)))
 }
 
+// commentSort 将请求的排序方式转换为评论排序，newest 为默认排序
+func commentSort(req *request.CommentSortRequest) models.CommentSort {
+	if req.Sort == "newest" {
+		return models.CommentSortNewest
+	}
+	return models.CommentSort(req.Sort)
+}
+
 // Delete 删除评论
 func (h *CommentHandler) Delete(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
@@ -76,10 +127,169 @@ func (h *CommentHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.commentService.DeleteComment(c, uint(id)); err != nil {
+	// 管理员可以删除任意评论，其他用户只能删除自己的评论
+	if ownerID := ownerScope(c); ownerID != nil {
+		comment, err := h.commentService.GetCommentByID(c.Request.Context(), uint(id))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "评论不存在", nil))
+				return
+			}
+			c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+			return
+		}
+		if comment.UserID != *ownerID {
+			c.JSON(http.StatusForbidden, response.NewResponse(http.StatusForbidden, "权限不足", nil))
+			return
+		}
+	}
+
+	if err := h.commentService.DeleteComment(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "评论不存在", nil))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
 		return
 	}
 
-	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "删除成功", nil))
-}
\ No newline at end of file
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "已移入回收站", nil))
+}
+
+// Restore 从回收站恢复评论，所属文章在回收站中的评论需随文章一起恢复
+func (h *CommentHandler) Restore(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return
+	}
+
+	if err := h.commentService.RestoreComment(c.Request.Context(), uint(id), ownerScope(c)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "回收站中没有该评论", nil))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "恢复成功", nil))
+}
+
+// Trash 获取回收站中的评论，管理员可以看到全部评论，其他用户只能看到自己的评论
+func (h *CommentHandler) Trash(c *gin.Context) {
+	var req request.ListTrashRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+
+	comments, total, err := h.commentService.ListTrashedComments(c.Request.Context(), ownerScope(c), req.Page, req.PageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", response.NewPaginationResponse(comments, total, req.Page, req.PageSize)))
+}
+// Upvote 点赞评论，每个用户对每条评论只计一次
+func (h *CommentHandler) Upvote(c *gin.Context) {
+	comment, ok := h.visibleComment(c)
+	if !ok {
+		return
+	}
+
+	state, err := h.commentService.UpvoteComment(c.Request.Context(), comment.ID, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "点赞成功", state))
+}
+
+// RemoveUpvote 取消点赞评论
+func (h *CommentHandler) RemoveUpvote(c *gin.Context) {
+	comment, ok := h.visibleComment(c)
+	if !ok {
+		return
+	}
+
+	state, err := h.commentService.RemoveCommentUpvote(c.Request.Context(), comment.ID, c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "已取消点赞", state))
+}
+
+// Pin 置顶评论，每篇文章最多置顶一条，置顶新评论会取消原有的置顶
+func (h *CommentHandler) Pin(c *gin.Context) {
+	h.setPinned(c, true, "置顶成功")
+}
+
+// Unpin 取消置顶评论
+func (h *CommentHandler) Unpin(c *gin.Context) {
+	h.setPinned(c, false, "已取消置顶")
+}
+
+// setPinned 只有文章作者和管理员可以置顶文章下的评论
+func (h *CommentHandler) setPinned(c *gin.Context, pinned bool, msg string) {
+	comment, ok := h.visibleComment(c)
+	if !ok {
+		return
+	}
+	post, err := h.postService.GetPostByID(c.Request.Context(), comment.PostID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+	if post.UserID != c.GetUint("userID") && c.GetString("role") != "admin" {
+		c.JSON(http.StatusForbidden, response.NewResponse(http.StatusForbidden, "只有文章作者可以置顶评论", nil))
+		return
+	}
+
+	if err := h.commentService.PinComment(c.Request.Context(), comment, pinned); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "评论不存在", nil))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, msg, nil))
+}
+
+// visibleComment 读取路径中的评论，待审核的评论或所属文章对当前用户不可见时与评论不存在一样处理
+// 不可用时已写入错误响应
+func (h *CommentHandler) visibleComment(c *gin.Context) (*models.Comment, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return nil, false
+	}
+
+	comment, err := h.commentService.GetCommentByID(c.Request.Context(), uint(id))
+	if err == nil && comment.Status != models.CommentStatusApproved {
+		err = gorm.ErrRecordNotFound
+	}
+	if err == nil {
+		access := &service.PostAccess{Viewer: currentViewer(c), Password: c.GetHeader("X-Post-Password")}
+		_, err = h.postService.GetVisiblePost(c.Request.Context(), comment.PostID, access)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "评论不存在", nil))
+			return nil, false
+		}
+		if errors.Is(err, service.ErrPostPasswordRequired) {
+			c.JSON(http.StatusForbidden, response.NewResponse(http.StatusForbidden, "文章受密码保护，请提供正确的密码", nil))
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return nil, false
+	}
+	return comment, true
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/personal-blog/handler/request"
+	"github.com/personal-blog/handler/response"
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/service"
+	"gorm.io/gorm"
+)
+
+// DraftHandler 文章草稿处理器，草稿只对保存它的用户可见
+type DraftHandler struct {
+	draftService service.DraftService
+	postService  service.PostService
+}
+
+// NewDraftHandler 创建文章草稿处理器实例
+func NewDraftHandler(draftService service.DraftService, postService service.PostService) *DraftHandler {
+	return &DraftHandler{
+		draftService: draftService,
+		postService:  postService,
+	}
+}
+
+// Save 自动保存草稿，不会修改已发布的文章
+func (h *DraftHandler) Save(c *gin.Context) {
+	var req request.SaveDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+
+	draft := &models.PostDraft{
+		ID:         req.ID,
+		UserID:     c.GetUint("userID"),
+		Title:      req.Title,
+		Content:    req.Content,
+		CategoryID: req.CategoryID,
+		Tags:       req.Tags,
+	}
+	if req.PostID != 0 {
+		// 只能为自己有权编辑的文章保存草稿
+		if !authorizePost(c, h.postService, req.PostID) {
+			return
+		}
+		draft.PostID = &req.PostID
+	}
+
+	if err := h.draftService.SaveDraft(c.Request.Context(), draft); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "草稿不存在", nil))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "保存成功", draft))
+}
+
+// List 获取当前用户的草稿，按最近保存时间排序
+func (h *DraftHandler) List(c *gin.Context) {
+	drafts, err := h.draftService.ListDrafts(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", drafts))
+}
+
+// Get 获取草稿详情
+func (h *DraftHandler) Get(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return
+	}
+
+	draft, err := h.draftService.GetDraft(c.Request.Context(), c.GetUint("userID"), uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "草稿不存在", nil))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", draft))
+}
+
+// Delete 删除草稿
+func (h *DraftHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return
+	}
+
+	if err := h.draftService.DeleteDraft(c.Request.Context(), c.GetUint("userID"), uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "草稿不存在", nil))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "删除成功", nil))
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/personal-blog/handler/response"
+)
+
+// HealthCheck 单个依赖的就绪检查函数
+type HealthCheck func(ctx context.Context) error
+
+// HealthHandler 健康检查处理器
+type HealthHandler struct {
+	checks  map[string]HealthCheck
+	timeout time.Duration
+}
+
+// NewHealthHandler 创建健康检查处理器实例
+func NewHealthHandler(checks map[string]HealthCheck, timeout time.Duration) *HealthHandler {
+	return &HealthHandler{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// Liveness godoc
+// @Summary 存活检查
+// @Description 进程存活即返回200，不检查外部依赖
+// @Tags health
+// @Produce json
+// @Success 200 {object} response.Response "ok"
+// @Router /healthz [get]
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "ok", nil))
+}
+
+// Readiness godoc
+// @Summary 就绪检查
+// @Description 并发检查数据库、Redis等依赖，全部可用时返回200
+// @Tags health
+// @Produce json
+// @Success 200 {object} response.Response "ready"
+// @Failure 503 {object} response.Response "依赖不可用"
+// @Router /readyz [get]
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	results := make(map[string]string, len(h.checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ready := true
+
+	for name, check := range h.checks {
+		wg.Add(1)
+		go func(name string, check HealthCheck) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+			defer cancel()
+
+			status := "ok"
+			if err := check(ctx); err != nil {
+				status = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = status
+			if status != "ok" {
+				ready = false
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, response.NewResponse(http.StatusServiceUnavailable, "not ready", results))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "ready", results))
+}
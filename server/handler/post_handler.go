@@ -1,25 +1,41 @@
 package handler
 
 import (
+	"errors"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/personal-blog/handler/request"
 	"github.com/personal-blog/handler/response"
 	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/utils"
+	"github.com/personal-blog/repository/mysql"
 	"github.com/personal-blog/service"
+	"gorm.io/gorm"
 )
 
 // PostHandler 文章处理器
 type PostHandler struct {
-	postService service.PostService
+	postService     service.PostService
+	seriesService   service.SeriesService
+	categoryService service.CategoryService
+	draftService    service.DraftService
+	reactionService service.ReactionService
 }
 
 // NewPostHandler 创建文章处理器实例
-func NewPostHandler(postService service.PostService) *PostHandler {
+func NewPostHandler(postService service.PostService, seriesService service.SeriesService, categoryService service.CategoryService, draftService service.DraftService, reactionService service.ReactionService) *PostHandler {
 	return &PostHandler{
-		postService: postService,
+		postService:     postService,
+		seriesService:   seriesService,
+		categoryService: categoryService,
+		draftService:    draftService,
+		reactionService: reactionService,
 	}
 }
 
@@ -36,14 +52,28 @@ func (h *PostHandler) Create(c *gin.Context) {
 		Title:      req.Title,
 		Content:    req.Content,
 		CategoryID: req.CategoryID,
+		Status:     req.Status,
+		Visibility: req.Visibility,
+		Password:   req.Password,
 		UserID:     userID.(uint),
 	}
 
-	if err := h.postService.CreatePost(c, post, req.Tags); err != nil {
+	if err := h.postService.CreatePost(c.Request.Context(), post, req.Tags); err != nil {
+		if errors.Is(err, service.ErrPostPasswordMissing) {
+			c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "密码保护的文章需要设置密码", nil))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
 		return
 	}
 
+	// 文章已保存，删除对应的自动保存草稿，失败不影响创建结果
+	if req.DraftID != 0 {
+		if err := h.draftService.DeleteDraft(c.Request.Context(), post.UserID, req.DraftID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("delete draft %d after creating post %d: %v", req.DraftID, post.ID, err)
+		}
+	}
+
 	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "创建成功", post))
 }
 
@@ -61,18 +91,38 @@ func (h *PostHandler) Update(c *gin.Context) {
 		return
 	}
 
+	if !authorizePost(c, h.postService, uint(id)) {
+		return
+	}
+
 	post := &models.Post{
 		ID:         uint(id),
 		Title:      req.Title,
 		Content:    req.Content,
 		CategoryID: req.CategoryID,
+		Status:     req.Status,
+		Visibility: req.Visibility,
+		Password:   req.Password,
 	}
 
-	if err := h.postService.UpdatePost(c, post, req.Tags); err != nil {
+	if err := h.postService.UpdatePost(c.Request.Context(), post, req.Tags); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "文章不存在", nil))
+			return
+		}
+		if errors.Is(err, service.ErrPostPasswordMissing) {
+			c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "密码保护的文章需要设置密码", nil))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
 		return
 	}
 
+	// 文章已保存，删除当前用户对该文章的自动保存草稿，失败不影响更新结果
+	if err := h.draftService.DiscardPostDraft(c.Request.Context(), c.GetUint("userID"), post.ID); err != nil {
+		log.Printf("discard draft of post %d: %v", post.ID, err)
+	}
+
 	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "更新成功", post))
 }
 
@@ -84,12 +134,84 @@ func (h *PostHandler) Delete(c *gin.Context) {
 		return
 	}
 
-	if err := h.postService.DeletePost(c, uint(id)); err != nil {
+	if !authorizePost(c, h.postService, uint(id)) {
+		return
+	}
+
+	if err := h.postService.DeletePost(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "文章不存在", nil))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "已移入回收站", nil))
+}
+
+// PreviewLink 生成未发布文章的预览链接，持有链接的人无需登录即可在有效期内阅读
+func (h *PostHandler) PreviewLink(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return
+	}
+
+	var req request.PreviewLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+
+	if !authorizePost(c, h.postService, uint(id)) {
+		return
+	}
+
+	link, err := h.draftService.PreviewLink(c.Request.Context(), uint(id), time.Duration(req.ExpiresIn)*time.Second)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
 		return
 	}
 
-	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "删除成功", nil))
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "生成成功", link))
+}
+
+// Restore 从回收站恢复文章
+func (h *PostHandler) Restore(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return
+	}
+
+	if err := h.postService.RestorePost(c.Request.Context(), uint(id), ownerScope(c)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "回收站中没有该文章", nil))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "恢复成功", nil))
+}
+
+// Trash 获取回收站中的文章，管理员可以看到全部文章，其他用户只能看到自己的文章
+func (h *PostHandler) Trash(c *gin.Context) {
+	var req request.ListTrashRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+
+	posts, total, err := h.postService.ListTrashedPosts(c.Request.Context(), ownerScope(c), req.Page, req.PageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", response.NewPaginationResponse(posts, total, req.Page, req.PageSize)))
 }
 
 // Get 获取文章详情
@@ -100,45 +222,240 @@ func (h *PostHandler) Get(c *gin.Context) {
 		return
 	}
 
-	post, err := h.postService.GetPostByID(c, uint(id))
+	// 未发布的文章只有作者、编辑、管理员或持有预览链接的人可以访问，密码保护的文章通过请求头传入密码
+	access := &service.PostAccess{
+		Viewer:   currentViewer(c),
+		Preview:  c.Query("preview") != "" && h.draftService.VerifyPreview(uint(id), c.Query("preview")),
+		Password: c.GetHeader("X-Post-Password"),
+	}
+	post, err := h.postService.GetVisiblePost(c.Request.Context(), uint(id), access)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "文章不存在", nil))
+			return
+		}
+		if errors.Is(err, service.ErrPostPasswordRequired) {
+			c.JSON(http.StatusForbidden, response.NewResponse(http.StatusForbidden, "文章受密码保护，请提供正确的密码", nil))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
 		return
 	}
 
+	// 未发布和非公开的文章不允许缓存和收录，未发布文章的浏览不计入浏览量
+	published := post.Status == models.PostStatusPublished
+	if !published || post.Visibility != models.VisibilityPublic {
+		c.Header("Cache-Control", "private, no-store")
+		c.Header("X-Robots-Tag", "noindex")
+	}
+
+	// 面包屑、系列导航、浏览量和反应数失败不影响文章读取
+	if err := h.categoryService.AttachBreadcrumbs(c.Request.Context(), post); err != nil {
+		log.Printf("load breadcrumb for post %d: %v", post.ID, err)
+	}
+	if post.Series, err = h.seriesService.Navigation(c.Request.Context(), post.ID); err != nil {
+		log.Printf("load series navigation for post %d: %v", post.ID, err)
+	}
+	if err := h.postService.AttachViewCount(c.Request.Context(), post); err != nil {
+		log.Printf("load view count for post %d: %v", post.ID, err)
+	}
+	if published {
+		counts, err := h.reactionService.Counts(c.Request.Context(), []uint{post.ID})
+		if err != nil {
+			log.Printf("load reactions for post %d: %v", post.ID, err)
+		}
+		post.Reactions = counts[post.ID]
+	}
+
+	// 浏览量统计失败不影响文章读取
+	if published {
+		if err := h.postService.RecordView(c.Request.Context(), post.ID, viewInfo(c)); err != nil {
+			log.Printf("record view for post %d: %v", post.ID, err)
+		}
+	}
+
 	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", post))
 }
 
 // List 获取文章列表
-// List 获取文章列表
+// 传入limit或cursor时使用游标分页，否则使用page和page_size分页
 func (h *PostHandler) List(c *gin.Context) {
+	h.list(c, nil)
+}
+
+// Archive 获取按年月统计的文章归档
+func (h *PostHandler) Archive(c *gin.Context) {
+	years, err := h.postService.Archive(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", years))
+}
+
+// ArchivePosts 获取某年或某月发布的文章列表，其他筛选和分页参数与文章列表相同
+func (h *PostHandler) ArchivePosts(c *gin.Context) {
+	var archive request.ArchiveRequest
+	if err := c.ShouldBindUri(&archive); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return
+	}
+
+	h.list(c, &archive)
+}
+
+// list 获取文章列表，archive不为nil时按路径中的年月筛选，忽略查询参数中的year和month
+func (h *PostHandler) list(c *gin.Context, archive *request.ArchiveRequest) {
+	if useCursor(c) {
+		h.listByCursor(c, archive)
+		return
+	}
+
 	var req request.ListPostsRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
 		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
 		return
 	}
+	if archive != nil {
+		req.Year, req.Month = archive.Year, archive.Month
+	}
+
+	query, err := h.postQuery(c, &req.PostFilterRequest)
+	if err != nil {
+		code, msg := listError(err)
+		c.JSON(code, response.NewResponse(code, msg, nil))
+		return
+	}
 
-	conditions := make(map[string]interface{})
-	if req.Keyword != "" {
-		conditions["keyword"] = req.Keyword
+	posts, total, err := h.postService.ListPosts(c.Request.Context(), req.Page, req.PageSize, query, currentViewer(c))
+	if err != nil {
+		code, msg := listError(err)
+		c.JSON(code, response.NewResponse(code, msg, nil))
+		return
 	}
-	if req.CategoryID > 0 {
-		conditions["category_id"] = req.CategoryID
+	h.attachListBreadcrumbs(c, posts)
+	attachReactions(c, h.reactionService, posts)
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", response.NewPaginationResponse(posts, total, req.Page, req.PageSize)))
+}
+
+// listByCursor 按游标分页获取文章列表
+func (h *PostHandler) listByCursor(c *gin.Context, archive *request.ArchiveRequest) {
+	var req request.ListPostsCursorRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
 	}
-	if req.Tag != "" {
-		conditions["tag"] = req.Tag
+	if archive != nil {
+		req.Year, req.Month = archive.Year, archive.Month
 	}
-	if req.Status > 0 {
-		conditions["status"] = req.Status
+
+	query, err := h.postQuery(c, &req.PostFilterRequest)
+	if err != nil {
+		code, msg := listError(err)
+		c.JSON(code, response.NewResponse(code, msg, nil))
+		return
 	}
 
-	posts, total, err := h.postService.ListPosts(c, req.Page, req.PageSize, conditions)
+	page, err := h.postService.ListPostsByCursor(c.Request.Context(), query, currentViewer(c), cursorQuery(&req.CursorRequest))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		code, msg := listError(err)
+		c.JSON(code, response.NewResponse(code, msg, nil))
 		return
 	}
+	h.attachListBreadcrumbs(c, page.Posts)
+	attachReactions(c, h.reactionService, page.Posts)
 
-	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", response.NewPaginationResponse(posts, total, req.Page, req.PageSize)))
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", response.NewCursorResponse(page.Posts, page.NextCursor, page.Total)))
+}
+
+// errInvalidDateRange 归档年月与发布日期范围没有交集，或起始日期晚于结束日期
+var errInvalidDateRange = errors.New("发布日期范围无效")
+
+// postQuery 将请求的筛选和排序条件转换为文章查询
+func (h *PostHandler) postQuery(c *gin.Context, req *request.PostFilterRequest) (*models.PostQuery, error) {
+	q := &models.PostQuery{
+		Keyword:      req.Keyword,
+		MatchAllTags: req.TagMode == "all",
+		AuthorID:     req.AuthorID,
+		Status:       req.Status,
+		HasCover:     req.HasCover,
+		Pinned:       req.Pinned,
+		Featured:     req.Featured,
+		Sort:         models.PostSort(req.OrderBy),
+		Ascending:    req.Order == "asc",
+	}
+	if req.CategoryID > 0 && req.IncludeChildren {
+		ids, err := h.categoryService.DescendantIDs(c.Request.Context(), req.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		q.CategoryIDs = ids
+	} else if req.CategoryID > 0 {
+		q.CategoryIDs = []uint{req.CategoryID}
+	}
+	for _, tag := range req.Tags {
+		for _, name := range strings.Split(tag, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				q.Tags = append(q.Tags, name)
+			}
+		}
+	}
+
+	// 日期按服务器时区解释，结束日期包含当天
+	if req.From != "" {
+		from, _ := time.ParseInLocation(dateLayout, req.From, time.Local)
+		q.PublishedFrom = &from
+	}
+	if req.To != "" {
+		to, _ := time.ParseInLocation(dateLayout, req.To, time.Local)
+		to = to.AddDate(0, 0, 1)
+		q.PublishedTo = &to
+	}
+	if req.Year > 0 {
+		start := time.Date(req.Year, time.January, 1, 0, 0, 0, 0, time.Local)
+		end := start.AddDate(1, 0, 0)
+		if req.Month > 0 {
+			start = time.Date(req.Year, time.Month(req.Month), 1, 0, 0, 0, 0, time.Local)
+			end = start.AddDate(0, 1, 0)
+		}
+		if q.PublishedFrom == nil || q.PublishedFrom.Before(start) {
+			q.PublishedFrom = &start
+		}
+		if q.PublishedTo == nil || q.PublishedTo.After(end) {
+			q.PublishedTo = &end
+		}
+	}
+	if q.PublishedFrom != nil && q.PublishedTo != nil && !q.PublishedFrom.Before(*q.PublishedTo) {
+		return nil, errInvalidDateRange
+	}
+	return q, nil
+}
+
+// listError 返回文章列表查询错误对应的状态码和提示
+func listError(err error) (int, string) {
+	switch {
+	case errors.Is(err, errInvalidDateRange):
+		return http.StatusBadRequest, err.Error()
+	case errors.Is(err, utils.ErrInvalidCursor):
+		return http.StatusBadRequest, "无效的分页游标"
+	case errors.Is(err, mysql.ErrInvalidPostSort):
+		return http.StatusBadRequest, "不支持的排序方式"
+	default:
+		return http.StatusInternalServerError, err.Error()
+	}
+}
+
+// attachListBreadcrumbs 为列表中的文章加载分类面包屑，失败不影响列表读取
+func (h *PostHandler) attachListBreadcrumbs(c *gin.Context, posts []models.Post) {
+	items := make([]*models.Post, len(posts))
+	for i := range posts {
+		items[i] = &posts[i]
+	}
+	if err := h.categoryService.AttachBreadcrumbs(c.Request.Context(), items...); err != nil {
+		log.Printf("load breadcrumbs for post list: %v", err)
+	}
 }
 
 // UpdateStatus 更新文章状态
@@ -155,15 +472,243 @@ func (h *PostHandler) UpdateStatus(c *gin.Context) {
 		return
 	}
 
+	if !authorizePost(c, h.postService, uint(id)) {
+		return
+	}
+
 	post := &models.Post{
 		ID:     uint(id),
 		Status: req.Status,
 	}
 
-	if err := h.postService.UpdatePost(c, post, nil); err != nil {
+	if err := h.postService.UpdatePost(c.Request.Context(), post, nil); err != nil {
 		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
 		return
 	}
 
 	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "更新成功", nil))
 }
+
+// Related 获取相关文章
+func (h *PostHandler) Related(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return
+	}
+
+	var req request.RelatedPostsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 5
+	}
+
+	// 无权阅读的文章与不存在的文章一样处理，不暴露其相关文章
+	if _, ok := visiblePost(c, h.postService, uint(id)); !ok {
+		return
+	}
+
+	posts, err := h.postService.RelatedPosts(c.Request.Context(), uint(id), req.Limit)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "文章不存在", nil))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", posts))
+}
+
+// Pin 置顶文章（管理员）
+func (h *PostHandler) Pin(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return
+	}
+
+	var req request.PinPostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+
+	if err := h.postService.PinPost(c.Request.Context(), uint(id), req.Order, req.ExpiresAt); err != nil {
+		h.pinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "置顶成功", nil))
+}
+
+// Unpin 取消文章置顶（管理员）
+func (h *PostHandler) Unpin(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return
+	}
+
+	if err := h.postService.UnpinPost(c.Request.Context(), uint(id)); err != nil {
+		h.pinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "已取消置顶", nil))
+}
+
+// Feature 将文章设为精选（管理员）
+func (h *PostHandler) Feature(c *gin.Context) {
+	h.setFeatured(c, true, "已设为精选")
+}
+
+// Unfeature 取消文章的精选（管理员）
+func (h *PostHandler) Unfeature(c *gin.Context) {
+	h.setFeatured(c, false, "已取消精选")
+}
+
+func (h *PostHandler) setFeatured(c *gin.Context, featured bool, msg string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return
+	}
+
+	if err := h.postService.SetFeatured(c.Request.Context(), uint(id), featured); err != nil {
+		h.pinError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, msg, nil))
+}
+
+// pinError 返回置顶和精选操作的错误
+func (h *PostHandler) pinError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "文章不存在", nil))
+	case errors.Is(err, service.ErrPinExpired):
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "置顶到期时间必须晚于当前时间", nil))
+	default:
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+	}
+}
+
+// Featured 获取当前的精选文章
+func (h *PostHandler) Featured(c *gin.Context) {
+	var req request.FeaturedPostsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 10
+	}
+
+	posts, err := h.postService.FeaturedPosts(c.Request.Context(), req.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+	attachReactions(c, h.reactionService, posts)
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", posts))
+}
+
+// viewInfo 从请求中提取浏览来源，前端路由的站内跳转可以通过 ref 参数传入原始来源
+func viewInfo(c *gin.Context) *service.ViewInfo {
+	referrer := c.Query("ref")
+	if referrer == "" {
+		referrer = c.Request.Referer()
+	}
+	return &service.ViewInfo{
+		ClientIP:  c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Host:      c.Request.Host,
+		Referrer:  referrer,
+		Source:    c.Query("utm_source"),
+		Medium:    c.Query("utm_medium"),
+		Campaign:  c.Query("utm_campaign"),
+	}
+}
+
+// useCursor 请求是否使用游标分页
+func useCursor(c *gin.Context) bool {
+	return c.Query("limit") != "" || c.Query("cursor") != ""
+}
+
+// cursorQuery 将游标分页请求转换为服务层参数，未指定count时不返回总数
+func cursorQuery(req *request.CursorRequest) *service.CursorQuery {
+	count := service.CountMode(req.Count)
+	if count == "" {
+		count = service.CountNone
+	}
+	return &service.CursorQuery{Cursor: req.Cursor, Limit: req.Limit, Count: count}
+}
+
+// currentViewer 当前访问者，未登录时返回nil
+func currentViewer(c *gin.Context) *service.Viewer {
+	userID, ok := c.Get("userID")
+	if !ok {
+		return nil
+	}
+	return &service.Viewer{UserID: userID.(uint), Role: c.GetString("role")}
+}
+
+// ownerScope 删除和回收站操作的范围：管理员返回nil表示不限，其他用户只能操作自己的内容
+func ownerScope(c *gin.Context) *uint {
+	if c.GetString("role") == "admin" {
+		return nil
+	}
+	userID := c.GetUint("userID")
+	return &userID
+}
+
+// visiblePost 按当前访问者的权限读取文章，密码保护的文章通过请求头传入密码
+// 无权查看时已写入错误响应
+func visiblePost(c *gin.Context, postService service.PostService, id uint) (*models.Post, bool) {
+	access := &service.PostAccess{Viewer: currentViewer(c), Password: c.GetHeader("X-Post-Password")}
+	post, err := postService.GetVisiblePost(c.Request.Context(), id, access)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "文章不存在", nil))
+			return nil, false
+		}
+		if errors.Is(err, service.ErrPostPasswordRequired) {
+			c.JSON(http.StatusForbidden, response.NewResponse(http.StatusForbidden, "文章受密码保护，请提供正确的密码", nil))
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return nil, false
+	}
+	return post, true
+}
+
+// authorizePost 校验当前用户能否管理文章：管理员可以管理任意文章，其他用户只能管理自己的文章
+// 校验失败时已写入响应
+func authorizePost(c *gin.Context, postService service.PostService, id uint) bool {
+	ownerID := ownerScope(c)
+	if ownerID == nil {
+		return true
+	}
+
+	post, err := postService.GetPostByID(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "文章不存在", nil))
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return false
+	}
+	if post.UserID != *ownerID {
+		c.JSON(http.StatusForbidden, response.NewResponse(http.StatusForbidden, "权限不足", nil))
+		return false
+	}
+	return true
+}
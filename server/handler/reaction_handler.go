@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/personal-blog/config"
+	"github.com/personal-blog/handler/request"
+	"github.com/personal-blog/handler/response"
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/service"
+	"gorm.io/gorm"
+)
+
+// visitorCookie 匿名访客标识的cookie名称
+const visitorCookie = "blog_visitor"
+
+type ReactionHandler struct {
+	reactionService service.ReactionService
+	postService     service.PostService
+}
+
+// NewReactionHandler 创建文章反应处理器实例
+func NewReactionHandler(reactionService service.ReactionService, postService service.PostService) *ReactionHandler {
+	return &ReactionHandler{
+		reactionService: reactionService,
+		postService:     postService,
+	}
+}
+
+// State 获取文章的反应总数以及当前访问者已做出的反应
+func (h *ReactionHandler) State(c *gin.Context) {
+	postID, ok := h.reactablePost(c)
+	if !ok {
+		return
+	}
+
+	state, err := h.reactionService.State(c.Request.Context(), postID, h.reactor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", state))
+}
+
+// React 对文章做出反应，同一访问者对同一类型只计一次
+func (h *ReactionHandler) React(c *gin.Context) {
+	var req request.ReactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return
+	}
+	postID, ok := h.reactablePost(c)
+	if !ok {
+		return
+	}
+
+	state, err := h.reactionService.React(c.Request.Context(), postID, req.Type, h.reactor(c))
+	if err != nil {
+		h.reactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "操作成功", state))
+}
+
+// Unreact 撤销对文章的反应
+func (h *ReactionHandler) Unreact(c *gin.Context) {
+	postID, ok := h.reactablePost(c)
+	if !ok {
+		return
+	}
+
+	state, err := h.reactionService.Unreact(c.Request.Context(), postID, c.Param("type"), h.reactor(c))
+	if err != nil {
+		h.reactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "操作成功", state))
+}
+
+// ListMine 获取当前用户做出过反应的文章，按反应时间倒序
+func (h *ReactionHandler) ListMine(c *gin.Context) {
+	var req request.ListReactedPostsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+	if req.Type == "" {
+		req.Type = models.ReactionLike
+	}
+
+	posts, total, err := h.reactionService.ListReactedPosts(c.Request.Context(), c.GetUint("userID"), req.Type, req.Page, req.PageSize)
+	if err != nil {
+		h.reactionError(c, err)
+		return
+	}
+	attachReactions(c, h.reactionService, posts)
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", response.NewPaginationResponse(posts, total, req.Page, req.PageSize)))
+}
+
+// reactablePost 解析路径中的文章ID并确认访问者可以对其做出反应，只有访问者可见的已发布文章可以做出反应
+// 不可用时已写入错误响应
+func (h *ReactionHandler) reactablePost(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return 0, false
+	}
+
+	access := &service.PostAccess{Viewer: currentViewer(c), Password: c.GetHeader("X-Post-Password")}
+	post, err := h.postService.GetVisiblePost(c.Request.Context(), uint(id), access)
+	if err == nil && post.Status != models.PostStatusPublished {
+		err = gorm.ErrRecordNotFound
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "文章不存在", nil))
+			return 0, false
+		}
+		if errors.Is(err, service.ErrPostPasswordRequired) {
+			c.JSON(http.StatusForbidden, response.NewResponse(http.StatusForbidden, "文章受密码保护，请提供正确的密码", nil))
+			return 0, false
+		}
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return 0, false
+	}
+	return post.ID, true
+}
+
+// reactor 返回当前访问者：登录用户使用用户ID；匿名访客使用签名cookie中的标识，
+// 没有有效cookie时由IP和User-Agent生成标识并下发cookie，不接受cookie的客户端仍按指纹去重
+func (h *ReactionHandler) reactor(c *gin.Context) *service.Reactor {
+	if userID, ok := c.Get("userID"); ok {
+		return &service.Reactor{UserID: userID.(uint)}
+	}
+	if token, err := c.Cookie(visitorCookie); err == nil {
+		if visitor, ok := service.VerifyVisitor(token); ok {
+			return &service.Reactor{Visitor: visitor}
+		}
+	}
+
+	visitor, token := service.NewVisitor(c.ClientIP(), c.Request.UserAgent())
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(visitorCookie, token, config.Get().Reaction.AnonymousTTL, "/", "", c.Request.TLS != nil, true)
+	return &service.Reactor{Visitor: visitor}
+}
+
+// reactionError 返回反应操作的错误
+func (h *ReactionHandler) reactionError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidReactionType) {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "不支持的反应类型", nil))
+		return
+	}
+	c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+}
+
+// attachReactions 为列表中的文章加载反应总数，失败不影响列表读取
+func attachReactions(c *gin.Context, reactionService service.ReactionService, posts []models.Post) {
+	if len(posts) == 0 {
+		return
+	}
+	ids := make([]uint, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+	}
+	counts, err := reactionService.Counts(c.Request.Context(), ids)
+	if err != nil {
+		log.Printf("load reactions for post list: %v", err)
+		return
+	}
+	for i := range posts {
+		posts[i].Reactions = counts[posts[i].ID]
+	}
+}
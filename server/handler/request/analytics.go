@@ -0,0 +1,19 @@
+package request
+
+// AnalyticsRangeRequest 统计查询的日期范围，日期格式为 YYYY-MM-DD（UTC），包含起止两天
+type AnalyticsRangeRequest struct {
+	From  string `form:"from" binding:"required,datetime=2006-01-02"`
+	To    string `form:"to" binding:"required,datetime=2006-01-02"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+// ViewSeriesRequest 文章浏览量时间序列请求
+type ViewSeriesRequest struct {
+	Granularity string `form:"granularity" binding:"omitempty,oneof=hour day"` // 默认 day
+	AnalyticsRangeRequest
+}
+
+// PopularPostsRequest 热门文章请求
+type PopularPostsRequest struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=50"`
+}
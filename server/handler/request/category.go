@@ -4,12 +4,19 @@ package request
 type CreateCategoryRequest struct {
 	Name        string `json:"name" binding:"required,min=1,max=50"`
 	Description string `json:"description" binding:"omitempty,max=200"`
+	ParentID    *uint  `json:"parent_id" binding:"omitempty,min=1"` // 为空表示顶级分类
 }
 
 // UpdateCategoryRequest 更新分类请求
 type UpdateCategoryRequest struct {
 	Name        string `json:"name" binding:"required,min=1,max=50"`
 	Description string `json:"description" binding:"omitempty,max=200"`
+	ParentID    *uint  `json:"parent_id" binding:"omitempty,min=1"` // 为空表示移动到顶级
+}
+
+// DeleteCategoryRequest 删除分类请求
+type DeleteCategoryRequest struct {
+	ReassignTo *uint `form:"reassign_to" binding:"omitempty,min=1"` // 子分类和文章转移到的分类
 }
 
 // ListCategoriesRequest 分类列表请求
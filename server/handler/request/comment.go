@@ -7,10 +7,21 @@ type CreateCommentRequest struct {
 	ParentID uint  `json:"parent_id" binding:"omitempty,min=1"`
 }
 
-// ListCommentsRequest 评论列表请求
+// CommentSortRequest 评论列表的排序方式：newest 最新优先（默认），oldest 最早优先，top 点赞数最多优先
+type CommentSortRequest struct {
+	Sort string `form:"sort" binding:"omitempty,oneof=newest oldest top"`
+}
+
+// ListCommentsRequest 评论列表请求，文章ID通过路径传入
 type ListCommentsRequest struct {
-	PostID uint `form:"post_id" binding:"required,min=1"`
 	SearchRequest
+	CommentSortRequest
+}
+
+// ListCommentsCursorRequest 按游标分页的评论列表请求
+type ListCommentsCursorRequest struct {
+	CursorRequest
+	CommentSortRequest
 }
 
 // UpdateCommentStatusRequest 更新评论状态请求
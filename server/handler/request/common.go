@@ -6,6 +6,13 @@ type PaginationRequest struct {
 	PageSize int `form:"page_size" binding:"required,min=1,max=100"`
 }
 
+// CursorRequest 游标分页请求的通用结构，第一页不传cursor，之后传入上一页返回的next_cursor
+type CursorRequest struct {
+	Cursor string `form:"cursor" binding:"omitempty,max=512"`
+	Limit  int    `form:"limit" binding:"required,min=1,max=100"`
+	Count  string `form:"count" binding:"omitempty,oneof=none exact approx"` // 总数：none 不返回（默认），exact 精确计数，approx 近似值
+}
+
 // SearchRequest 搜索请求的通用结构
 type SearchRequest struct {
 	Keyword string `form:"keyword" binding:"omitempty,min=1"`
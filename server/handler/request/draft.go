@@ -0,0 +1,17 @@
+package request
+
+// SaveDraftRequest 自动保存草稿请求
+// 编辑已有文章时传 post_id；新文章第一次保存不传 id，之后使用返回的草稿ID
+type SaveDraftRequest struct {
+	ID         uint     `json:"id" binding:"omitempty,min=1,excluded_with=PostID"`
+	PostID     uint     `json:"post_id" binding:"omitempty,min=1"`
+	Title      string   `json:"title" binding:"max=200"`
+	Content    string   `json:"content" binding:"max=200000"`
+	CategoryID uint     `json:"category_id"`
+	Tags       []string `json:"tags" binding:"omitempty,max=20,dive,max=50"`
+}
+
+// PreviewLinkRequest 生成预览链接请求
+type PreviewLinkRequest struct {
+	ExpiresIn int `json:"expires_in" binding:"omitempty,min=60,max=604800"` // 有效期（秒），默认24小时，最长7天
+}
@@ -1,12 +1,17 @@
 package request
 
+import "time"
+
 // CreatePostRequest 创建文章请求
 type CreatePostRequest struct {
 	Title      string   `json:"title" binding:"required,min=1,max=100"`
 	Content    string   `json:"content" binding:"required,min=1"`
 	CategoryID uint     `json:"category_id" binding:"required"`
 	Tags       []string `json:"tags" binding:"omitempty,dive,min=1"`
-	Status     int      `json:"status" binding:"required,oneof=1 2"` // 1:公开 2:草稿
+	Status     int      `json:"status" binding:"required,oneof=1 2"`                           // 1:公开 2:草稿
+	DraftID    uint     `json:"draft_id" binding:"omitempty,min=1"`                            // 由自动保存的草稿创建时传入，创建成功后删除该草稿
+	Visibility string   `json:"visibility" binding:"omitempty,oneof=public unlisted password"` // 默认public
+	Password   string   `json:"password" binding:"omitempty,min=4,max=64"`                     // visibility为password时必填
 }
 
 // UpdatePostRequest 更新文章请求
@@ -14,19 +19,67 @@ type UpdatePostRequest struct {
 	Title      string   `json:"title" binding:"required,min=1,max=100"`
 	Content    string   `json:"content" binding:"required,min=1"`
 	CategoryID uint     `json:"category_id" binding:"required"`
-	Tags       []string `json:"tags" binding:"omitempty,dive,min=1"`
-	Status     int      `json:"status" binding:"required,oneof=1 2"` // 1:公开 2:草稿
+	Tags       []string `json:"tags" binding:"omitempty,dive,min=1"`                           // 不传或为null时保留原有标签，传空数组时清空标签
+	Status     int      `json:"status" binding:"required,oneof=1 2"`                           // 1:公开 2:草稿
+	Visibility string   `json:"visibility" binding:"omitempty,oneof=public unlisted password"` // 为空时不修改
+	Password   string   `json:"password" binding:"omitempty,min=4,max=64"`                     // 为空时保留原密码
+}
+
+// PostFilterRequest 文章列表筛选和排序条件，各条件之间为"且"的关系
+type PostFilterRequest struct {
+	Keyword         string   `form:"keyword" binding:"omitempty,min=1"`
+	CategoryID      uint     `form:"category_id" binding:"omitempty,min=1"`
+	IncludeChildren bool     `form:"include_children"`                                 // 按分类筛选时包含子孙分类的文章
+	Tags            []string `form:"tag" binding:"omitempty,max=10,dive,min=1,max=50"` // 可重复传入，也可用逗号分隔多个标签
+	TagMode         string   `form:"tag_mode" binding:"omitempty,oneof=any all"`       // any 包含任一标签（默认），all 包含全部标签
+	AuthorID        uint     `form:"author_id" binding:"omitempty,min=1"`
+	From            string   `form:"from" binding:"omitempty,datetime=2006-01-02"`                 // 发布日期下限（含）
+	To              string   `form:"to" binding:"omitempty,datetime=2006-01-02"`                   // 发布日期上限（含）
+	Year            int      `form:"year" binding:"omitempty,min=1970,max=9999"`                   // 按发布年份归档
+	Month           int      `form:"month" binding:"omitempty,min=1,max=12,excluded_without=Year"` // 按发布月份归档，需同时传入year
+	HasCover        *bool    `form:"has_cover"`
+	Pinned          *bool    `form:"pinned"`
+	Featured        *bool    `form:"featured"`
+	Status          int      `form:"status" binding:"omitempty,oneof=1 2"`                                                           // 1:公开 2:草稿
+	OrderBy         string   `form:"order_by" binding:"omitempty,oneof=published_at created_at updated_at view_count comment_count"` // 默认置顶优先，再按发布时间
+	Order           string   `form:"order" binding:"omitempty,oneof=asc desc"`
 }
 
 // ListPostsRequest 文章列表请求
 type ListPostsRequest struct {
-	CategoryID uint   `form:"category_id" binding:"omitempty,min=1"`
-	Tag        string `form:"tag" binding:"omitempty,min=1"`
-	Status     int    `form:"status" binding:"omitempty,oneof=1 2"` // 1:公开 2:草稿
-	SearchRequest
+	PostFilterRequest
+	PaginationRequest
+}
+
+// ListPostsCursorRequest 文章列表游标分页请求
+type ListPostsCursorRequest struct {
+	PostFilterRequest
+	CursorRequest
+}
+
+// ArchiveRequest 按年或年月归档的路径参数
+type ArchiveRequest struct {
+	Year  int `uri:"year" binding:"required,min=1970,max=9999"`
+	Month int `uri:"month" binding:"omitempty,min=1,max=12"`
+}
+
+// PinPostRequest 置顶文章请求
+type PinPostRequest struct {
+	Order     int        `json:"order" binding:"omitempty,min=0,max=10000"` // 置顶文章之间的顺序，越小越靠前
+	ExpiresAt *time.Time `json:"expires_at"`                                // 到期后自动取消置顶，为空表示不过期
+}
+
+// FeaturedPostsRequest 精选文章请求
+type FeaturedPostsRequest struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=20"`
 }
 
 // UpdatePostStatusRequest 更新文章状态请求
 type UpdatePostStatusRequest struct {
 	Status int `json:"status" binding:"required,oneof=1 2"` // 1:公开 2:草稿
 }
+
+// RelatedPostsRequest 相关文章请求
+type RelatedPostsRequest struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=20"`
+}
@@ -0,0 +1,12 @@
+package request
+
+// ReactRequest 对文章做出反应的请求
+type ReactRequest struct {
+	Type string `json:"type" binding:"required,max=20"`
+}
+
+// ListReactedPostsRequest 当前用户做出过反应的文章列表请求，不传type时返回点赞过的文章
+type ListReactedPostsRequest struct {
+	Type string `form:"type" binding:"omitempty,max=20"`
+	PaginationRequest
+}
@@ -0,0 +1,27 @@
+package request
+
+// CreateSeriesRequest 创建系列请求
+type CreateSeriesRequest struct {
+	Title       string `json:"title" binding:"required,min=1,max=200"`
+	Slug        string `json:"slug" binding:"required,min=1,max=100"` // 小写字母、数字和连字符
+	Description string `json:"description" binding:"omitempty,max=1000"`
+	Cover       string `json:"cover" binding:"omitempty,max=255"`
+}
+
+// UpdateSeriesRequest 更新系列请求
+type UpdateSeriesRequest struct {
+	Title       string `json:"title" binding:"required,min=1,max=200"`
+	Slug        string `json:"slug" binding:"required,min=1,max=100"`
+	Description string `json:"description" binding:"omitempty,max=1000"`
+	Cover       string `json:"cover" binding:"omitempty,max=255"`
+}
+
+// SetSeriesPostsRequest 设置系列文章请求，数组顺序即文章顺序
+type SetSeriesPostsRequest struct {
+	PostIDs []uint `json:"post_ids" binding:"omitempty,max=200,dive,min=1"`
+}
+
+// ListSeriesRequest 系列列表请求
+type ListSeriesRequest struct {
+	PaginationRequest
+}
@@ -19,3 +19,19 @@ type CreateTagsRequest struct {
 type ListTagsRequest struct {
 	SearchRequest
 }
+
+// AddTagAliasRequest 添加标签别名请求
+type AddTagAliasRequest struct {
+	Alias string `json:"alias" binding:"required,min=1,max=50"`
+}
+
+// MergeTagsRequest 合并标签请求
+type MergeTagsRequest struct {
+	SourceIDs []uint `json:"source_ids" binding:"required,min=1,max=100,dive,min=1"`
+	TargetID  uint   `json:"target_id" binding:"required,min=1"`
+}
+
+// TagCloudRequest 标签云请求
+type TagCloudRequest struct {
+	Limit int `form:"limit" binding:"omitempty,min=1,max=200"`
+}
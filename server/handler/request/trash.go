@@ -0,0 +1,6 @@
+package request
+
+// ListTrashRequest 回收站列表请求
+type ListTrashRequest struct {
+	PaginationRequest
+}
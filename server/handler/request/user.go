@@ -1,12 +1,11 @@
 package request
 
-// RegisterRequest 用户注册请求
+// RegisterRequest 用户注册请求，注册的用户均为普通用户，角色由管理员分配
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=32"`
 	Password string `json:"password" binding:"required,min=6,max=32"`
 	Email    string `json:"email" binding:"required,email"`
 	Nickname string `json:"nickname" binding:"required,min=2,max=32"`
-	Role     string `json:"role" binding:"required,oneof=admin user"`
 }
 
 // LoginRequest 用户登录请求
@@ -37,3 +36,8 @@ type ListUsersRequest struct {
 type UpdateUserStatusRequest struct {
 	StatusRequest
 }
+
+// UpdateUserRoleRequest 分配用户角色请求
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin editor user"`
+}
@@ -15,6 +15,14 @@ type PaginationData struct {
 	Items    interface{} `json:"items"`     // 数据列表
 }
 
+// CursorData represents cursor pagination information
+type CursorData struct {
+	NextCursor string      `json:"next_cursor"`     // 下一页游标，为空表示没有下一页
+	HasMore    bool        `json:"has_more"`        // 是否还有下一页
+	Total      *int64      `json:"total,omitempty"` // 总数，仅在请求时返回
+	Items      interface{} `json:"items"`           // 数据列表
+}
+
 // NewResponse creates a new response
 func NewResponse(code int, msg string, data interface{}) *Response {
 	return &Response{
@@ -33,3 +41,13 @@ func NewPaginationResponse(items interface{}, total int64, page, pageSize int) *
 		Items:    items,
 	})
 }
+
+// NewCursorResponse creates a new cursor pagination response
+func NewCursorResponse(items interface{}, nextCursor string, total *int64) *Response {
+	return NewResponse(200, "success", CursorData{
+		NextCursor: nextCursor,
+		HasMore:    nextCursor != "",
+		Total:      total,
+		Items:      items,
+	})
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/personal-blog/handler/request"
+	"github.com/personal-blog/handler/response"
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/repository/mysql"
+	"github.com/personal-blog/service"
+	"gorm.io/gorm"
+)
+
+// SeriesHandler 系列处理器
+type SeriesHandler struct {
+	seriesService service.SeriesService
+}
+
+// NewSeriesHandler 创建系列处理器实例
+func NewSeriesHandler(seriesService service.SeriesService) *SeriesHandler {
+	return &SeriesHandler{
+		seriesService: seriesService,
+	}
+}
+
+// Create 创建系列
+func (h *SeriesHandler) Create(c *gin.Context) {
+	var req request.CreateSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+
+	series := &models.Series{
+		Title:       req.Title,
+		Slug:        req.Slug,
+		Description: req.Description,
+		Cover:       req.Cover,
+	}
+
+	if err := h.seriesService.CreateSeries(c.Request.Context(), series); err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "创建成功", series))
+}
+
+// Update 更新系列
+func (h *SeriesHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return
+	}
+
+	var req request.UpdateSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+
+	series := &models.Series{
+		ID:          uint(id),
+		Title:       req.Title,
+		Slug:        req.Slug,
+		Description: req.Description,
+		Cover:       req.Cover,
+	}
+
+	if err := h.seriesService.UpdateSeries(c.Request.Context(), series); err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "更新成功", series))
+}
+
+// Delete 删除系列，系列中的文章保留
+func (h *SeriesHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return
+	}
+
+	if err := h.seriesService.DeleteSeries(c.Request.Context(), uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "删除成功", nil))
+}
+
+// Get 获取系列详情及已发布的文章，参数可以是ID或slug
+func (h *SeriesHandler) Get(c *gin.Context) {
+	var (
+		series *models.Series
+		err    error
+	)
+	if id, parseErr := strconv.ParseUint(c.Param("id"), 10, 64); parseErr == nil {
+		series, err = h.seriesService.GetSeries(c.Request.Context(), uint(id), false)
+	} else {
+		series, err = h.seriesService.GetSeriesBySlug(c.Request.Context(), c.Param("id"), false)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "系列不存在", nil))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", series))
+}
+
+// List 获取系列列表
+func (h *SeriesHandler) List(c *gin.Context) {
+	var req request.ListSeriesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+
+	series, total, err := h.seriesService.ListSeries(c.Request.Context(), req.Page, req.PageSize)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", response.NewPaginationResponse(series, total, req.Page, req.PageSize)))
+}
+
+// SetPosts 设置系列中的文章及顺序，返回包含草稿在内的完整文章列表
+func (h *SeriesHandler) SetPosts(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return
+	}
+
+	var req request.SetSeriesPostsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+
+	if err := h.seriesService.SetSeriesPosts(c.Request.Context(), uint(id), req.PostIDs); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "系列或文章不存在", nil))
+		case errors.Is(err, mysql.ErrPostInOtherSeries):
+			c.JSON(http.StatusConflict, response.NewResponse(http.StatusConflict, err.Error(), nil))
+		default:
+			c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		}
+		return
+	}
+
+	series, err := h.seriesService.GetSeries(c.Request.Context(), uint(id), true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "更新成功", series))
+}
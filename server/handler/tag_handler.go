@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/personal-blog/handler/response"
 	"github.com/personal-blog/models"
 	"github.com/personal-blog/service"
+	"gorm.io/gorm"
 )
 
 // TagHandler 标签处理器
@@ -224,7 +226,7 @@ func (h *TagHandler) List(c *gin.Context) {
 // @Failure 500 {object} response.Response "服务器内部错误"
 // @Router /posts/{post_id}/tags [get]
 func (h *TagHandler) GetPostTags(c *gin.Context) {
-	postID, err := strconv.ParseUint(c.Param("post_id"), 10, 32)
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "无效的文章ID", nil))
 		return
@@ -238,3 +240,147 @@ func (h *TagHandler) GetPostTags(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", tags))
 }
+
+// AddAlias godoc
+// @Summary 添加标签别名
+// @Description 为标签添加别名，创建文章时输入别名会使用该标签（管理员）
+// @Tags tag
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "标签ID"
+// @Param data body request.AddTagAliasRequest true "别名"
+// @Success 200 {object} response.Response{data=models.TagAlias} "添加成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "标签不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /tags/{id}/aliases [post]
+func (h *TagHandler) AddAlias(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "无效的标签ID", nil))
+		return
+	}
+
+	var req request.AddTagAliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+
+	alias, err := h.tagService.AddAlias(c.Request.Context(), uint(id), req.Alias)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "标签不存在", nil))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "添加成功", alias))
+}
+
+// DeleteAlias godoc
+// @Summary 删除标签别名
+// @Description 删除标签的别名（管理员）
+// @Tags tag
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "标签ID"
+// @Param alias_id path int true "别名ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 404 {object} response.Response "别名不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /tags/{id}/aliases/{alias_id} [delete]
+func (h *TagHandler) DeleteAlias(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "无效的标签ID", nil))
+		return
+	}
+	aliasID, err := strconv.ParseUint(c.Param("alias_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "无效的别名ID", nil))
+		return
+	}
+
+	if err := h.tagService.DeleteAlias(c.Request.Context(), uint(id), uint(aliasID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "别名不存在", nil))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "删除成功", nil))
+}
+
+// Merge godoc
+// @Summary 合并标签
+// @Description 将源标签合并到目标标签，源标签的文章改用目标标签，源标签名称成为目标标签的别名（管理员）
+// @Tags tag
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param data body request.MergeTagsRequest true "源标签和目标标签"
+// @Success 200 {object} response.Response{data=models.Tag} "合并成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "标签不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /tags/merge [post]
+func (h *TagHandler) Merge(c *gin.Context) {
+	var req request.MergeTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+
+	if err := h.tagService.MergeTags(c.Request.Context(), req.SourceIDs, req.TargetID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "标签不存在", nil))
+			return
+		}
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+
+	tag, err := h.tagService.GetTagByID(c.Request.Context(), req.TargetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "合并成功", tag))
+}
+
+// Cloud godoc
+// @Summary 获取标签云
+// @Description 获取文章数最多的标签及其权重（1-5）
+// @Tags tag
+// @Accept json
+// @Produce json
+// @Param limit query int false "标签数量，默认50"
+// @Success 200 {object} response.Response{data=[]models.TagCloudItem} "获取成功"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /tags/cloud [get]
+func (h *TagHandler) Cloud(c *gin.Context) {
+	var req request.TagCloudRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = 50
+	}
+
+	items, err := h.tagService.TagCloud(c.Request.Context(), req.Limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "获取成功", items))
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/personal-blog/handler/response"
 	"github.com/personal-blog/models"
 	"github.com/personal-blog/service"
+	"gorm.io/gorm"
 )
 
 // UserHandler 用户处理器
@@ -46,10 +48,9 @@ func (h *UserHandler) Register(c *gin.Context) {
 		Password: req.Password,
 		Email:    req.Email,
 		Nickname: req.Nickname,
-		Role:     req.Role,
 	}
 
-	if err := h.userService.Register(c, user); err != nil {
+	if err := h.userService.Register(c.Request.Context(), user); err != nil {
 		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
 		return
 	}
@@ -76,7 +77,7 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	user, token, err := h.userService.Login(c, req.Username, req.Password)
+	user, token, err := h.userService.Login(c.Request.Context(), req.Username, req.Password)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, response.NewResponse(http.StatusUnauthorized, err.Error(), nil))
 		return
@@ -101,7 +102,7 @@ func (h *UserHandler) Login(c *gin.Context) {
 // @Router /users/profile [get]
 func (h *UserHandler) GetProfile(c *gin.Context) {
 	userID, _ := c.Get("userID")
-	user, err := h.userService.GetUserByID(c, userID.(uint))
+	user, err := h.userService.GetUserByID(c.Request.Context(), userID.(uint))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
 		return
@@ -138,7 +139,7 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		Avatar:   req.Avatar,
 	}
 
-	if err := h.userService.UpdateUser(c, user); err != nil {
+	if err := h.userService.UpdateUser(c.Request.Context(), user); err != nil {
 		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
 		return
 	}
@@ -167,7 +168,7 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	}
 
 	userID, _ := c.Get("userID")
-	if err := h.userService.ChangePassword(c, userID.(uint), req.OldPassword, req.NewPassword); err != nil {
+	if err := h.userService.ChangePassword(c.Request.Context(), userID.(uint), req.OldPassword, req.NewPassword); err != nil {
 		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
 		return
 	}
@@ -197,7 +198,7 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 		return
 	}
 
-	users, total, err := h.userService.ListUsers(c, req.Page, req.PageSize)
+	users, total, err := h.userService.ListUsers(c.Request.Context(), req.Page, req.PageSize)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
 		return
@@ -239,7 +240,48 @@ func (h *UserHandler) UpdateStatus(c *gin.Context) {
 		Status: req.Status,
 	}
 
-	if err := h.userService.UpdateUser(c, user); err != nil {
+	if err := h.userService.UpdateUser(c.Request.Context(), user); err != nil {
+		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.NewResponse(http.StatusOK, "更新成功", nil))
+}
+
+// UpdateRole 分配用户角色
+// @Summary 分配用户角色
+// @Description 分配用户角色（管理员），用户重新登录后生效
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "用户ID"
+// @Param data body request.UpdateUserRoleRequest true "角色"
+// @Success 200 {object} response.Response "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未登录"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 404 {object} response.Response "用户不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /users/{id}/role [put]
+func (h *UserHandler) UpdateRole(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, "参数错误", nil))
+		return
+	}
+
+	var req request.UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, response.NewResponse(http.StatusBadRequest, err.Error(), nil))
+		return
+	}
+
+	if err := h.userService.SetRole(c.Request.Context(), uint(id), req.Role); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, response.NewResponse(http.StatusNotFound, "用户不存在", nil))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, response.NewResponse(http.StatusInternalServerError, err.Error(), nil))
 		return
 	}
@@ -55,6 +55,22 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// OptionalJWTAuthMiddleware 可选的JWT认证中间件，用于公开接口
+// 携带有效Token时与 JWTAuthMiddleware 一样保存用户信息，否则按匿名访客继续处理
+func OptionalJWTAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.SplitN(c.GetHeader("Authorization"), " ", 2)
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			if mc, err := ParseToken(parts[1]); err == nil {
+				c.Set("userID", mc.UserID)
+				c.Set("username", mc.Username)
+				c.Set("role", mc.Role)
+			}
+		}
+		c.Next()
+	}
+}
+
 // MyClaims 自定义声明结构体并内嵌jwt.RegisteredClaims
 type MyClaims struct {
 	UserID   uint   `json:"user_id"`
@@ -71,7 +87,7 @@ func GenerateToken(userID uint, username, role string) (string, error) {
 		username,
 		role,
 		jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(config.GlobalConfig.JWT.ExpireTime) * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(config.Get().JWT.ExpireTime) * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
@@ -79,7 +95,7 @@ func GenerateToken(userID uint, username, role string) (string, error) {
 	// 使用指定的签名方法创建签名对象
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	// 使用指定的secret签名并获得完整的编码后的字符串token
-	return token.SignedString([]byte(config.GlobalConfig.JWT.Secret))
+	return token.SignedString([]byte(config.Get().JWT.Secret))
 }
 
 // ParseToken 解析JWT
@@ -87,7 +103,7 @@ func ParseToken(tokenString string) (*MyClaims, error) {
 	// 解析token
 	var mc = new(MyClaims)
 	token, err := jwt.ParseWithClaims(tokenString, mc, func(token *jwt.Token) (interface{}, error) {
-		return []byte(config.GlobalConfig.JWT.Secret), nil
+		return []byte(config.Get().JWT.Secret), nil
 	})
 	if err != nil {
 		return nil, err
@@ -110,20 +110,33 @@ func InitLogger() *logrus.Logger {
 	})
 
 	// 设置输出
-	if config.GlobalConfig.Server.LogPath != "" {
+	if config.Get().Server.LogPath != "" {
 		// TODO: 实现日志文件轮转
-		// file, err := os.OpenFile(config.GlobalConfig.Server.LogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+		// file, err := os.OpenFile(config.Get().Server.LogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 		// if err == nil {
 		// 	logger.Out = file
 		// }
 	}
 
-	// 设置日志级别
-	if config.GlobalConfig.Server.Mode == "debug" {
-		logger.SetLevel(logrus.DebugLevel)
-	} else {
-		logger.SetLevel(logrus.InfoLevel)
-	}
+	// 设置日志级别，并在配置变更时更新
+	logger.SetLevel(parseLogLevel(config.Get()))
+	config.Subscribe(func(old, new *config.Config) {
+		if old.Log.Level != new.Log.Level {
+			logger.SetLevel(parseLogLevel(new))
+		}
+	})
 
 	return logger
 }
+
+// parseLogLevel 解析日志级别，debug模式下始终输出调试日志
+func parseLogLevel(cfg *config.Config) logrus.Level {
+	if cfg.Server.Mode == "debug" {
+		return logrus.DebugLevel
+	}
+	level, err := logrus.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		return logrus.InfoLevel
+	}
+	return level
+}
@@ -7,6 +7,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/time/rate"
+
+	"github.com/personal-blog/config"
 )
 
 // IPRateLimiter IP限流器
@@ -35,6 +37,19 @@ func NewIPRateLimiter(r rate.Limit, burst int, ttl time.Duration) *IPRateLimiter
 	return i
 }
 
+// SetLimit 更新限流参数，已存在的限流器立即生效
+func (i *IPRateLimiter) SetLimit(r rate.Limit, burst int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.rate = r
+	i.burst = burst
+	for _, limiter := range i.ips {
+		limiter.SetLimit(r)
+		limiter.SetBurst(burst)
+	}
+}
+
 // AddIP 创建一个新的限流器并添加到map中
 func (i *IPRateLimiter) AddIP(ip string) *rate.Limiter {
 	i.mu.Lock()
@@ -78,9 +93,27 @@ func (i *IPRateLimiter) cleanupLoop() {
 	}
 }
 
+// NewIPRateLimiterFromConfig 根据配置创建IP限流器，并在配置变更时更新限流参数
+func NewIPRateLimiterFromConfig() *IPRateLimiter {
+	cfg := config.Get().RateLimit
+	limiter := NewIPRateLimiter(rate.Limit(cfg.Rate), cfg.Burst, 10*time.Minute)
+
+	config.Subscribe(func(old, new *config.Config) {
+		if old.RateLimit != new.RateLimit {
+			limiter.SetLimit(rate.Limit(new.RateLimit.Rate), new.RateLimit.Burst)
+		}
+	})
+	return limiter
+}
+
 // RateLimitMiddleware IP限流中间件
 func RateLimitMiddleware(limiter *IPRateLimiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if !config.Get().RateLimit.Enabled {
+			c.Next()
+			return
+		}
+
 		// 获取IP地址
 		ip := c.ClientIP()
 		
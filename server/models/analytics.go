@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+)
+
+// 浏览量统计粒度
+const (
+	GranularityHour = "hour"
+	GranularityDay  = "day"
+)
+
+// PostViewBucket 文章按小时/按天的浏览量
+// 按小时的数据只保留最近48小时，按天的数据长期保留
+type PostViewBucket struct {
+	PostID      uint      `gorm:"primaryKey;autoIncrement:false" json:"post_id"`
+	Granularity string    `gorm:"primaryKey;size:8" json:"granularity"`
+	BucketStart time.Time `gorm:"primaryKey" json:"bucket_start"` // UTC时间
+	Views       int64     `gorm:"not null;default:0" json:"views"`
+}
+
+// PostReferrerStat 文章每天各来源域名的浏览量
+type PostReferrerStat struct {
+	PostID uint      `gorm:"primaryKey;autoIncrement:false" json:"post_id"`
+	Day    time.Time `gorm:"primaryKey" json:"day"`
+	Domain string    `gorm:"primaryKey;size:255" json:"domain"` // (direct) 表示没有来源
+	Views  int64     `gorm:"not null;default:0" json:"views"`
+}
+
+// PostCampaignStat 文章每天各UTM推广活动的浏览量
+type PostCampaignStat struct {
+	PostID   uint      `gorm:"primaryKey;autoIncrement:false" json:"post_id"`
+	Day      time.Time `gorm:"primaryKey" json:"day"`
+	Source   string    `gorm:"primaryKey;size:100" json:"source"`
+	Medium   string    `gorm:"primaryKey;size:100" json:"medium"`
+	Campaign string    `gorm:"primaryKey;size:100" json:"campaign"`
+	Views    int64     `gorm:"not null;default:0" json:"views"`
+}
+
+// PopularPost 热门文章统计结果
+type PopularPost struct {
+	PostID uint   `json:"post_id"`
+	Title  string `json:"title"`
+	Views  int64  `json:"views"`
+}
+
+// ReferrerCount 来源域名统计结果
+type ReferrerCount struct {
+	Domain string `json:"domain"`
+	Views  int64  `json:"views"`
+}
+
+// CampaignCount UTM推广活动统计结果
+type CampaignCount struct {
+	Source   string `json:"source"`
+	Medium   string `json:"medium"`
+	Campaign string `json:"campaign"`
+	Views    int64  `json:"views"`
+}
@@ -0,0 +1,14 @@
+package models
+
+// ArchiveYear 文章归档中的一年，只统计已发布且公开列出的文章
+type ArchiveYear struct {
+	Year   int            `json:"year"`
+	Count  int64          `json:"count"`
+	Months []ArchiveMonth `json:"months"` // 按月份倒序，只包含有文章的月份
+}
+
+// ArchiveMonth 文章归档中的一个月
+type ArchiveMonth struct {
+	Month int   `json:"month"`
+	Count int64 `json:"count"`
+}
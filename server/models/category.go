@@ -9,7 +9,25 @@ type Category struct {
 	ID          uint      `gorm:"primarykey" json:"id"`
 	Name        string    `gorm:"size:50;not null;unique" json:"name"`
 	Description string    `gorm:"size:200" json:"description"`
+	ParentID    *uint     `gorm:"index" json:"parent_id"` // 父分类ID，为空表示顶级分类
 	Posts       []Post    `json:"posts"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
+
+// CategoryNode 分类树节点
+type CategoryNode struct {
+	ID          uint            `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	ParentID    *uint           `json:"parent_id"`
+	PostCount   int64           `json:"post_count"`  // 直接属于该分类的已发布文章数
+	TotalCount  int64           `json:"total_count"` // 包含所有子孙分类的已发布文章数
+	Children    []*CategoryNode `json:"children"`
+}
+
+// CategoryCrumb 面包屑中的一级分类
+type CategoryCrumb struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
@@ -2,21 +2,58 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // Comment 评论模型
 type Comment struct {
-	ID        uint       `gorm:"primarykey" json:"id"`
-	Content   string     `gorm:"size:1000;not null" json:"content"`
-	PostID    uint       `json:"post_id"`
-	Post      Post       `json:"post"`
-	UserID    uint       `json:"user_id"`
-	User      User       `json:"user"`
-	ParentID  *uint      `json:"parent_id"`           // 父评论ID，用于回复功能
-	Parent    *Comment   `json:"parent"`              // 父评论
-	Children  []Comment  `gorm:"foreignkey:ParentID"` // 子评论
-	Status    int        `gorm:"default:1" json:"status"` // 1:正常 0:待审核 -1:已删除
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
-	DeletedAt *time.Time `gorm:"index" json:"-"`
+	ID          uint           `gorm:"primarykey" json:"id"`
+	Content     string         `gorm:"size:1000;not null" json:"content"`
+	PostID      uint           `json:"post_id"`
+	Post        Post           `json:"post"`
+	UserID      uint           `json:"user_id"`
+	User        User           `json:"user"`
+	ParentID    *uint          `json:"parent_id"`           // 父评论ID，用于回复功能
+	Parent      *Comment       `json:"parent"`              // 父评论
+	Children    []Comment      `gorm:"foreignkey:ParentID"` // 子评论
+	Status      int            `json:"status"`              // 1:正常 0:待审核，删除的评论通过 DeletedAt 移入回收站
+	UpvoteCount int64          `gorm:"not null;default:0" json:"upvote_count"`
+	IsPinned    bool           `gorm:"not null;default:false" json:"is_pinned"` // 由文章作者置顶，每篇文章最多一条
+	Badges      []string       `gorm:"-" json:"badges,omitempty"`               // 评论者的身份标记，如文章作者的评论带有 author
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"` // 非空表示在回收站中
+}
+
+// 评论审核状态，Status 没有默认值标签，否则 GORM 插入时会跳过待审核的零值
+const (
+	CommentStatusPending  = 0 // 待审核，不出现在列表中也不计入评论数
+	CommentStatusApproved = 1 // 正常显示
+)
+
+// CommentBadgeAuthor 文章作者发表的评论的标记
+const CommentBadgeAuthor = "author"
+
+// CommentSort 文章评论列表的排序方式，置顶的评论始终排在最前
+type CommentSort string
+
+const (
+	CommentSortNewest CommentSort = ""       // 最新优先
+	CommentSortOldest CommentSort = "oldest" // 最早优先
+	CommentSortTop    CommentSort = "top"    // 点赞数最多优先
+)
+
+// CommentVote 用户对评论的点赞，每个用户对每条评论最多一条
+type CommentVote struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CommentID uint      `gorm:"not null" json:"comment_id"`
+	UserID    uint      `gorm:"not null" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CommentVoteState 评论的点赞数以及当前用户是否已点赞
+type CommentVoteState struct {
+	UpvoteCount int64 `json:"upvote_count"`
+	Upvoted     bool  `json:"upvoted"`
 }
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// PostDraft 编辑器自动保存的文章草稿快照，保存草稿不会修改已发布的文章
+type PostDraft struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	UserID     uint      `gorm:"not null;uniqueIndex:idx_post_drafts_user_post" json:"user_id"`
+	PostID     *uint     `gorm:"uniqueIndex:idx_post_drafts_user_post;index" json:"post_id"` // 为空表示尚未创建的新文章
+	Title      string    `gorm:"size:200" json:"title"`
+	Content    string    `gorm:"type:text" json:"content"`
+	CategoryID uint      `json:"category_id"`
+	Tags       []string  `gorm:"serializer:json;type:text" json:"tags"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// PreviewLink 草稿或未发布文章的预览链接
+type PreviewLink struct {
+	PostID    uint      `json:"post_id"`
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -2,25 +2,58 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
+)
+
+// PostStatusPublished 已发布的文章，其他状态的文章只有作者、编辑和管理员可见，或通过预览链接访问
+const PostStatusPublished = 1
+
+// 文章可见性
+const (
+	VisibilityPublic   = "public"   // 公开
+	VisibilityUnlisted = "unlisted" // 不出现在列表中，知道链接即可访问
+	VisibilityPassword = "password" // 出现在列表中，正文需要密码才能阅读
 )
 
 // Post 文章模型
 type Post struct {
-	ID         uint       `gorm:"primarykey" json:"id"`
-	Title      string     `gorm:"size:200;not null" json:"title"`
-	Content    string     `gorm:"type:text" json:"content"`
-	Summary    string     `gorm:"size:500" json:"summary"`
-	Cover      string     `gorm:"size:255" json:"cover"`
-	Status     int        `gorm:"default:1" json:"status"`     // 1:已发布 0:草稿 -1:已删除
-	IsTop      bool       `gorm:"default:false" json:"is_top"` // 是否置顶
-	ViewCount  int64      `gorm:"default:0" json:"view_count"` // 浏览量
-	UserID     uint       `json:"user_id"`                     // 作者ID
-	User       User       `json:"user"`
-	CategoryID uint       `json:"category_id"`
-	Category   Category   `json:"category"`
-	Tags       []Tag      `gorm:"many2many:post_tags;" json:"tags"`
-	Comments   []Comment  `json:"comments"`
-	CreatedAt  time.Time  `json:"created_at"`
-	UpdatedAt  time.Time  `json:"updated_at"`
-	DeletedAt  *time.Time `gorm:"index" json:"-"`
+	ID           uint              `gorm:"primarykey" json:"id"`
+	Title        string            `gorm:"size:200;not null" json:"title"`
+	Content      string            `gorm:"type:text" json:"content"`
+	Summary      string            `gorm:"size:500" json:"summary"`
+	Cover        string            `gorm:"size:255" json:"cover"`
+	Status       int               `gorm:"default:1" json:"status"`                      // 1:已发布 0:草稿，删除的文章通过 DeletedAt 移入回收站
+	IsTop        bool              `gorm:"default:false" json:"is_top"`                  // 是否置顶
+	PinOrder     int               `gorm:"not null;default:0" json:"pin_order"`          // 置顶文章之间的顺序，越小越靠前
+	PinnedUntil  *time.Time        `gorm:"index" json:"pinned_until"`                    // 置顶到期时间，到期后由后台任务取消置顶，为空表示不过期
+	Featured     bool              `gorm:"not null;default:false;index" json:"featured"` // 精选文章，用于首页轮播，与置顶相互独立
+	Visibility   string            `gorm:"size:20;not null;default:public;index" json:"visibility"`
+	PasswordHash string            `gorm:"size:100" json:"-"`            // 密码保护文章的密码哈希，不写入缓存，校验时单独查询
+	Password     string            `gorm:"-" json:"-"`                   // 设置密码保护时传入的明文密码，只在创建和更新时使用
+	Locked       bool              `gorm:"-" json:"locked,omitempty"`    // 密码保护且未解锁，正文已隐藏
+	ViewCount    int64             `gorm:"default:0" json:"view_count"`  // 浏览量
+	CommentCount int64             `gorm:"->" json:"comment_count"`      // 未删除且已通过审核的评论数，随评论的增删在同一事务中更新
+	Reactions    map[string]int64  `gorm:"-" json:"reactions,omitempty"` // 各类反应的总数，返回前从缓存读取
+	UserID       uint              `json:"user_id"`                      // 作者ID
+	User         User              `json:"user"`
+	CategoryID   uint              `json:"category_id"`
+	Category     Category          `json:"category"`
+	Breadcrumb   []CategoryCrumb   `gorm:"-" json:"breadcrumb,omitempty"` // 从顶级分类到文章所属分类的路径
+	Tags         []Tag             `gorm:"many2many:post_tags;" json:"tags"`
+	Comments     []Comment         `json:"comments"`
+	Series       *SeriesNavigation `gorm:"-" json:"series,omitempty"` // 所属系列的导航，仅文章详情返回
+	PublishedAt  *time.Time        `gorm:"index" json:"published_at"` // 首次发布的时间，未发布过的文章为空
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt    `gorm:"index" json:"-"` // 非空表示在回收站中
+}
+
+// RelatedPost 相关文章推荐结果
+type RelatedPost struct {
+	PostID  uint    `json:"post_id"`
+	Title   string  `json:"title"`
+	Summary string  `json:"summary"`
+	Cover   string  `json:"cover"`
+	Score   float64 `json:"score"`
 }
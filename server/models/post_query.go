@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// PostSort 文章列表的排序方式
+type PostSort string
+
+const (
+	PostSortDefault   PostSort = ""              // 置顶文章按置顶顺序优先，再按发布时间倒序
+	PostSortPublished PostSort = "published_at"  // 发布时间，未发布过的文章按创建时间
+	PostSortCreated   PostSort = "created_at"    // 创建时间
+	PostSortUpdated   PostSort = "updated_at"    // 更新时间
+	PostSortViews     PostSort = "view_count"    // 浏览量
+	PostSortComments  PostSort = "comment_count" // 评论数
+)
+
+// PostQuery 文章列表的筛选和排序条件
+// 只包含类型化的条件，由仓库层统一转换为参数化的SQL，不接受任何SQL片段
+type PostQuery struct {
+	Keyword       string     `json:"keyword,omitempty"`        // 标题或正文包含的关键词
+	CategoryIDs   []uint     `json:"category_ids,omitempty"`   // 属于其中任一分类
+	Tags          []string   `json:"tags,omitempty"`           // 标签名称或别名，由服务层解析为 TagIDs
+	MatchAllTags  bool       `json:"match_all_tags,omitempty"` // 为true时必须包含全部标签，否则包含任一标签即可
+	TagIDs        []uint     `json:"tag_ids,omitempty"`
+	AuthorID      uint       `json:"author_id,omitempty"`
+	Status        int        `json:"status,omitempty"`
+	PublishedFrom *time.Time `json:"published_from,omitempty"` // 发布时间下限（含）
+	PublishedTo   *time.Time `json:"published_to,omitempty"`   // 发布时间上限（不含）
+	HasCover      *bool      `json:"has_cover,omitempty"`
+	Pinned        *bool      `json:"pinned,omitempty"`
+	Featured      *bool      `json:"featured,omitempty"`
+	Sort          PostSort   `json:"sort,omitempty"`
+	Ascending     bool       `json:"ascending,omitempty"` // 默认倒序，默认排序方式下忽略
+
+	// 以下为访问者的可见范围，由服务层设置
+	PublicOnly bool `json:"public_only,omitempty"` // 只包含已发布且公开列出的文章
+	OwnerID    uint `json:"owner_id,omitempty"`    // PublicOnly 时额外包含该作者的全部文章
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// ReactionLike 点赞，始终可用，其他反应类型由 reaction.types 配置
+const ReactionLike = "like"
+
+// PostReaction 登录用户对文章的一种反应，每个用户对每篇文章的每种反应最多一条
+// 匿名访客的反应只计入总数，不保存记录
+type PostReaction struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	PostID    uint      `gorm:"not null" json:"post_id"`
+	UserID    uint      `gorm:"not null" json:"user_id"`
+	Type      string    `gorm:"size:20;not null" json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PostReactionCount 文章某种反应的总数，由缓存中的增量按批次写入
+type PostReactionCount struct {
+	PostID uint   `gorm:"primarykey" json:"post_id"`
+	Type   string `gorm:"primarykey;size:20" json:"type"`
+	Count  int64  `gorm:"not null;default:0" json:"count"`
+}
+
+// ReactionState 文章的反应总数，以及当前访问者已做出的反应
+type ReactionState struct {
+	Counts  map[string]int64 `json:"counts"`
+	Reacted []string         `json:"reacted"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+// Series 系列模型，将多篇文章按顺序组织为一个合集
+type Series struct {
+	ID          uint          `gorm:"primarykey" json:"id"`
+	Title       string        `gorm:"size:200;not null" json:"title"`
+	Slug        string        `gorm:"size:100;not null;unique" json:"slug"`
+	Description string        `gorm:"size:1000" json:"description"`
+	Cover       string        `gorm:"size:255" json:"cover"`
+	Posts       []SeriesEntry `gorm:"-" json:"posts,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// TableName 指定表名，避免 series 被复数化
+func (Series) TableName() string {
+	return "series"
+}
+
+// SeriesPost 系列与文章的关联，Position 从1开始
+type SeriesPost struct {
+	SeriesID uint `gorm:"primaryKey;autoIncrement:false" json:"series_id"`
+	PostID   uint `gorm:"primaryKey;autoIncrement:false;unique" json:"post_id"`
+	Position int  `gorm:"not null" json:"position"`
+}
+
+// SeriesEntry 系列中的一篇文章
+type SeriesEntry struct {
+	PostID   uint   `json:"post_id"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Position int    `json:"position"`
+}
+
+// SeriesNavigation 文章详情中的系列导航
+type SeriesNavigation struct {
+	SeriesID uint         `json:"series_id"`
+	Title    string       `json:"title"`
+	Slug     string       `json:"slug"`
+	Position int          `json:"position"` // 当前文章是第几篇
+	Total    int          `json:"total"`    // 系列共几篇
+	Prev     *SeriesEntry `json:"prev"`
+	Next     *SeriesEntry `json:"next"`
+}
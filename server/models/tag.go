@@ -6,9 +6,29 @@ import (
 
 // Tag 标签模型
 type Tag struct {
+	ID             uint       `gorm:"primarykey" json:"id"`
+	Name           string     `gorm:"size:50;not null;unique" json:"name"`
+	NormalizedName string     `gorm:"size:80;not null;uniqueIndex" json:"-"` // 规范化后的名称，用于识别同一标签的不同写法
+	PostCount      int64      `gorm:"-" json:"post_count"`                   // 使用该标签的已发布文章数，仅列表接口返回
+	Aliases        []TagAlias `json:"aliases,omitempty"`
+	Posts          []Post     `gorm:"many2many:post_tags;" json:"posts"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TagAlias 标签别名，输入别名时使用对应的标签
+// 标签改名或被合并时，旧名称会自动成为别名
+type TagAlias struct {
 	ID        uint      `gorm:"primarykey" json:"id"`
-	Name      string    `gorm:"size:50;not null;unique" json:"name"`
-	Posts     []Post    `gorm:"many2many:post_tags;" json:"posts"`
+	TagID     uint      `gorm:"not null;index" json:"tag_id"`
+	Alias     string    `gorm:"size:80;not null;uniqueIndex" json:"alias"` // 规范化后的名称
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TagCloudItem 标签云中的一个标签
+type TagCloudItem struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	PostCount int64  `json:"post_count"`
+	Weight    int    `json:"weight"` // 1-5，按文章数的对数分级
 }
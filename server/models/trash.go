@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// TrashedPost 回收站中的文章
+type TrashedPost struct {
+	ID         uint      `json:"id"`
+	Title      string    `json:"title"`
+	Status     int       `json:"status"`
+	UserID     uint      `json:"user_id"`
+	CategoryID uint      `json:"category_id"`
+	DeletedAt  time.Time `json:"deleted_at"`
+	PurgeAt    time.Time `json:"purge_at"` // 超过该时间后彻底删除
+}
+
+// TrashedComment 回收站中的评论，所属文章在回收站中的评论随文章一起恢复，不单独列出
+type TrashedComment struct {
+	ID        uint      `json:"id"`
+	PostID    uint      `json:"post_id"`
+	UserID    uint      `json:"user_id"`
+	Content   string    `json:"content"`
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
@@ -4,6 +4,13 @@ import (
 	"time"
 )
 
+// 用户角色
+const (
+	RoleAdmin  = "admin"  // 管理员
+	RoleEditor = "editor" // 编辑，可以查看所有文章
+	RoleUser   = "user"   // 普通用户，只能查看已发布的文章和自己的文章
+)
+
 // User 用户模型
 type User struct {
 	ID        uint      `gorm:"primarykey" json:"id"`
@@ -12,7 +19,7 @@ type User struct {
 	Email     string    `gorm:"size:100;not null;unique" json:"email"`
 	Nickname  string    `gorm:"size:50" json:"nickname"`
 	Avatar    string    `gorm:"size:255" json:"avatar"`
-	Role      string    `gorm:"size:20;default:'user'" json:"role"` // admin/user
+	Role      string    `gorm:"size:20;default:'user'" json:"role"` // admin/editor/user
 	Bio       string    `gorm:"size:500" json:"bio"`
 	Status    int       `gorm:"default:1" json:"status"`           // 1:正常 0:禁用
 	CreatedAt time.Time `json:"created_at"`
@@ -0,0 +1,12 @@
+package models
+
+import (
+	"time"
+)
+
+// ViewFlushBatch 已写入数据库的浏览量批次
+// 批次ID与浏览量增量在同一事务中写入，重复应用同一批次时据此跳过
+type ViewFlushBatch struct {
+	BatchID   string    `gorm:"primarykey;size:64" json:"batch_id"`
+	AppliedAt time.Time `gorm:"not null;index" json:"applied_at"`
+}
@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GormPlugin 为GORM的每次数据库操作创建span
+type GormPlugin struct{}
+
+// NewGormPlugin 创建GORM追踪插件
+func NewGormPlugin() gorm.Plugin {
+	return &GormPlugin{}
+}
+
+// Name 插件名称
+func (p *GormPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize 注册各类操作的前后回调
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("tracing:before_create", p.before("gorm.create")); err != nil {
+		return err
+	}
+	if err := cb.Create().After("gorm:create").Register("tracing:after_create", p.after); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("tracing:before_query", p.before("gorm.query")); err != nil {
+		return err
+	}
+	if err := cb.Query().After("gorm:query").Register("tracing:after_query", p.after); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("tracing:before_update", p.before("gorm.update")); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("tracing:after_update", p.after); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("gorm:delete").Register("tracing:before_delete", p.before("gorm.delete")); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("tracing:after_delete", p.after); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("tracing:before_row", p.before("gorm.row")); err != nil {
+		return err
+	}
+	if err := cb.Row().After("gorm:row").Register("tracing:after_row", p.after); err != nil {
+		return err
+	}
+	if err := cb.Raw().Before("gorm:raw").Register("tracing:before_raw", p.before("gorm.raw")); err != nil {
+		return err
+	}
+	return cb.Raw().After("gorm:raw").Register("tracing:after_raw", p.after)
+}
+
+func (p *GormPlugin) before(name string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement == nil || db.Statement.Context == nil {
+			return
+		}
+		ctx, span := Tracer().Start(db.Statement.Context, name, trace.WithSpanKind(trace.SpanKindClient))
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func (p *GormPlugin) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system", db.Dialector.Name()),
+		attribute.String("db.statement", db.Dialector.Explain(db.Statement.SQL.String(), db.Statement.Vars...)),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	}
+	if db.Statement.Table != "" {
+		attrs = append(attrs, attribute.String("db.sql.table", db.Statement.Table))
+	}
+	span.SetAttributes(attrs...)
+
+	// 记录未找到不视为错误
+	if db.Error != nil && db.Error != gorm.ErrRecordNotFound {
+		RecordError(span, db.Error)
+	}
+}
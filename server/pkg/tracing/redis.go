@@ -0,0 +1,72 @@
+package tracing
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedisHook 为go-redis的命令和管道创建span
+type RedisHook struct{}
+
+// NewRedisHook 创建Redis追踪钩子
+func NewRedisHook() redis.Hook {
+	return &RedisHook{}
+}
+
+// DialHook 建立连接时不创建span
+func (h *RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+// ProcessHook 追踪单条命令
+func (h *RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := Tracer().Start(ctx, "redis."+cmd.FullName(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "redis"),
+				attribute.String("db.operation", cmd.FullName()),
+			),
+		)
+		defer span.End()
+
+		err := next(ctx, cmd)
+		if err != nil && err != redis.Nil {
+			RecordError(span, err)
+		}
+		return err
+	}
+}
+
+// ProcessPipelineHook 追踪管道命令
+func (h *RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		names := make([]string, 0, len(cmds))
+		for _, cmd := range cmds {
+			names = append(names, cmd.FullName())
+		}
+
+		ctx, span := Tracer().Start(ctx, "redis.pipeline",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", "redis"),
+				attribute.String("db.operation", strings.Join(names, " ")),
+				attribute.Int("db.redis.num_cmd", len(cmds)),
+			),
+		)
+		defer span.End()
+
+		err := next(ctx, cmds)
+		if err != nil && err != redis.Nil {
+			RecordError(span, err)
+		}
+		return err
+	}
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrInvalidCursor 游标无法解析，通常是客户端修改或拼接了游标
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor 游标分页的位置，记录上一页最后一条记录的排序键
+// 按时间排序时使用 Time，按数量排序时使用 Num，id作为最后的排序键；没有置顶字段的列表 IsTop 和 PinOrder 始终为零值
+type Cursor struct {
+	Sort     string    `json:"s,omitempty"` // 生成游标时的排序方式，换用其他排序方式后游标无效
+	IsTop    bool      `json:"t,omitempty"`
+	PinOrder int       `json:"p,omitempty"`
+	Time     time.Time `json:"c"`
+	Num      int64     `json:"n,omitempty"`
+	ID       uint      `json:"i"`
+}
+
+// EncodeCursor 将游标编码为不透明的字符串，客户端只需原样传回
+func EncodeCursor(cursor *Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor 解析客户端传回的游标，空字符串表示从第一条开始，返回nil
+func DecodeCursor(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
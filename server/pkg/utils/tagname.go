@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/width"
+)
+
+var tagFolder = cases.Fold()
+
+// NormalizeTagName 规范化标签的显示名称：全角字符转为半角，去除首尾空白，连续空白合并为一个空格
+func NormalizeTagName(name string) string {
+	return strings.Join(strings.Fields(width.Fold.String(name)), " ")
+}
+
+// TagKey 返回用于判断标签是否相同的规范化名称，在显示名称的基础上折叠大小写
+// 例如 "Go"、"go "、"ＧＯ" 得到相同的结果
+func TagKey(name string) string {
+	return tagFolder.String(NormalizeTagName(name))
+}
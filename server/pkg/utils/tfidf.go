@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Tokenize 将文本切分为用于相似度计算的词项
+// 字母和数字按连续片段切分并转为小写；中日韩文字没有空格分词，按相邻两字切分（单字文本保留单字）
+func Tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+
+	flushWord := func() {
+		if len(word) > 1 {
+			tokens = append(tokens, strings.ToLower(string(word)))
+		}
+		word = word[:0]
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			tokens = append(tokens, string(cjk))
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			tokens = append(tokens, string(cjk[i:i+2]))
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+// TFIDF 计算一组文档的TF-IDF向量，返回的向量已归一化，点积即为余弦相似度
+func TFIDF(docs [][]string) []map[string]float64 {
+	df := make(map[string]int)
+	for _, doc := range docs {
+		seen := make(map[string]bool, len(doc))
+		for _, term := range doc {
+			if !seen[term] {
+				seen[term] = true
+				df[term]++
+			}
+		}
+	}
+
+	n := float64(len(docs))
+	vectors := make([]map[string]float64, len(docs))
+	for i, doc := range docs {
+		tf := make(map[string]float64, len(doc))
+		for _, term := range doc {
+			tf[term]++
+		}
+
+		vec := make(map[string]float64, len(tf))
+		var norm float64
+		for term, count := range tf {
+			// 平滑后的IDF，只出现在全部文档中的词权重接近0
+			w := count / float64(len(doc)) * math.Log((1+n)/(1+float64(df[term])))
+			if w > 0 {
+				vec[term] = w
+				norm += w * w
+			}
+		}
+		if norm > 0 {
+			norm = math.Sqrt(norm)
+			for term := range vec {
+				vec[term] /= norm
+			}
+		}
+		vectors[i] = vec
+	}
+	return vectors
+}
+
+// CosineSimilarity 计算两个已归一化向量的余弦相似度
+func CosineSimilarity(a, b map[string]float64) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	var sum float64
+	for term, w := range a {
+		sum += w * b[term]
+	}
+	return sum
+}
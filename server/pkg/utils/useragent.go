@@ -0,0 +1,18 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+// botPattern 常见爬虫、监控和命令行工具的User-Agent特征
+var botPattern = regexp.MustCompile(`(?i)bot|crawl|spider|slurp|fetch|scrap|preview|monitor|lighthouse|headless|phantomjs|curl|wget|python-requests|python-urllib|go-http-client|okhttp|java/|libwww|httpclient|feedparser|rss`)
+
+// IsBot 判断User-Agent是否来自爬虫等非真实用户，空User-Agent同样视为非真实用户
+func IsBot(userAgent string) bool {
+	userAgent = strings.TrimSpace(userAgent)
+	if userAgent == "" {
+		return true
+	}
+	return botPattern.MatchString(userAgent)
+}
@@ -0,0 +1,122 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	"github.com/personal-blog/models"
+	"gorm.io/gorm"
+)
+
+// hourlyRetention 按小时浏览量的保留时间
+const hourlyRetention = 48 * time.Hour
+
+// AnalyticsRepository 文章浏览统计仓库接口
+type AnalyticsRepository interface {
+	ApplyBatch(ctx context.Context, batchID string, buckets []models.PostViewBucket, referrers []models.PostReferrerStat, campaigns []models.PostCampaignStat) error
+	ListViewBuckets(ctx context.Context, postID uint, granularity string, from, to time.Time) ([]models.PostViewBucket, error)
+	TopPosts(ctx context.Context, from, to time.Time, limit int) ([]models.PopularPost, error)
+	TopReferrers(ctx context.Context, postID uint, from, to time.Time, limit int) ([]models.ReferrerCount, error)
+	TopCampaigns(ctx context.Context, postID uint, from, to time.Time, limit int) ([]models.CampaignCount, error)
+}
+
+type analyticsRepository struct {
+	db *gorm.DB
+}
+
+// NewAnalyticsRepository 创建浏览统计仓库实例
+func NewAnalyticsRepository(db *gorm.DB) AnalyticsRepository {
+	return &analyticsRepository{db: db}
+}
+
+// ApplyBatch 在一个事务中累加一批统计数据，同一批次只会应用一次
+func (r *analyticsRepository) ApplyBatch(ctx context.Context, batchID string, buckets []models.PostViewBucket, referrers []models.PostReferrerStat, campaigns []models.PostCampaignStat) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		claimed, err := claimBatch(tx, batchID, now)
+		if err != nil || !claimed {
+			return err
+		}
+
+		if len(buckets) > 0 {
+			err := tx.Clauses(addViewsOnConflict(tx, "post_view_buckets", "post_id", "granularity", "bucket_start")).
+				CreateInBatches(buckets, 200).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(referrers) > 0 {
+			err := tx.Clauses(addViewsOnConflict(tx, "post_referrer_stats", "post_id", "day", "domain")).
+				CreateInBatches(referrers, 200).Error
+			if err != nil {
+				return err
+			}
+		}
+		if len(campaigns) > 0 {
+			err := tx.Clauses(addViewsOnConflict(tx, "post_campaign_stats", "post_id", "day", "source", "medium", "campaign")).
+				CreateInBatches(campaigns, 200).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		// 按小时的数据只保留最近48小时
+		return tx.Where("granularity = ? AND bucket_start < ?", models.GranularityHour, now.UTC().Add(-hourlyRetention)).
+			Delete(&models.PostViewBucket{}).Error
+	})
+}
+
+// ListViewBuckets 查询文章在 [from, to) 内的浏览量，只返回有数据的时间段
+func (r *analyticsRepository) ListViewBuckets(ctx context.Context, postID uint, granularity string, from, to time.Time) ([]models.PostViewBucket, error) {
+	var buckets []models.PostViewBucket
+	err := r.db.WithContext(ctx).
+		Where("post_id = ? AND granularity = ? AND bucket_start >= ? AND bucket_start < ?", postID, granularity, from, to).
+		Order("bucket_start").
+		Find(&buckets).Error
+	return buckets, err
+}
+
+// TopPosts 查询 [from, to) 内浏览量最高的已发布文章，不公开列出的文章不参与排行
+func (r *analyticsRepository) TopPosts(ctx context.Context, from, to time.Time, limit int) ([]models.PopularPost, error) {
+	var posts []models.PopularPost
+	err := r.db.WithContext(ctx).
+		Table("post_view_buckets").
+		Select("post_view_buckets.post_id, posts.title, SUM(post_view_buckets.views) AS views").
+		Joins("JOIN posts ON posts.id = post_view_buckets.post_id").
+		Where("post_view_buckets.granularity = ? AND post_view_buckets.bucket_start >= ? AND post_view_buckets.bucket_start < ?",
+			models.GranularityDay, from, to).
+		Where("posts.status = ? AND posts.visibility <> ? AND posts.deleted_at IS NULL", 1, models.VisibilityUnlisted).
+		Group("post_view_buckets.post_id, posts.title").
+		Order("views DESC").
+		Limit(limit).
+		Scan(&posts).Error
+	return posts, err
+}
+
+// TopReferrers 查询文章在 [from, to) 内浏览量最高的来源域名
+func (r *analyticsRepository) TopReferrers(ctx context.Context, postID uint, from, to time.Time, limit int) ([]models.ReferrerCount, error) {
+	var referrers []models.ReferrerCount
+	err := r.db.WithContext(ctx).
+		Model(&models.PostReferrerStat{}).
+		Select("domain, SUM(views) AS views").
+		Where("post_id = ? AND day >= ? AND day < ?", postID, from, to).
+		Group("domain").
+		Order("views DESC").
+		Limit(limit).
+		Scan(&referrers).Error
+	return referrers, err
+}
+
+// TopCampaigns 查询文章在 [from, to) 内浏览量最高的UTM推广活动
+func (r *analyticsRepository) TopCampaigns(ctx context.Context, postID uint, from, to time.Time, limit int) ([]models.CampaignCount, error) {
+	var campaigns []models.CampaignCount
+	err := r.db.WithContext(ctx).
+		Model(&models.PostCampaignStat{}).
+		Select("source, medium, campaign, SUM(views) AS views").
+		Where("post_id = ? AND day >= ? AND day < ?", postID, from, to).
+		Group("source, medium, campaign").
+		Order("views DESC").
+		Limit(limit).
+		Scan(&campaigns).Error
+	return campaigns, err
+}
@@ -0,0 +1,54 @@
+package mysql
+
+import (
+	"time"
+
+	"github.com/personal-blog/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// viewBatchRetention 批次记录的保留时间，需远大于批次从缓存写入数据库的间隔
+const viewBatchRetention = 7 * 24 * time.Hour
+
+// claimBatch 在事务中登记批次ID，批次已应用过时返回false
+// 并发应用同一批次时，后提交的事务会因主键冲突回滚
+func claimBatch(tx *gorm.DB, batchID string, now time.Time) (bool, error) {
+	var applied int64
+	if err := tx.Model(&models.ViewFlushBatch{}).Where("batch_id = ?", batchID).Count(&applied).Error; err != nil {
+		return false, err
+	}
+	if applied > 0 {
+		return false, nil
+	}
+	if err := tx.Create(&models.ViewFlushBatch{BatchID: batchID, AppliedAt: now}).Error; err != nil {
+		return false, err
+	}
+
+	// 顺带清理过期的批次记录
+	if err := tx.Where("applied_at < ?", now.Add(-viewBatchRetention)).Delete(&models.ViewFlushBatch{}).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// addViewsOnConflict 主键冲突时把views累加到已有行，keys为主键列
+func addViewsOnConflict(tx *gorm.DB, table string, keys ...string) clause.OnConflict {
+	return addOnConflict(tx, table, "views", keys...)
+}
+
+// addOnConflict 主键冲突时把column累加到已有行，keys为主键列
+func addOnConflict(tx *gorm.DB, table, column string, keys ...string) clause.OnConflict {
+	expr := table + "." + column + " + excluded." + column
+	if tx.Dialector.Name() == "mysql" {
+		expr = column + " + VALUES(" + column + ")"
+	}
+	columns := make([]clause.Column, len(keys))
+	for i, key := range keys {
+		columns[i] = clause.Column{Name: key}
+	}
+	return clause.OnConflict{
+		Columns:   columns,
+		DoUpdates: clause.Assignments(map[string]interface{}{column: gorm.Expr(expr)}),
+	}
+}
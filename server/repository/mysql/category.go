@@ -1,18 +1,27 @@
 package mysql
 
 import (
+	"context"
+	"errors"
+
 	"github.com/personal-blog/models"
 	"gorm.io/gorm"
 )
 
+// ErrCategoryNotEmpty 分类下还有子分类或文章，删除时必须指定转移目标
+var ErrCategoryNotEmpty = errors.New("category has subcategories or posts, a reassignment target is required")
+
 // CategoryRepository 分类仓库接口
 type CategoryRepository interface {
-	Create(category *models.Category) error
-	Update(category *models.Category) error
-	Delete(id uint) error
-	FindByID(id uint) (*models.Category, error)
-	List(page, pageSize int) ([]models.Category, int64, error)
-	FindByName(name string) (*models.Category, error)
+	Create(ctx context.Context, category *models.Category) error
+	Update(ctx context.Context, category *models.Category) error
+	Delete(ctx context.Context, id uint) error
+	FindByID(ctx context.Context, id uint) (*models.Category, error)
+	List(ctx context.Context, page, pageSize int) ([]models.Category, int64, error)
+	FindByName(ctx context.Context, name string) (*models.Category, error)
+	ListAll(ctx context.Context) ([]models.Category, error)
+	CountPosts(ctx context.Context) (map[uint]int64, error)
+	DeleteAndReassign(ctx context.Context, id uint, targetID *uint) ([]uint, error)
 }
 
 type categoryRepository struct {
@@ -24,39 +33,41 @@ func NewCategoryRepository(db *gorm.DB) CategoryRepository {
 	return &categoryRepository{db: db}
 }
 
-func (r *categoryRepository) Create(category *models.Category) error {
-	return r.db.Create(category).Error
+func (r *categoryRepository) Create(ctx context.Context, category *models.Category) error {
+	return r.db.WithContext(ctx).Create(category).Error
 }
 
-func (r *categoryRepository) Update(category *models.Category) error {
-	// Updates 方法默认只更新非零值字段，且不会更新 created_at
-	return r.db.Model(category).Updates(category).Error
+func (r *categoryRepository) Update(ctx context.Context, category *models.Category) error {
+	// 显式指定更新的字段，parent_id 为空时需要写入NULL以移动到顶级
+	return r.db.WithContext(ctx).Model(category).
+		Select("name", "description", "parent_id", "updated_at").
+		Updates(category).Error
 }
 
-func (r *categoryRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Category{}, id).Error
+func (r *categoryRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Category{}, id).Error
 }
 
-func (r *categoryRepository) FindByID(id uint) (*models.Category, error) {
+func (r *categoryRepository) FindByID(ctx context.Context, id uint) (*models.Category, error) {
 	var category models.Category
-	err := r.db.First(&category, id).Error
+	err := r.db.WithContext(ctx).First(&category, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &category, nil
 }
 
-func (r *categoryRepository) List(page, pageSize int) ([]models.Category, int64, error) {
+func (r *categoryRepository) List(ctx context.Context, page, pageSize int) ([]models.Category, int64, error) {
 	var category []models.Category
 	var total int64
 
-	err := r.db.Model(&models.User{}).Count(&total).Error
+	err := r.db.WithContext(ctx).Model(&models.User{}).Count(&total).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
 	offset := (page - 1) * pageSize
-	err = r.db.Offset(offset).Limit(pageSize).Find(&category).Error
+	err = r.db.WithContext(ctx).Offset(offset).Limit(pageSize).Find(&category).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -64,11 +75,77 @@ func (r *categoryRepository) List(page, pageSize int) ([]models.Category, int64,
 	return category, total, nil
 }
 
-func (r *categoryRepository) FindByName(name string) (*models.Category, error) {
+func (r *categoryRepository) FindByName(ctx context.Context, name string) (*models.Category, error) {
 	var category models.Category
-	err := r.db.Where("name = ?", name).First(&category).Error
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&category).Error
 	if err != nil {
 		return nil, err
 	}
 	return &category, nil
 }
+
+// ListAll 查询全部分类，用于构建分类树
+func (r *categoryRepository) ListAll(ctx context.Context) ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.WithContext(ctx).Order("id").Find(&categories).Error
+	return categories, err
+}
+
+// CountPosts 统计每个分类下直接包含的已发布文章数
+func (r *categoryRepository) CountPosts(ctx context.Context) (map[uint]int64, error) {
+	var rows []struct {
+		CategoryID uint
+		Count      int64
+	}
+	err := r.db.WithContext(ctx).
+		Model(&models.Post{}).
+		Select("category_id, COUNT(*) AS count").
+		Where("status = ?", 1).
+		Group("category_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.CategoryID] = row.Count
+	}
+	return counts, nil
+}
+
+// DeleteAndReassign 在一个事务中删除分类，并将其子分类和文章转移到targetID
+// 分类下有子分类或文章且targetID为空时返回 ErrCategoryNotEmpty；返回被转移的文章ID
+func (r *categoryRepository) DeleteAndReassign(ctx context.Context, id uint, targetID *uint) ([]uint, error) {
+	var moved []uint
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var children int64
+		if err := tx.Model(&models.Category{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+			return err
+		}
+		// 回收站中的文章也引用该分类，一并转移
+		if err := tx.Unscoped().Model(&models.Post{}).Where("category_id = ?", id).Pluck("id", &moved).Error; err != nil {
+			return err
+		}
+		if (children > 0 || len(moved) > 0) && targetID == nil {
+			return ErrCategoryNotEmpty
+		}
+
+		if targetID != nil {
+			if err := tx.Model(&models.Category{}).Where("parent_id = ?", id).Update("parent_id", *targetID).Error; err != nil {
+				return err
+			}
+			if len(moved) > 0 {
+				err := tx.Unscoped().Model(&models.Post{}).Where("category_id = ?", id).Update("category_id", *targetID).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Delete(&models.Category{}, id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return moved, nil
+}
@@ -1,18 +1,36 @@
 package mysql
 
 import (
+	"context"
+	"time"
+
 	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // CommentRepository 评论仓库接口
 type CommentRepository interface {
-	Create(comment *models.Comment) error
-	Update(comment *models.Comment) error
-	Delete(id uint) error
-	FindByID(id uint) (*models.Comment, error)
-	ListByPostID(postID uint, page, pageSize int) ([]models.Comment, int64, error)
-	ListByUserID(userID uint, page, pageSize int) ([]models.Comment, int64, error)
+	Create(ctx context.Context, comment *models.Comment) error
+	Update(ctx context.Context, comment *models.Comment) error
+	Delete(ctx context.Context, id uint) error
+	Restore(ctx context.Context, id uint, ownerID *uint) (*models.Comment, error)
+	TrashByPost(ctx context.Context, postID uint, at time.Time) ([]models.Comment, error)
+	RestoreByPost(ctx context.Context, postID uint, at time.Time) ([]models.Comment, error)
+	ListTrashed(ctx context.Context, ownerID *uint, page, pageSize int) ([]models.Comment, int64, error)
+	ListPurgeable(ctx context.Context, before time.Time, limit int) ([]models.Comment, error)
+	Purge(ctx context.Context, ids []uint) error
+	PurgeByPosts(ctx context.Context, postIDs []uint) error
+	FindByID(ctx context.Context, id uint) (*models.Comment, error)
+	ListByPostID(ctx context.Context, postID uint, sort models.CommentSort, page, pageSize int) ([]models.Comment, int64, error)
+	ListByPostIDCursor(ctx context.Context, postID uint, sort models.CommentSort, after *utils.Cursor, limit int) ([]models.Comment, error)
+	CountByPostID(ctx context.Context, postID uint) (int64, error)
+	ListByUserID(ctx context.Context, userID uint, page, pageSize int) ([]models.Comment, int64, error)
+	Upvote(ctx context.Context, commentID, userID uint) error
+	RemoveUpvote(ctx context.Context, commentID, userID uint) error
+	VoteState(ctx context.Context, commentID, userID uint) (*models.CommentVoteState, error)
+	SetPinned(ctx context.Context, comment *models.Comment, pinned bool) ([]uint, error)
 }
 
 type commentRepository struct {
@@ -24,22 +42,180 @@ func NewCommentRepository(db *gorm.DB) CommentRepository {
 	return &commentRepository{db: db}
 }
 
-func (r *commentRepository) Create(comment *models.Comment) error {
-	return r.db.Create(comment).Error
+// Create 创建评论，已通过审核的评论同时计入文章的评论数
+func (r *commentRepository) Create(ctx context.Context, comment *models.Comment) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		if comment.Status != models.CommentStatusApproved {
+			return nil
+		}
+		return addCommentCount(tx, comment.PostID, 1)
+	})
 }
 
-func (r *commentRepository) Update(comment *models.Comment) error {
+func (r *commentRepository) Update(ctx context.Context, comment *models.Comment) error {
 	// Updates 方法默认只更新非零值字段，且不会更新 created_at
-	return r.db.Model(comment).Updates(comment).Error
+	return r.db.WithContext(ctx).Model(comment).Updates(comment).Error
 }
 
-func (r *commentRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Comment{}, id).Error
+// Delete 将评论移入回收站，已通过审核的评论同时从文章的评论数中扣除
+func (r *commentRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var comment models.Comment
+		if err := tx.Select("id, post_id, status").First(&comment, id).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Comment{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if comment.Status != models.CommentStatusApproved {
+			return nil
+		}
+		return addCommentCount(tx, comment.PostID, -1)
+	})
 }
 
-func (r *commentRepository) FindByID(id uint) (*models.Comment, error) {
+// Restore 从回收站恢复评论并返回该评论，ownerID非空时只恢复该用户的评论
+// 所属文章在回收站中的评论不能单独恢复，返回 gorm.ErrRecordNotFound
+func (r *commentRepository) Restore(ctx context.Context, id uint, ownerID *uint) (*models.Comment, error) {
 	var comment models.Comment
-	err := r.db.Preload("User").
+	query := r.db.WithContext(ctx).Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Where("post_id IN (?)", r.db.Model(&models.Post{}).Select("id"))
+	if ownerID != nil {
+		query = query.Where("user_id = ?", *ownerID)
+	}
+	if err := query.First(&comment).Error; err != nil {
+		return nil, err
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(&models.Comment{}).Where("id = ?", id).UpdateColumn("deleted_at", nil).Error
+		if err != nil || comment.Status != models.CommentStatusApproved {
+			return err
+		}
+		return addCommentCount(tx, comment.PostID, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+	comment.DeletedAt = gorm.DeletedAt{}
+	return &comment, nil
+}
+
+// TrashByPost 将文章下未删除的评论以文章的删除时间移入回收站，返回受影响的评论
+// 之后可通过相同的删除时间区分随文章删除的评论和之前单独删除的评论
+func (r *commentRepository) TrashByPost(ctx context.Context, postID uint, at time.Time) ([]models.Comment, error) {
+	var comments []models.Comment
+	db := r.db.WithContext(ctx)
+	if err := db.Select("id, post_id, user_id").Where("post_id = ?", postID).Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	if len(comments) == 0 {
+		return comments, nil
+	}
+	if err := db.Model(&models.Comment{}).Where("post_id = ?", postID).UpdateColumn("deleted_at", at).Error; err != nil {
+		return nil, err
+	}
+	return comments, syncCommentCount(db, postID)
+}
+
+// RestoreByPost 恢复随文章一起删除的评论，返回受影响的评论
+func (r *commentRepository) RestoreByPost(ctx context.Context, postID uint, at time.Time) ([]models.Comment, error) {
+	var comments []models.Comment
+	db := r.db.WithContext(ctx)
+	err := db.Unscoped().Select("id, post_id, user_id").Where("post_id = ? AND deleted_at = ?", postID, at).Find(&comments).Error
+	if err != nil || len(comments) == 0 {
+		return comments, err
+	}
+	err = db.Unscoped().Model(&models.Comment{}).Where("post_id = ? AND deleted_at = ?", postID, at).UpdateColumn("deleted_at", nil).Error
+	if err != nil {
+		return nil, err
+	}
+	return comments, syncCommentCount(db, postID)
+}
+
+// ListTrashed 按删除时间倒序分页查询单独删除的评论，ownerID非空时只查询该用户的评论
+func (r *commentRepository) ListTrashed(ctx context.Context, ownerID *uint, page, pageSize int) ([]models.Comment, int64, error) {
+	var comments []models.Comment
+	var total int64
+
+	query := r.db.WithContext(ctx).Unscoped().Model(&models.Comment{}).
+		Where("deleted_at IS NOT NULL").
+		Where("post_id IN (?)", r.db.Model(&models.Post{}).Select("id"))
+	if ownerID != nil {
+		query = query.Where("user_id = ?", *ownerID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Order("deleted_at DESC, id DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&comments).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return comments, total, nil
+}
+
+// ListPurgeable 查询在before之前移入回收站的评论
+func (r *commentRepository) ListPurgeable(ctx context.Context, before time.Time, limit int) ([]models.Comment, error) {
+	var comments []models.Comment
+	err := r.db.WithContext(ctx).Unscoped().
+		Select("id, post_id, user_id").
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("id").
+		Limit(limit).
+		Find(&comments).Error
+	return comments, err
+}
+
+// Purge 彻底删除评论，对这些评论的回复保留并成为顶级评论
+// 只有回收站中的评论会被彻底删除，它们在移入回收站时已从文章的评论数中扣除
+func (r *commentRepository) Purge(ctx context.Context, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	db := r.db.WithContext(ctx)
+	if err := db.Unscoped().Model(&models.Comment{}).Where("parent_id IN ?", ids).UpdateColumn("parent_id", nil).Error; err != nil {
+		return err
+	}
+	if err := db.Where("comment_id IN ?", ids).Delete(&models.CommentVote{}).Error; err != nil {
+		return err
+	}
+	return db.Unscoped().Where("id IN ?", ids).Delete(&models.Comment{}).Error
+}
+
+// PurgeByPosts 彻底删除文章下的全部评论
+func (r *commentRepository) PurgeByPosts(ctx context.Context, postIDs []uint) error {
+	if len(postIDs) == 0 {
+		return nil
+	}
+	db := r.db.WithContext(ctx)
+	// 先解除回复关系，避免逐行检查外键时父评论先于回复被删除
+	if err := db.Unscoped().Model(&models.Comment{}).Where("post_id IN ?", postIDs).UpdateColumn("parent_id", nil).Error; err != nil {
+		return err
+	}
+	err := db.Where("comment_id IN (?)", db.Unscoped().Model(&models.Comment{}).Select("id").Where("post_id IN ?", postIDs)).
+		Delete(&models.CommentVote{}).Error
+	if err != nil {
+		return err
+	}
+	return db.Unscoped().Where("post_id IN ?", postIDs).Delete(&models.Comment{}).Error
+}
+
+func (r *commentRepository) FindByID(ctx context.Context, id uint) (*models.Comment, error) {
+	var comment models.Comment
+	err := r.db.WithContext(ctx).Preload("User").
 		Preload("Post").
 		First(&comment, id).Error
 	if err != nil {
@@ -48,45 +224,89 @@ func (r *commentRepository) FindByID(id uint) (*models.Comment, error) {
 	return &comment, nil
 }
 
-func (r *commentRepository) ListByPostID(postID uint, page, pageSize int) ([]models.Comment, int64, error) {
+// ListByPostID 按sort分页查询文章已通过审核的评论，置顶的评论排在最前
+func (r *commentRepository) ListByPostID(ctx context.Context, postID uint, sort models.CommentSort, page, pageSize int) ([]models.Comment, int64, error) {
 	var comments []models.Comment
 	var total int64
 
-	err := r.db.Model(&models.Comment{}).
-		Where("post_id = ?", postID).
-		Count(&total).Error
+	order, err := commentOrder(sort)
 	if err != nil {
 		return nil, 0, err
 	}
 
+	query := r.db.WithContext(ctx).Model(&models.Comment{}).
+		Where("post_id = ? AND status = ?", postID, models.CommentStatusApproved)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
 	offset := (page - 1) * pageSize
-	err = r.db.Where("post_id = ?", postID).
-		Preload("User").
+	err = query.Preload("User").
 		Offset(offset).
 		Limit(pageSize).
-		Order("created_at DESC").
+		Order(order).
 		Find(&comments).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
+	if err := markAuthorComments(r.db.WithContext(ctx), postID, comments); err != nil {
+		return nil, 0, err
+	}
 	return comments, total, nil
 }
 
-func (r *commentRepository) ListByUserID(userID uint, page, pageSize int) ([]models.Comment, int64, error) {
+// ListByPostIDCursor 按sort查询文章下排在after之后的评论，after为nil时从第一条开始
+func (r *commentRepository) ListByPostIDCursor(ctx context.Context, postID uint, sort models.CommentSort, after *utils.Cursor, limit int) ([]models.Comment, error) {
 	var comments []models.Comment
-	var total int64
 
-	err := r.db.Model(&models.Comment{}).
-		Where("user_id = ?", userID).
-		Count(&total).Error
+	order, err := commentOrder(sort)
 	if err != nil {
+		return nil, err
+	}
+
+	query := r.db.WithContext(ctx).Where("post_id = ? AND status = ?", postID, models.CommentStatusApproved)
+	if after != nil {
+		if query, err = afterCommentCursor(query, sort, after); err != nil {
+			return nil, err
+		}
+	}
+
+	err = query.Preload("User").
+		Order(order).
+		Limit(limit).
+		Find(&comments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := markAuthorComments(r.db.WithContext(ctx), postID, comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// CountByPostID 统计文章下已通过审核的评论数
+func (r *commentRepository) CountByPostID(ctx context.Context, postID uint) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&models.Comment{}).
+		Where("post_id = ? AND status = ?", postID, models.CommentStatusApproved).
+		Count(&total).Error
+	return total, err
+}
+
+func (r *commentRepository) ListByUserID(ctx context.Context, userID uint, page, pageSize int) ([]models.Comment, int64, error) {
+	var comments []models.Comment
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.Comment{}).
+		Where("user_id = ? AND status = ?", userID, models.CommentStatusApproved)
+	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	offset := (page - 1) * pageSize
-	err = r.db.Where("user_id = ?", userID).
-		Preload("Post").
+	err := query.Preload("Post").
 		Offset(offset).
 		Limit(pageSize).
 		Order("created_at DESC").
@@ -97,3 +317,87 @@ func (r *commentRepository) ListByUserID(userID uint, page, pageSize int) ([]mod
 
 	return comments, total, nil
 }
+
+// Upvote 点赞评论，已点赞过时不重复计数
+func (r *commentRepository) Upvote(ctx context.Context, commentID, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.CommentVote{CommentID: commentID, UserID: userID})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.Comment{}).Where("id = ?", commentID).
+			UpdateColumn("upvote_count", gorm.Expr("upvote_count + 1")).Error
+	})
+}
+
+// RemoveUpvote 取消点赞，没有点赞过时不改变计数
+func (r *commentRepository) RemoveUpvote(ctx context.Context, commentID, userID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("comment_id = ? AND user_id = ?", commentID, userID).Delete(&models.CommentVote{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.Comment{}).Where("id = ? AND upvote_count > 0", commentID).
+			UpdateColumn("upvote_count", gorm.Expr("upvote_count - 1")).Error
+	})
+}
+
+// VoteState 返回评论的点赞数以及该用户是否已点赞
+func (r *commentRepository) VoteState(ctx context.Context, commentID, userID uint) (*models.CommentVoteState, error) {
+	var state models.CommentVoteState
+	db := r.db.WithContext(ctx)
+	if err := db.Model(&models.Comment{}).Where("id = ?", commentID).Pluck("upvote_count", &state.UpvoteCount).Error; err != nil {
+		return nil, err
+	}
+	var votes int64
+	if err := db.Model(&models.CommentVote{}).Where("comment_id = ? AND user_id = ?", commentID, userID).Count(&votes).Error; err != nil {
+		return nil, err
+	}
+	state.Upvoted = votes > 0
+	return &state, nil
+}
+
+// SetPinned 置顶或取消置顶评论，置顶时同时取消同一文章下其他评论的置顶，返回被取消置顶的其他评论
+func (r *commentRepository) SetPinned(ctx context.Context, comment *models.Comment, pinned bool) ([]uint, error) {
+	var unpinned []uint
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if pinned {
+			others := tx.Model(&models.Comment{}).Where("post_id = ? AND is_pinned = ? AND id <> ?", comment.PostID, true, comment.ID)
+			if err := others.Pluck("id", &unpinned).Error; err != nil {
+				return err
+			}
+			if len(unpinned) > 0 {
+				if err := tx.Model(&models.Comment{}).Where("id IN ?", unpinned).UpdateColumn("is_pinned", false).Error; err != nil {
+					return err
+				}
+			}
+		}
+		result := tx.Model(&models.Comment{}).Where("id = ?", comment.ID).UpdateColumn("is_pinned", pinned)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	return unpinned, err
+}
+
+// addCommentCount 累加文章的评论数，评论数不会减为负数
+// Post.CommentCount 是只读字段，通过表名更新；回收站中的文章也会更新，恢复后评论数保持正确
+func addCommentCount(tx *gorm.DB, postID uint, delta int64) error {
+	query := tx.Table("posts").Where("id = ?", postID)
+	if delta < 0 {
+		query = query.Where("comment_count >= ?", -delta)
+	}
+	return query.UpdateColumn("comment_count", gorm.Expr("comment_count + ?", delta)).Error
+}
+
+// syncCommentCount 批量移入或移出回收站后按未删除且已通过审核的评论重新统计文章的评论数
+func syncCommentCount(db *gorm.DB, postID uint) error {
+	count := db.Model(&models.Comment{}).Select("COUNT(*)").
+		Where("post_id = ? AND status = ?", postID, models.CommentStatusApproved)
+	return db.Table("posts").Where("id = ?", postID).UpdateColumn("comment_count", count).Error
+}
@@ -0,0 +1,88 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCommentSort 不支持的评论排序方式
+var ErrInvalidCommentSort = errors.New("invalid comment sort")
+
+// commentSortExprs 排序方式对应的排序表达式和是否升序，只有这里列出的表达式会出现在 ORDER BY 中
+var commentSortExprs = map[models.CommentSort]struct {
+	expr string
+	asc  bool
+}{
+	models.CommentSortNewest: {"comments.created_at", false},
+	models.CommentSortOldest: {"comments.created_at", true},
+	models.CommentSortTop:    {"comments.upvote_count", false},
+}
+
+// commentOrder 返回排序子句，置顶的评论排在最前，id作为最后的排序键保证顺序稳定
+func commentOrder(sort models.CommentSort) (string, error) {
+	s, ok := commentSortExprs[sort]
+	if !ok {
+		return "", ErrInvalidCommentSort
+	}
+	dir := "DESC"
+	if s.asc {
+		dir = "ASC"
+	}
+	return fmt.Sprintf("comments.is_pinned DESC, %s %s, comments.id %s", s.expr, dir, dir), nil
+}
+
+// afterCommentCursor 只保留按sort排在after之后的评论，游标由其他排序方式生成时返回 utils.ErrInvalidCursor
+func afterCommentCursor(db *gorm.DB, sort models.CommentSort, after *utils.Cursor) (*gorm.DB, error) {
+	if after.Sort != string(sort) {
+		return nil, utils.ErrInvalidCursor
+	}
+	s, ok := commentSortExprs[sort]
+	if !ok {
+		return nil, ErrInvalidCommentSort
+	}
+
+	var value interface{} = after.Time
+	if sort == models.CommentSortTop {
+		value = after.Num
+	}
+	op := "<"
+	if s.asc {
+		op = ">"
+	}
+	keyset := fmt.Sprintf("%s %s ? OR (%s = ? AND comments.id %s ?)", s.expr, op, s.expr, op)
+	return db.Where("(comments.is_pinned < ? OR (comments.is_pinned = ? AND ("+keyset+")))",
+		after.IsTop, after.IsTop, value, value, after.ID), nil
+}
+
+// CommentCursor 返回评论在sort排序中的位置，用于生成下一页的游标
+func CommentCursor(sort models.CommentSort, comment *models.Comment) utils.Cursor {
+	cursor := utils.Cursor{Sort: string(sort), IsTop: comment.IsPinned, Time: comment.CreatedAt, ID: comment.ID}
+	if sort == models.CommentSortTop {
+		cursor.Num = comment.UpvoteCount
+	}
+	return cursor
+}
+
+// markAuthorComments 为文章作者发表的评论加上作者标记
+func markAuthorComments(db *gorm.DB, postID uint, comments []models.Comment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+	var authorIDs []uint
+	if err := db.Unscoped().Model(&models.Post{}).Where("id = ?", postID).Pluck("user_id", &authorIDs).Error; err != nil {
+		return err
+	}
+	if len(authorIDs) == 0 {
+		return nil
+	}
+	for i := range comments {
+		if comments[i].UserID == authorIDs[0] {
+			comments[i].Badges = []string{models.CommentBadgeAuthor}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,258 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/utils"
+)
+
+// createComment 通过仓库在文章下创建已通过审核的评论，创建时间按调用顺序依次晚一分钟
+func (f *fixture) createComment(post *models.Post, user *models.User, upvotes int64) *models.Comment {
+	f.t.Helper()
+	f.clock = f.clock.Add(time.Minute)
+	comment := &models.Comment{
+		Content:     "comment",
+		PostID:      post.ID,
+		UserID:      user.ID,
+		Status:      models.CommentStatusApproved,
+		UpvoteCount: upvotes,
+		CreatedAt:   f.clock,
+		UpdatedAt:   f.clock,
+	}
+	if err := NewCommentRepository(f.db).Create(context.Background(), comment); err != nil {
+		f.t.Fatalf("create comment: %v", err)
+	}
+	return comment
+}
+
+func TestCommentRepositoryUpvote(t *testing.T) {
+	db := newTestDB(t)
+	f := newFixture(t, db)
+	repo := NewCommentRepository(db)
+	ctx := context.Background()
+
+	post := f.createPost("post", nil)
+	comment := f.createComment(post, f.user, 0)
+	bob := f.createUser("bob")
+
+	voteState := func(userID uint) models.CommentVoteState {
+		t.Helper()
+		state, err := repo.VoteState(ctx, comment.ID, userID)
+		if err != nil {
+			t.Fatalf("VoteState: %v", err)
+		}
+		return *state
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := repo.Upvote(ctx, comment.ID, bob.ID); err != nil {
+			t.Fatalf("Upvote: %v", err)
+		}
+	}
+	if err := repo.Upvote(ctx, comment.ID, f.user.ID); err != nil {
+		t.Fatalf("Upvote: %v", err)
+	}
+	if got, want := voteState(bob.ID), (models.CommentVoteState{UpvoteCount: 2, Upvoted: true}); got != want {
+		t.Errorf("after upvotes = %+v, want %+v", got, want)
+	}
+
+	// 重复取消点赞只减一次
+	for i := 0; i < 2; i++ {
+		if err := repo.RemoveUpvote(ctx, comment.ID, bob.ID); err != nil {
+			t.Fatalf("RemoveUpvote: %v", err)
+		}
+	}
+	if got, want := voteState(bob.ID), (models.CommentVoteState{UpvoteCount: 1}); got != want {
+		t.Errorf("after removing = %+v, want %+v", got, want)
+	}
+
+	// 计数不会减为负数
+	if err := db.Model(comment).UpdateColumn("upvote_count", 0).Error; err != nil {
+		t.Fatalf("reset upvote_count: %v", err)
+	}
+	if err := repo.RemoveUpvote(ctx, comment.ID, f.user.ID); err != nil {
+		t.Fatalf("RemoveUpvote: %v", err)
+	}
+	if got := voteState(f.user.ID); got.UpvoteCount != 0 || got.Upvoted {
+		t.Errorf("after removing from zero = %+v, want zero state", got)
+	}
+}
+
+func TestCommentRepositoryListByPostIDCursor(t *testing.T) {
+	db := newTestDB(t)
+	f := newFixture(t, db)
+	repo := NewCommentRepository(db)
+	ctx := context.Background()
+
+	post := f.createPost("post", nil)
+	bob := f.createUser("bob")
+	upvotes := []int64{2, 5, 2, 0, 5}
+	comments := make([]*models.Comment, len(upvotes))
+	for i, n := range upvotes {
+		comments[i] = f.createComment(post, bob, n)
+	}
+	authored := f.createComment(post, f.user, 1)
+	pinned := comments[3]
+	if _, err := repo.SetPinned(ctx, pinned, true); err != nil {
+		t.Fatalf("SetPinned: %v", err)
+	}
+
+	tests := []struct {
+		sort models.CommentSort
+		want []uint
+	}{
+		{models.CommentSortNewest, []uint{pinned.ID, authored.ID, comments[4].ID, comments[2].ID, comments[1].ID, comments[0].ID}},
+		{models.CommentSortOldest, []uint{pinned.ID, comments[0].ID, comments[1].ID, comments[2].ID, comments[4].ID, authored.ID}},
+		{models.CommentSortTop, []uint{pinned.ID, comments[4].ID, comments[1].ID, comments[2].ID, comments[0].ID, authored.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.sort), func(t *testing.T) {
+			var paged []uint
+			var after *utils.Cursor
+			for page := 0; page < len(tt.want); page++ {
+				list, err := repo.ListByPostIDCursor(ctx, post.ID, tt.sort, after, 2)
+				if err != nil {
+					t.Fatalf("ListByPostIDCursor: %v", err)
+				}
+				if len(list) == 0 {
+					break
+				}
+				for _, c := range list {
+					paged = append(paged, c.ID)
+					if isAuthor := len(c.Badges) > 0; isAuthor != (c.ID == authored.ID) {
+						t.Errorf("comment %d badges = %v", c.ID, c.Badges)
+					}
+				}
+				cursor := CommentCursor(tt.sort, &list[len(list)-1])
+				if after, err = utils.DecodeCursor(utils.EncodeCursor(&cursor)); err != nil {
+					t.Fatalf("DecodeCursor: %v", err)
+				}
+			}
+			if !equalIDs(paged, tt.want) {
+				t.Errorf("cursor pages = %v, want %v", paged, tt.want)
+			}
+		})
+	}
+
+	t.Run("cursor from another sort", func(t *testing.T) {
+		after := &utils.Cursor{Sort: string(models.CommentSortTop), ID: 1}
+		if _, err := repo.ListByPostIDCursor(ctx, post.ID, models.CommentSortOldest, after, 2); err != utils.ErrInvalidCursor {
+			t.Errorf("ListByPostIDCursor error = %v, want ErrInvalidCursor", err)
+		}
+	})
+}
+
+func TestCommentRepositoryPending(t *testing.T) {
+	db := newTestDB(t)
+	f := newFixture(t, db)
+	repo := NewCommentRepository(db)
+	ctx := context.Background()
+
+	post := f.createPost("post", nil)
+	approved := f.createComment(post, f.user, 0)
+	pending := &models.Comment{Content: "pending", PostID: post.ID, UserID: f.user.ID, Status: models.CommentStatusPending}
+	if err := repo.Create(ctx, pending); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	stored, err := repo.FindByID(ctx, pending.ID)
+	if err != nil {
+		t.Fatalf("FindByID: %v", err)
+	}
+	if stored.Status != models.CommentStatusPending {
+		t.Fatalf("stored status = %d, want pending", stored.Status)
+	}
+
+	want := []uint{approved.ID}
+	listed, total, err := repo.ListByPostID(ctx, post.ID, models.CommentSortNewest, 1, 10)
+	if err != nil {
+		t.Fatalf("ListByPostID: %v", err)
+	}
+	if got := commentIDs(listed); !equalIDs(got, want) || total != 1 {
+		t.Errorf("ListByPostID = %v (total %d), want %v", got, total, want)
+	}
+	listed, err = repo.ListByPostIDCursor(ctx, post.ID, models.CommentSortNewest, nil, 10)
+	if err != nil {
+		t.Fatalf("ListByPostIDCursor: %v", err)
+	}
+	if got := commentIDs(listed); !equalIDs(got, want) {
+		t.Errorf("ListByPostIDCursor = %v, want %v", got, want)
+	}
+	if count, err := repo.CountByPostID(ctx, post.ID); err != nil || count != 1 {
+		t.Errorf("CountByPostID = %d, %v, want 1", count, err)
+	}
+
+	posts := NewPostRepository(db)
+	detail, err := posts.FindByID(ctx, post.ID)
+	if err != nil {
+		t.Fatalf("post FindByID: %v", err)
+	}
+	if got := commentIDs(detail.Comments); !equalIDs(got, want) {
+		t.Errorf("post detail comments = %v, want %v", got, want)
+	}
+	list, _, err := posts.List(ctx, 1, 10, &models.PostQuery{})
+	if err != nil {
+		t.Fatalf("post List: %v", err)
+	}
+	if len(list) != 1 || list[0].CommentCount != 1 {
+		t.Errorf("post list comment_count = %+v, want 1", list)
+	}
+}
+
+func TestCommentRepositoryCommentCount(t *testing.T) {
+	db := newTestDB(t)
+	f := newFixture(t, db)
+	repo := NewCommentRepository(db)
+	ctx := context.Background()
+
+	post := f.createPost("post", nil)
+	first := f.createComment(post, f.user, 0)
+	f.createComment(post, f.user, 0)
+	pending := &models.Comment{Content: "pending", PostID: post.ID, UserID: f.user.ID, Status: models.CommentStatusPending}
+	if err := repo.Create(ctx, pending); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	commentCount := func() int64 {
+		t.Helper()
+		var counts []int64
+		if err := db.Unscoped().Model(&models.Post{}).Where("id = ?", post.ID).Pluck("comment_count", &counts).Error; err != nil {
+			t.Fatalf("read comment_count: %v", err)
+		}
+		return counts[0]
+	}
+
+	steps := []struct {
+		name string
+		run  func() error
+		want int64
+	}{
+		{"create", func() error { return nil }, 2},
+		{"trash pending", func() error { return repo.Delete(ctx, pending.ID) }, 2},
+		{"trash", func() error { return repo.Delete(ctx, first.ID) }, 1},
+		{"restore", func() error { _, err := repo.Restore(ctx, first.ID, nil); return err }, 2},
+		{"restore pending", func() error { _, err := repo.Restore(ctx, pending.ID, nil); return err }, 2},
+		{"trash by post", func() error { _, err := repo.TrashByPost(ctx, post.ID, f.clock); return err }, 0},
+		{"restore by post", func() error { _, err := repo.RestoreByPost(ctx, post.ID, f.clock); return err }, 2},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := commentCount(); got != step.want {
+			t.Errorf("after %s comment_count = %d, want %d", step.name, got, step.want)
+		}
+	}
+}
+
+// commentIDs 返回评论ID，便于比较列表顺序
+func commentIDs(comments []models.Comment) []uint {
+	ids := make([]uint, len(comments))
+	for i := range comments {
+		ids[i] = comments[i].ID
+	}
+	return ids
+}
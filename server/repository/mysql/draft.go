@@ -0,0 +1,105 @@
+package mysql
+
+import (
+	"context"
+
+	"github.com/personal-blog/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DraftRepository 文章草稿仓库接口，所有查询都限定在草稿所属用户内
+type DraftRepository interface {
+	Create(ctx context.Context, draft *models.PostDraft) error
+	Update(ctx context.Context, draft *models.PostDraft) error
+	SaveForPost(ctx context.Context, draft *models.PostDraft) error
+	Delete(ctx context.Context, userID, id uint) error
+	DeleteForPost(ctx context.Context, userID, postID uint) error
+	FindByID(ctx context.Context, userID, id uint) (*models.PostDraft, error)
+	ListByUser(ctx context.Context, userID uint, limit int) ([]models.PostDraft, error)
+	CountNew(ctx context.Context, userID uint) (int64, error)
+}
+
+type draftRepository struct {
+	db *gorm.DB
+}
+
+// NewDraftRepository 创建文章草稿仓库实例
+func NewDraftRepository(db *gorm.DB) DraftRepository {
+	return &draftRepository{db: db}
+}
+
+func (r *draftRepository) Create(ctx context.Context, draft *models.PostDraft) error {
+	return r.db.WithContext(ctx).Create(draft).Error
+}
+
+// Update 覆盖新文章草稿的内容，草稿不存在或不属于该用户时返回 gorm.ErrRecordNotFound
+func (r *draftRepository) Update(ctx context.Context, draft *models.PostDraft) error {
+	result := r.db.WithContext(ctx).Model(draft).
+		Where("user_id = ? AND post_id IS NULL", draft.UserID).
+		Select("title", "content", "category_id", "tags", "updated_at").
+		Updates(draft)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return r.db.WithContext(ctx).First(draft, draft.ID).Error
+}
+
+// SaveForPost 写入用户对已有文章的草稿，已存在时覆盖
+func (r *draftRepository) SaveForPost(ctx context.Context, draft *models.PostDraft) error {
+	db := r.db.WithContext(ctx)
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "post_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"title", "content", "category_id", "tags", "updated_at"}),
+	}).Create(draft).Error
+	if err != nil {
+		return err
+	}
+	// 冲突更新时部分数据库不返回已有记录的ID，重新读取
+	return db.Where("user_id = ? AND post_id = ?", draft.UserID, *draft.PostID).First(draft).Error
+}
+
+func (r *draftRepository) Delete(ctx context.Context, userID, id uint) error {
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&models.PostDraft{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *draftRepository) DeleteForPost(ctx context.Context, userID, postID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ? AND post_id = ?", userID, postID).Delete(&models.PostDraft{}).Error
+}
+
+func (r *draftRepository) FindByID(ctx context.Context, userID, id uint) (*models.PostDraft, error) {
+	var draft models.PostDraft
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&draft).Error
+	if err != nil {
+		return nil, err
+	}
+	return &draft, nil
+}
+
+// ListByUser 按最近保存时间查询用户的草稿
+func (r *draftRepository) ListByUser(ctx context.Context, userID uint, limit int) ([]models.PostDraft, error) {
+	drafts := []models.PostDraft{}
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("updated_at DESC, id DESC").
+		Limit(limit).
+		Find(&drafts).Error
+	return drafts, err
+}
+
+// CountNew 统计用户尚未创建文章的草稿数
+func (r *draftRepository) CountNew(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.PostDraft{}).Where("user_id = ? AND post_id IS NULL", userID).Count(&count).Error
+	return count, err
+}
@@ -1,6 +1,7 @@
 package mysql
 
 import (
+	"context"
 	"sync"
 
 	"gorm.io/gorm"
@@ -18,6 +19,13 @@ type Factory interface {
 	GetCategoryRepository() CategoryRepository
 	GetTagRepository() TagRepository
 	GetCommentRepository() CommentRepository
+	GetAnalyticsRepository() AnalyticsRepository
+	GetSeriesRepository() SeriesRepository
+	GetDraftRepository() DraftRepository
+	GetReactionRepository() ReactionRepository
+	// Transaction 在一个数据库事务中执行fn，fn内通过repos获取的仓库共享该事务
+	// fn返回错误或panic时回滚，否则提交；在事务内再次调用时使用保存点
+	Transaction(ctx context.Context, fn func(repos Factory) error) error
 }
 
 // factory 实现Factory接口
@@ -28,6 +36,10 @@ type factory struct {
 	categoryRepo CategoryRepository
 	tagRepo     TagRepository
 	commentRepo CommentRepository
+	analyticsRepo AnalyticsRepository
+	seriesRepo  SeriesRepository
+	draftRepo   DraftRepository
+	reactionRepo ReactionRepository
 	mu          sync.RWMutex
 }
 
@@ -120,3 +132,74 @@ func (f *factory) GetCommentRepository() CommentRepository {
 	}
 	return f.commentRepo
 }
+
+func (f *factory) GetAnalyticsRepository() AnalyticsRepository {
+	f.mu.RLock()
+	if f.analyticsRepo != nil {
+		defer f.mu.RUnlock()
+		return f.analyticsRepo
+	}
+	f.mu.RUnlock()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.analyticsRepo == nil {
+		f.analyticsRepo = NewAnalyticsRepository(f.db)
+	}
+	return f.analyticsRepo
+}
+
+func (f *factory) GetSeriesRepository() SeriesRepository {
+	f.mu.RLock()
+	if f.seriesRepo != nil {
+		defer f.mu.RUnlock()
+		return f.seriesRepo
+	}
+	f.mu.RUnlock()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.seriesRepo == nil {
+		f.seriesRepo = NewSeriesRepository(f.db)
+	}
+	return f.seriesRepo
+}
+
+func (f *factory) GetDraftRepository() DraftRepository {
+	f.mu.RLock()
+	if f.draftRepo != nil {
+		defer f.mu.RUnlock()
+		return f.draftRepo
+	}
+	f.mu.RUnlock()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.draftRepo == nil {
+		f.draftRepo = NewDraftRepository(f.db)
+	}
+	return f.draftRepo
+}
+
+func (f *factory) GetReactionRepository() ReactionRepository {
+	f.mu.RLock()
+	if f.reactionRepo != nil {
+		defer f.mu.RUnlock()
+		return f.reactionRepo
+	}
+	f.mu.RUnlock()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.reactionRepo == nil {
+		f.reactionRepo = NewReactionRepository(f.db)
+	}
+	return f.reactionRepo
+}
+
+func (f *factory) Transaction(ctx context.Context, fn func(repos Factory) error) error {
+	return f.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 事务内的工厂不是单例，仓库按需创建且只在本次事务中使用
+		return fn(&factory{db: tx})
+	})
+}
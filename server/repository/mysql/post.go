@@ -1,21 +1,41 @@
 package mysql
 
 import (
+	"context"
+	"time"
+
 	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // PostRepository 文章仓库接口
 type PostRepository interface {
-	Create(post *models.Post) error
-	Update(post *models.Post) error
-	Delete(id uint) error
-	FindByID(id uint) (*models.Post, error)
-	List(page, pageSize int, conditions map[string]interface{}) ([]models.Post, int64, error)
-	IncrementViewCount(id uint) error
-	ListByUserID(userID uint, page, pageSize int) ([]models.Post, int64, error)
-	ListByCategoryID(categoryID uint, page, pageSize int) ([]models.Post, int64, error)
-	ListByTagID(tagID uint, page, pageSize int) ([]models.Post, int64, error)
+	Create(ctx context.Context, post *models.Post) error
+	Update(ctx context.Context, post *models.Post) error
+	MarkPublished(ctx context.Context, id uint, at time.Time) error
+	SetPin(ctx context.Context, id uint, pinned bool, order int, until *time.Time) error
+	SetFeatured(ctx context.Context, id uint, featured bool) error
+	ExpirePins(ctx context.Context, now time.Time) ([]uint, error)
+	Trash(ctx context.Context, id uint, at time.Time) error
+	Restore(ctx context.Context, id uint, ownerID *uint) (*models.Post, error)
+	ListTrashed(ctx context.Context, ownerID *uint, page, pageSize int) ([]models.Post, int64, error)
+	ListPurgeable(ctx context.Context, before time.Time, limit int) ([]uint, error)
+	Purge(ctx context.Context, ids []uint) error
+	ReplaceTags(ctx context.Context, postID uint, tagIDs []uint) error
+	FindByID(ctx context.Context, id uint) (*models.Post, error)
+	PasswordHash(ctx context.Context, id uint) (string, error)
+	ViewCount(ctx context.Context, id uint) (int64, error)
+	List(ctx context.Context, page, pageSize int, q *models.PostQuery) ([]models.Post, int64, error)
+	ListByCursor(ctx context.Context, q *models.PostQuery, after *utils.Cursor, limit int) ([]models.Post, error)
+	Count(ctx context.Context, q *models.PostQuery) (int64, error)
+	ApplyViewCounts(ctx context.Context, batchID string, counts map[uint]int64) error
+	ListByUserID(ctx context.Context, userID uint, page, pageSize int) ([]models.Post, int64, error)
+	ListByCategoryID(ctx context.Context, categoryID uint, page, pageSize int) ([]models.Post, int64, error)
+	ListByTagID(ctx context.Context, tagID uint, page, pageSize int) ([]models.Post, int64, error)
+	ListRelatedCandidates(ctx context.Context, excludeID uint, limit int) ([]models.Post, error)
+	ListPublishedTimes(ctx context.Context) ([]time.Time, error)
 }
 
 type postRepository struct {
@@ -27,25 +47,164 @@ func NewPostRepository(db *gorm.DB) PostRepository {
 	return &postRepository{db: db}
 }
 
-func (r *postRepository) Create(post *models.Post) error {
-	return r.db.Create(post).Error
+// Create 只写入文章本身，标签等关联通过 ReplaceTags 单独维护
+func (r *postRepository) Create(ctx context.Context, post *models.Post) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(post).Error
 }
 
-func (r *postRepository) Update(post *models.Post) error {
+func (r *postRepository) Update(ctx context.Context, post *models.Post) error {
 	// Updates 方法默认只更新非零值字段，且不会更新 created_at
-	return r.db.Model(post).Updates(post).Error
+	// 关联不随文章更新，否则只会追加标签而不会移除已取消的标签
+	return r.db.WithContext(ctx).Model(post).Omit(clause.Associations).Updates(post).Error
+}
+
+// MarkPublished 已发布但从未记录发布时间的文章，将发布时间设为at
+// 撤回后再次发布的文章保留首次发布的时间
+func (r *postRepository) MarkPublished(ctx context.Context, id uint, at time.Time) error {
+	// 使用 UpdateColumn 避免修改 updated_at
+	return r.db.WithContext(ctx).Model(&models.Post{}).
+		Where("id = ? AND status = ? AND published_at IS NULL", id, models.PostStatusPublished).
+		UpdateColumn("published_at", at).Error
+}
+
+// SetPin 设置文章的置顶状态，取消置顶时order和until应为零值
+func (r *postRepository) SetPin(ctx context.Context, id uint, pinned bool, order int, until *time.Time) error {
+	// 置顶不属于内容修改，使用 UpdateColumns 避免修改 updated_at
+	return r.db.WithContext(ctx).Model(&models.Post{}).Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"is_top": pinned, "pin_order": order, "pinned_until": until}).Error
+}
+
+// SetFeatured 设置文章的精选标记
+func (r *postRepository) SetFeatured(ctx context.Context, id uint, featured bool) error {
+	return r.db.WithContext(ctx).Model(&models.Post{}).Where("id = ?", id).UpdateColumn("featured", featured).Error
+}
+
+// ExpirePins 取消置顶到期时间不晚于now的文章的置顶，返回被取消置顶的文章ID
+func (r *postRepository) ExpirePins(ctx context.Context, now time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Model(&models.Post{}).
+		Where("is_top = ? AND pinned_until <= ?", true, now).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	// 查询之后重新置顶的文章到期时间已经改变，不会被取消
+	err = r.db.WithContext(ctx).Model(&models.Post{}).
+		Where("id IN ? AND is_top = ? AND pinned_until <= ?", ids, true, now).
+		UpdateColumns(map[string]interface{}{"is_top": false, "pin_order": 0, "pinned_until": nil}).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// Trash 将文章移入回收站，文章不存在或已在回收站中时返回 gorm.ErrRecordNotFound
+func (r *postRepository) Trash(ctx context.Context, id uint, at time.Time) error {
+	// 使用 UpdateColumn 避免修改 updated_at
+	result := r.db.WithContext(ctx).Model(&models.Post{}).Where("id = ?", id).UpdateColumn("deleted_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Restore 从回收站恢复文章并返回恢复前的记录（含删除时间），ownerID非空时只恢复该用户的文章
+func (r *postRepository) Restore(ctx context.Context, id uint, ownerID *uint) (*models.Post, error) {
+	var post models.Post
+	query := r.db.WithContext(ctx).Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id)
+	if ownerID != nil {
+		query = query.Where("user_id = ?", *ownerID)
+	}
+	if err := query.First(&post).Error; err != nil {
+		return nil, err
+	}
+
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.Post{}).Where("id = ?", id).UpdateColumn("deleted_at", nil).Error
+	if err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// ListTrashed 按删除时间倒序分页查询回收站中的文章，ownerID非空时只查询该用户的文章
+func (r *postRepository) ListTrashed(ctx context.Context, ownerID *uint, page, pageSize int) ([]models.Post, int64, error) {
+	var posts []models.Post
+	var total int64
+
+	query := r.db.WithContext(ctx).Unscoped().Model(&models.Post{}).Where("deleted_at IS NOT NULL")
+	if ownerID != nil {
+		query = query.Where("user_id = ?", *ownerID)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Select("id, title, status, user_id, category_id, deleted_at").
+		Order("deleted_at DESC, id DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&posts).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return posts, total, nil
+}
+
+// ListPurgeable 查询在before之前移入回收站的文章ID
+func (r *postRepository) ListPurgeable(ctx context.Context, before time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.Post{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("id").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
 }
 
-func (r *postRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Post{}, id).Error
+// Purge 彻底删除文章及其标签、系列、草稿和浏览统计记录，评论需先通过 CommentRepository.PurgeByPosts 删除
+// 应在事务中与评论的删除一起调用
+func (r *postRepository) Purge(ctx context.Context, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	db := r.db.WithContext(ctx)
+	for _, table := range []string{"post_tags", "series_posts", "post_drafts", "post_view_buckets", "post_referrer_stats", "post_campaign_stats", "post_reactions", "post_reaction_counts"} {
+		if err := db.Exec("DELETE FROM "+table+" WHERE post_id IN ?", ids).Error; err != nil {
+			return err
+		}
+	}
+	return db.Unscoped().Where("id IN ?", ids).Delete(&models.Post{}).Error
 }
 
-func (r *postRepository) FindByID(id uint) (*models.Post, error) {
+// ReplaceTags 将文章的标签精确替换为tagIDs，tagIDs为空时清空文章的标签
+// 应在事务中与文章的写入一起调用
+func (r *postRepository) ReplaceTags(ctx context.Context, postID uint, tagIDs []uint) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Exec("DELETE FROM post_tags WHERE post_id = ?", postID).Error; err != nil {
+		return err
+	}
+	if len(tagIDs) == 0 {
+		return nil
+	}
+
+	rows := make([]map[string]interface{}, 0, len(tagIDs))
+	for _, tagID := range tagIDs {
+		rows = append(rows, map[string]interface{}{"post_id": postID, "tag_id": tagID})
+	}
+	return db.Table("post_tags").Create(rows).Error
+}
+
+func (r *postRepository) FindByID(ctx context.Context, id uint) (*models.Post, error) {
 	var post models.Post
-	err := r.db.Preload("User").
+	err := r.db.WithContext(ctx).Preload("User").
 		Preload("Category").
 		Preload("Tags").
-		Preload("Comments").
+		Preload("Comments", "status = ?", models.CommentStatusApproved).
 		First(&post, id).Error
 	if err != nil {
 		return nil, err
@@ -53,18 +212,46 @@ func (r *postRepository) FindByID(id uint) (*models.Post, error) {
 	return &post, nil
 }
 
-func (r *postRepository) List(page, pageSize int, conditions map[string]interface{}) ([]models.Post, int64, error) {
+// PasswordHash 查询密码保护文章的密码哈希
+func (r *postRepository) PasswordHash(ctx context.Context, id uint) (string, error) {
+	var hashes []string
+	err := r.db.WithContext(ctx).Model(&models.Post{}).Where("id = ?", id).Pluck("password_hash", &hashes).Error
+	if err != nil {
+		return "", err
+	}
+	if len(hashes) == 0 {
+		return "", gorm.ErrRecordNotFound
+	}
+	return hashes[0], nil
+}
+
+// ViewCount 查询文章已写入数据库的浏览量
+func (r *postRepository) ViewCount(ctx context.Context, id uint) (int64, error) {
+	var counts []int64
+	err := r.db.WithContext(ctx).Model(&models.Post{}).Where("id = ?", id).Pluck("view_count", &counts).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(counts) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return counts[0], nil
+}
+
+// List 按查询条件分页查询文章
+func (r *postRepository) List(ctx context.Context, page, pageSize int, q *models.PostQuery) ([]models.Post, int64, error) {
 	var posts []models.Post
 	var total int64
 
-	query := r.db.Model(&models.Post{})
-	
-	// 应用查询条件
-	for key, value := range conditions {
-		query = query.Where(key, value)
+	order, err := postOrder(q)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	err := query.Count(&total).Error
+	// 应用查询条件
+	query := filterPosts(r.db.WithContext(ctx).Model(&models.Post{}), q)
+
+	err = query.Count(&total).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -75,7 +262,7 @@ func (r *postRepository) List(page, pageSize int, conditions map[string]interfac
 		Preload("Tags").
 		Offset(offset).
 		Limit(pageSize).
-		Order("is_top DESC, created_at DESC").
+		Order(order).
 		Find(&posts).Error
 	if err != nil {
 		return nil, 0, err
@@ -84,32 +271,79 @@ func (r *postRepository) List(page, pageSize int, conditions map[string]interfac
 	return posts, total, nil
 }
 
-func (r *postRepository) IncrementViewCount(id uint) error {
-	return r.db.Model(&models.Post{}).Where("id = ?", id).
-		UpdateColumn("view_count", gorm.Expr("view_count + ?", 1)).Error
+// ListByCursor 按查询的排序方式查询排在after之后的文章，after为nil时从第一条开始
+// 不使用OFFSET，翻页深度不影响查询性能，翻页期间新发布的文章也不会导致重复
+func (r *postRepository) ListByCursor(ctx context.Context, q *models.PostQuery, after *utils.Cursor, limit int) ([]models.Post, error) {
+	var posts []models.Post
+
+	order, err := postOrder(q)
+	if err != nil {
+		return nil, err
+	}
+
+	query := filterPosts(r.db.WithContext(ctx).Model(&models.Post{}), q)
+	if after != nil {
+		if query, err = afterCursor(query, q, after); err != nil {
+			return nil, err
+		}
+	}
+
+	err = query.Preload("User").
+		Preload("Category").
+		Preload("Tags").
+		Order(order).
+		Limit(limit).
+		Find(&posts).Error
+	return posts, err
+}
+
+// Count 统计符合条件的文章数
+func (r *postRepository) Count(ctx context.Context, q *models.PostQuery) (int64, error) {
+	var total int64
+	err := filterPosts(r.db.WithContext(ctx).Model(&models.Post{}), q).Count(&total).Error
+	return total, err
+}
+
+// ApplyViewCounts 在一个事务中累加一批浏览量并记录批次ID
+// 同一批次重复应用时直接返回，保证进程在写库后、清理缓存前退出也不会重复计数
+func (r *postRepository) ApplyViewCounts(ctx context.Context, batchID string, counts map[uint]int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		claimed, err := claimBatch(tx, batchID, time.Now())
+		if err != nil || !claimed {
+			return err
+		}
+		for id, delta := range counts {
+			if err := tx.Model(&models.Post{}).Where("id = ?", id).
+				UpdateColumn("view_count", gorm.Expr("view_count + ?", delta)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-func (r *postRepository) ListByUserID(userID uint, page, pageSize int) ([]models.Post, int64, error) {
-	return r.List(page, pageSize, map[string]interface{}{"user_id": userID})
+func (r *postRepository) ListByUserID(ctx context.Context, userID uint, page, pageSize int) ([]models.Post, int64, error) {
+	return r.List(ctx, page, pageSize, &models.PostQuery{AuthorID: userID})
 }
 
-func (r *postRepository) ListByCategoryID(categoryID uint, page, pageSize int) ([]models.Post, int64, error) {
-	return r.List(page, pageSize, map[string]interface{}{"category_id": categoryID})
+func (r *postRepository) ListByCategoryID(ctx context.Context, categoryID uint, page, pageSize int) ([]models.Post, int64, error) {
+	return r.List(ctx, page, pageSize, &models.PostQuery{CategoryIDs: []uint{categoryID}})
 }
 
-func (r *postRepository) ListByTagID(tagID uint, page, pageSize int) ([]models.Post, int64, error) {
+func (r *postRepository) ListByTagID(ctx context.Context, tagID uint, page, pageSize int) ([]models.Post, int64, error) {
 	var posts []models.Post
 	var total int64
 
-	subQuery := r.db.Table("post_tags").Select("post_id").Where("tag_id = ?", tagID)
+	db := r.db.WithContext(ctx)
+	subQuery := db.Table("post_tags").Select("post_id").Where("tag_id = ?", tagID)
 	
-	err := r.db.Model(&models.Post{}).Where("id IN (?)", subQuery).Count(&total).Error
+	err := db.Model(&models.Post{}).Where("id IN (?)", subQuery).Count(&total).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
 	offset := (page - 1) * pageSize
-	err = r.db.Preload("User").
+	err = db.Preload("User").
 		Preload("Category").
 		Preload("Tags").
 		Where("id IN (?)", subQuery).
@@ -123,3 +357,26 @@ func (r *postRepository) ListByTagID(tagID uint, page, pageSize int) ([]models.P
 
 	return posts, total, nil
 }
+
+// ListRelatedCandidates 查询用于相关文章推荐的候选文章：最近发布的公开文章，只加载标题、摘要、分类和标签
+func (r *postRepository) ListRelatedCandidates(ctx context.Context, excludeID uint, limit int) ([]models.Post, error) {
+	var posts []models.Post
+	err := r.db.WithContext(ctx).
+		Select("id, title, summary, cover, category_id, created_at").
+		Preload("Tags").
+		Where("id <> ? AND status = ? AND visibility = ?", excludeID, 1, models.VisibilityPublic).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&posts).Error
+	return posts, err
+}
+
+// ListPublishedTimes 查询所有已发布且公开列出的文章的发布时间，用于生成按年月的归档
+// 按年月分组的SQL在三种数据库中写法不同，且需要按服务器时区划分月份，因此由服务层分组
+func (r *postRepository) ListPublishedTimes(ctx context.Context) ([]time.Time, error) {
+	var times []time.Time
+	err := r.db.WithContext(ctx).Model(&models.Post{}).
+		Where("status = ? AND visibility <> ? AND published_at IS NOT NULL", models.PostStatusPublished, models.VisibilityUnlisted).
+		Pluck("published_at", &times).Error
+	return times, err
+}
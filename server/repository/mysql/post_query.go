@@ -0,0 +1,144 @@
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidPostSort 不支持的文章排序方式
+var ErrInvalidPostSort = errors.New("invalid post sort")
+
+// postSortExprs 排序方式对应的排序表达式，只有这里列出的表达式会出现在 ORDER BY 中
+var postSortExprs = map[models.PostSort]string{
+	models.PostSortDefault:   "COALESCE(posts.published_at, posts.created_at)",
+	models.PostSortPublished: "COALESCE(posts.published_at, posts.created_at)",
+	models.PostSortCreated:   "posts.created_at",
+	models.PostSortUpdated:   "posts.updated_at",
+	models.PostSortViews:     "posts.view_count",
+	models.PostSortComments:  "posts.comment_count",
+}
+
+// likeEscaper 转义LIKE中的通配符，配合 ESCAPE '!' 使用，三种数据库的写法一致
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// filterPosts 将筛选条件转换为参数化的查询条件
+func filterPosts(db *gorm.DB, q *models.PostQuery) *gorm.DB {
+	if q.PublicOnly {
+		if q.OwnerID != 0 {
+			db = db.Where("((posts.status = ? AND posts.visibility <> ?) OR posts.user_id = ?)",
+				models.PostStatusPublished, models.VisibilityUnlisted, q.OwnerID)
+		} else {
+			db = db.Where("posts.status = ? AND posts.visibility <> ?", models.PostStatusPublished, models.VisibilityUnlisted)
+		}
+	}
+	if q.Keyword != "" {
+		like := "%" + likeEscaper.Replace(strings.ToLower(q.Keyword)) + "%"
+		db = db.Where("(LOWER(posts.title) LIKE ? ESCAPE '!' OR LOWER(posts.content) LIKE ? ESCAPE '!')", like, like)
+	}
+	if len(q.CategoryIDs) > 0 {
+		db = db.Where("posts.category_id IN ?", q.CategoryIDs)
+	}
+	if len(q.TagIDs) > 0 {
+		if q.MatchAllTags {
+			db = db.Where("posts.id IN (SELECT post_id FROM post_tags WHERE tag_id IN ? GROUP BY post_id HAVING COUNT(*) = ?)",
+				q.TagIDs, len(q.TagIDs))
+		} else {
+			db = db.Where("posts.id IN (SELECT post_id FROM post_tags WHERE tag_id IN ?)", q.TagIDs)
+		}
+	}
+	if q.AuthorID != 0 {
+		db = db.Where("posts.user_id = ?", q.AuthorID)
+	}
+	if q.Status != 0 {
+		db = db.Where("posts.status = ?", q.Status)
+	}
+	if q.PublishedFrom != nil {
+		db = db.Where("posts.published_at >= ?", *q.PublishedFrom)
+	}
+	if q.PublishedTo != nil {
+		db = db.Where("posts.published_at < ?", *q.PublishedTo)
+	}
+	if q.HasCover != nil {
+		if *q.HasCover {
+			db = db.Where("COALESCE(posts.cover, '') <> ''")
+		} else {
+			db = db.Where("COALESCE(posts.cover, '') = ''")
+		}
+	}
+	if q.Pinned != nil {
+		db = db.Where("posts.is_top = ?", *q.Pinned)
+	}
+	if q.Featured != nil {
+		db = db.Where("posts.featured = ?", *q.Featured)
+	}
+	return db
+}
+
+// postOrder 返回排序子句，id作为最后的排序键保证顺序稳定
+func postOrder(q *models.PostQuery) (string, error) {
+	expr, ok := postSortExprs[q.Sort]
+	if !ok {
+		return "", ErrInvalidPostSort
+	}
+	if q.Sort == models.PostSortDefault {
+		return "posts.is_top DESC, posts.pin_order ASC, " + expr + " DESC, posts.id DESC", nil
+	}
+	dir := "DESC"
+	if q.Ascending {
+		dir = "ASC"
+	}
+	return fmt.Sprintf("%s %s, posts.id %s", expr, dir, dir), nil
+}
+
+// afterCursor 只保留按查询的排序排在after之后的文章，游标由其他排序方式生成时返回 utils.ErrInvalidCursor
+func afterCursor(db *gorm.DB, q *models.PostQuery, after *utils.Cursor) (*gorm.DB, error) {
+	if after.Sort != string(q.Sort) {
+		return nil, utils.ErrInvalidCursor
+	}
+	expr, ok := postSortExprs[q.Sort]
+	if !ok {
+		return nil, ErrInvalidPostSort
+	}
+
+	var value interface{} = after.Time
+	if q.Sort == models.PostSortViews || q.Sort == models.PostSortComments {
+		value = after.Num
+	}
+	op := "<"
+	if q.Ascending && q.Sort != models.PostSortDefault {
+		op = ">"
+	}
+	keyset := fmt.Sprintf("%s %s ? OR (%s = ? AND posts.id %s ?)", expr, op, expr, op)
+
+	if q.Sort == models.PostSortDefault {
+		return db.Where("(posts.is_top < ? OR (posts.is_top = ? AND (posts.pin_order > ? OR (posts.pin_order = ? AND ("+keyset+")))))",
+			after.IsTop, after.IsTop, after.PinOrder, after.PinOrder, value, value, after.ID), nil
+	}
+	return db.Where("("+keyset+")", value, value, after.ID), nil
+}
+
+// PostCursor 返回文章在查询排序中的位置，用于生成下一页的游标
+func PostCursor(q *models.PostQuery, post *models.Post) utils.Cursor {
+	cursor := utils.Cursor{Sort: string(q.Sort), IsTop: post.IsTop, PinOrder: post.PinOrder, ID: post.ID}
+	switch q.Sort {
+	case models.PostSortCreated:
+		cursor.Time = post.CreatedAt
+	case models.PostSortUpdated:
+		cursor.Time = post.UpdatedAt
+	case models.PostSortViews:
+		cursor.Num = post.ViewCount
+	case models.PostSortComments:
+		cursor.Num = post.CommentCount
+	default:
+		cursor.Time = post.CreatedAt
+		if post.PublishedAt != nil {
+			cursor.Time = *post.PublishedAt
+		}
+	}
+	return cursor
+}
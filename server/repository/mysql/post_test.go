@@ -0,0 +1,157 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/utils"
+)
+
+func TestPostRepositoryListByTags(t *testing.T) {
+	db := newTestDB(t)
+	f := newFixture(t, db)
+	repo := NewPostRepository(db)
+	ctx := context.Background()
+
+	goTag, dbTag := f.createTag("go"), f.createTag("db")
+	both := f.createPost("both", func(p *models.Post) { p.Tags = []models.Tag{*goTag, *dbTag} })
+	onlyGo := f.createPost("only go", func(p *models.Post) { p.Tags = []models.Tag{*goTag} })
+	f.createPost("untagged", nil)
+
+	tests := []struct {
+		name string
+		q    *models.PostQuery
+		want []uint
+	}{
+		{"any", &models.PostQuery{TagIDs: []uint{goTag.ID, dbTag.ID}}, []uint{onlyGo.ID, both.ID}},
+		{"all", &models.PostQuery{TagIDs: []uint{goTag.ID, dbTag.ID}, MatchAllTags: true}, []uint{both.ID}},
+		{"single", &models.PostQuery{TagIDs: []uint{dbTag.ID}}, []uint{both.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posts, total, err := repo.List(ctx, 1, 10, tt.q)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+			if got := postIDs(posts); !equalIDs(got, tt.want) || total != int64(len(tt.want)) {
+				t.Errorf("List = %v (total %d), want %v", got, total, tt.want)
+			}
+		})
+	}
+
+	posts, total, err := repo.ListByTagID(ctx, goTag.ID, 1, 10)
+	if err != nil {
+		t.Fatalf("ListByTagID: %v", err)
+	}
+	if total != 2 || len(posts) != 2 {
+		t.Errorf("ListByTagID = %v (total %d), want 2 posts", postIDs(posts), total)
+	}
+}
+
+func TestPostRepositoryListByCursor(t *testing.T) {
+	db := newTestDB(t)
+	f := newFixture(t, db)
+	repo := NewPostRepository(db)
+	ctx := context.Background()
+
+	views := []int64{5, 3, 5, 8, 1, 3}
+	for i, n := range views {
+		n := n
+		pinned := i == 1 || i == 4
+		post := f.createPost("post", func(p *models.Post) {
+			p.ViewCount = n
+			p.IsTop = pinned
+			p.PinOrder = int(n)
+		})
+		for j := 0; j < i%3; j++ {
+			f.createComment(post, f.user, 0)
+		}
+	}
+
+	sorts := []struct {
+		name string
+		q    *models.PostQuery
+	}{
+		{"default", &models.PostQuery{}},
+		{"published desc", &models.PostQuery{Sort: models.PostSortPublished}},
+		{"created asc", &models.PostQuery{Sort: models.PostSortCreated, Ascending: true}},
+		{"views desc", &models.PostQuery{Sort: models.PostSortViews}},
+		{"views asc", &models.PostQuery{Sort: models.PostSortViews, Ascending: true}},
+		{"comments desc", &models.PostQuery{Sort: models.PostSortComments}},
+	}
+	for _, s := range sorts {
+		t.Run(s.name, func(t *testing.T) {
+			all, _, err := repo.List(ctx, 1, 100, s.q)
+			if err != nil {
+				t.Fatalf("List: %v", err)
+			}
+
+			// 游标经过编码和解码，与客户端传回的游标一致
+			var paged []models.Post
+			var after *utils.Cursor
+			for page := 0; page < len(views); page++ {
+				posts, err := repo.ListByCursor(ctx, s.q, after, 2)
+				if err != nil {
+					t.Fatalf("ListByCursor: %v", err)
+				}
+				if len(posts) == 0 {
+					break
+				}
+				paged = append(paged, posts...)
+				cursor := PostCursor(s.q, &posts[len(posts)-1])
+				if after, err = utils.DecodeCursor(utils.EncodeCursor(&cursor)); err != nil {
+					t.Fatalf("DecodeCursor: %v", err)
+				}
+			}
+
+			if got, want := postIDs(paged), postIDs(all); !equalIDs(got, want) {
+				t.Errorf("cursor pages = %v, want %v", got, want)
+			}
+		})
+	}
+
+	t.Run("cursor from another sort", func(t *testing.T) {
+		after := &utils.Cursor{Sort: string(models.PostSortViews), ID: 1}
+		if _, err := repo.ListByCursor(ctx, &models.PostQuery{}, after, 2); err != utils.ErrInvalidCursor {
+			t.Errorf("ListByCursor error = %v, want ErrInvalidCursor", err)
+		}
+	})
+}
+
+func TestPostRepositoryApplyViewCounts(t *testing.T) {
+	db := newTestDB(t)
+	f := newFixture(t, db)
+	repo := NewPostRepository(db)
+	ctx := context.Background()
+
+	a := f.createPost("a", func(p *models.Post) { p.ViewCount = 10 })
+	b := f.createPost("b", nil)
+
+	viewCount := func(id uint) int64 {
+		t.Helper()
+		post, err := repo.FindByID(ctx, id)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		return post.ViewCount
+	}
+
+	if err := repo.ApplyViewCounts(ctx, "batch-1", map[uint]int64{a.ID: 3, b.ID: 2}); err != nil {
+		t.Fatalf("ApplyViewCounts: %v", err)
+	}
+	// 重放同一批次不会重复计数
+	if err := repo.ApplyViewCounts(ctx, "batch-1", map[uint]int64{a.ID: 3, b.ID: 2}); err != nil {
+		t.Fatalf("ApplyViewCounts replay: %v", err)
+	}
+	if err := repo.ApplyViewCounts(ctx, "batch-2", map[uint]int64{a.ID: 1}); err != nil {
+		t.Fatalf("ApplyViewCounts: %v", err)
+	}
+
+	if got := viewCount(a.ID); got != 14 {
+		t.Errorf("view_count of a = %d, want 14", got)
+	}
+	if got := viewCount(b.ID); got != 2 {
+		t.Errorf("view_count of b = %d, want 2", got)
+	}
+}
@@ -0,0 +1,130 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	"github.com/personal-blog/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReactionRepository 文章反应仓库接口
+type ReactionRepository interface {
+	Add(ctx context.Context, reaction *models.PostReaction) (bool, error)
+	Remove(ctx context.Context, postID, userID uint, reactionType string) (bool, error)
+	ListTypes(ctx context.Context, postID, userID uint) ([]string, error)
+	ListReactedPosts(ctx context.Context, userID uint, reactionType string, page, pageSize int) ([]models.Post, int64, error)
+	Counts(ctx context.Context, postIDs []uint) (map[uint]map[string]int64, error)
+	ApplyCounts(ctx context.Context, batchID string, counts map[uint]map[string]int64) error
+}
+
+type reactionRepository struct {
+	db *gorm.DB
+}
+
+// NewReactionRepository 创建文章反应仓库实例
+func NewReactionRepository(db *gorm.DB) ReactionRepository {
+	return &reactionRepository{db: db}
+}
+
+// Add 记录用户的反应，用户已做出过同类反应时返回false
+func (r *reactionRepository) Add(ctx context.Context, reaction *models.PostReaction) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(reaction)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Remove 撤销用户的反应，用户没有做出过该反应时返回false
+func (r *reactionRepository) Remove(ctx context.Context, postID, userID uint, reactionType string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Where("post_id = ? AND user_id = ? AND type = ?", postID, userID, reactionType).
+		Delete(&models.PostReaction{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListTypes 查询用户对文章做出过的反应类型
+func (r *reactionRepository) ListTypes(ctx context.Context, postID, userID uint) ([]string, error) {
+	types := []string{}
+	err := r.db.WithContext(ctx).Model(&models.PostReaction{}).
+		Where("post_id = ? AND user_id = ?", postID, userID).
+		Order("id").
+		Pluck("type", &types).Error
+	return types, err
+}
+
+// ListReactedPosts 按反应时间倒序分页查询用户做出过某种反应的文章
+// 只包含用户仍然可以在列表中看到的文章：已发布且公开列出的文章，以及用户自己的文章
+func (r *reactionRepository) ListReactedPosts(ctx context.Context, userID uint, reactionType string, page, pageSize int) ([]models.Post, int64, error) {
+	var posts []models.Post
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&models.Post{}).
+		Joins("JOIN post_reactions ON post_reactions.post_id = posts.id").
+		Where("post_reactions.user_id = ? AND post_reactions.type = ?", userID, reactionType)
+	query = filterPosts(query, &models.PostQuery{PublicOnly: true, OwnerID: userID})
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err := query.Select("posts.*").
+		Preload("User").
+		Preload("Category").
+		Preload("Tags").
+		Order("post_reactions.created_at DESC, post_reactions.id DESC").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&posts).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return posts, total, nil
+}
+
+// Counts 查询已写入数据库的反应总数，没有任何反应的文章不在结果中
+func (r *reactionRepository) Counts(ctx context.Context, postIDs []uint) (map[uint]map[string]int64, error) {
+	counts := make(map[uint]map[string]int64, len(postIDs))
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []models.PostReactionCount
+	if err := r.db.WithContext(ctx).Where("post_id IN ?", postIDs).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if counts[row.PostID] == nil {
+			counts[row.PostID] = make(map[string]int64)
+		}
+		counts[row.PostID][row.Type] = row.Count
+	}
+	return counts, nil
+}
+
+// ApplyCounts 在一个事务中累加一批反应数的增量并记录批次ID，同一批次只会应用一次
+func (r *reactionRepository) ApplyCounts(ctx context.Context, batchID string, counts map[uint]map[string]int64) error {
+	rows := make([]models.PostReactionCount, 0, len(counts))
+	for postID, types := range counts {
+		for reactionType, delta := range types {
+			if delta != 0 {
+				rows = append(rows, models.PostReactionCount{PostID: postID, Type: reactionType, Count: delta})
+			}
+		}
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		claimed, err := claimBatch(tx, batchID, time.Now())
+		if err != nil || !claimed || len(rows) == 0 {
+			return err
+		}
+		return tx.Clauses(addOnConflict(tx, "post_reaction_counts", "count", "post_id", "type")).
+			CreateInBatches(rows, 200).Error
+	})
+}
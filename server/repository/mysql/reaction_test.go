@@ -0,0 +1,64 @@
+package mysql
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/personal-blog/models"
+)
+
+func TestReactionRepositoryApplyCounts(t *testing.T) {
+	db := newTestDB(t)
+	f := newFixture(t, db)
+	repo := NewReactionRepository(db)
+	ctx := context.Background()
+
+	a, b := f.createPost("a", nil), f.createPost("b", nil)
+
+	batches := []struct {
+		id     string
+		counts map[uint]map[string]int64
+	}{
+		{"batch-1", map[uint]map[string]int64{a.ID: {models.ReactionLike: 3}}},
+		// 重放同一批次不会重复累加
+		{"batch-1", map[uint]map[string]int64{a.ID: {models.ReactionLike: 3}}},
+		{"batch-2", map[uint]map[string]int64{a.ID: {models.ReactionLike: -1}, b.ID: {models.ReactionLike: 2}}},
+		{"batch-3", map[uint]map[string]int64{b.ID: {models.ReactionLike: 0}}},
+	}
+	for _, batch := range batches {
+		if err := repo.ApplyCounts(ctx, batch.id, batch.counts); err != nil {
+			t.Fatalf("ApplyCounts %s: %v", batch.id, err)
+		}
+	}
+
+	got, err := repo.Counts(ctx, []uint{a.ID, b.ID})
+	if err != nil {
+		t.Fatalf("Counts: %v", err)
+	}
+	want := map[uint]map[string]int64{
+		a.ID: {models.ReactionLike: 2},
+		b.ID: {models.ReactionLike: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Counts = %v, want %v", got, want)
+	}
+}
+
+func TestReactionRepositoryAdd(t *testing.T) {
+	db := newTestDB(t)
+	f := newFixture(t, db)
+	repo := NewReactionRepository(db)
+	ctx := context.Background()
+
+	post := f.createPost("post", nil)
+	for i, want := range []bool{true, false} {
+		added, err := repo.Add(ctx, &models.PostReaction{PostID: post.ID, UserID: f.user.ID, Type: models.ReactionLike})
+		if err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		if added != want {
+			t.Errorf("Add #%d = %v, want %v", i+1, added, want)
+		}
+	}
+}
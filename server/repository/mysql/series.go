@@ -0,0 +1,155 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/personal-blog/models"
+	"gorm.io/gorm"
+)
+
+// ErrPostInOtherSeries 文章已属于其他系列
+var ErrPostInOtherSeries = errors.New("post already belongs to another series")
+
+// SeriesRepository 系列仓库接口
+type SeriesRepository interface {
+	Create(ctx context.Context, series *models.Series) error
+	Update(ctx context.Context, series *models.Series) error
+	Delete(ctx context.Context, id uint) error
+	FindByID(ctx context.Context, id uint) (*models.Series, error)
+	FindBySlug(ctx context.Context, slug string) (*models.Series, error)
+	FindByPostID(ctx context.Context, postID uint) (*models.Series, error)
+	List(ctx context.Context, page, pageSize int) ([]models.Series, int64, error)
+	ListEntries(ctx context.Context, seriesID uint) ([]models.SeriesEntry, error)
+	SetPosts(ctx context.Context, seriesID uint, postIDs []uint) error
+}
+
+type seriesRepository struct {
+	db *gorm.DB
+}
+
+// NewSeriesRepository 创建系列仓库实例
+func NewSeriesRepository(db *gorm.DB) SeriesRepository {
+	return &seriesRepository{db: db}
+}
+
+func (r *seriesRepository) Create(ctx context.Context, series *models.Series) error {
+	return r.db.WithContext(ctx).Create(series).Error
+}
+
+func (r *seriesRepository) Update(ctx context.Context, series *models.Series) error {
+	// Updates 方法默认只更新非零值字段，且不会更新 created_at
+	return r.db.WithContext(ctx).Model(series).Updates(series).Error
+}
+
+// Delete 删除系列及其文章关联，文章本身保留
+func (r *seriesRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("series_id = ?", id).Delete(&models.SeriesPost{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Series{}, id).Error
+	})
+}
+
+func (r *seriesRepository) FindByID(ctx context.Context, id uint) (*models.Series, error) {
+	var series models.Series
+	err := r.db.WithContext(ctx).First(&series, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+func (r *seriesRepository) FindBySlug(ctx context.Context, slug string) (*models.Series, error) {
+	var series models.Series
+	err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&series).Error
+	if err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+// FindByPostID 查询文章所属的系列
+func (r *seriesRepository) FindByPostID(ctx context.Context, postID uint) (*models.Series, error) {
+	var series models.Series
+	err := r.db.WithContext(ctx).
+		Joins("JOIN series_posts ON series_posts.series_id = series.id").
+		Where("series_posts.post_id = ?", postID).
+		First(&series).Error
+	if err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+func (r *seriesRepository) List(ctx context.Context, page, pageSize int) ([]models.Series, int64, error) {
+	var series []models.Series
+	var total int64
+
+	err := r.db.WithContext(ctx).Model(&models.Series{}).Count(&total).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	err = r.db.WithContext(ctx).Offset(offset).Limit(pageSize).Order("created_at DESC").Find(&series).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return series, total, nil
+}
+
+// ListEntries 按顺序查询系列中的文章，包含未发布的文章，已删除的文章不返回
+func (r *seriesRepository) ListEntries(ctx context.Context, seriesID uint) ([]models.SeriesEntry, error) {
+	var entries []models.SeriesEntry
+	err := r.db.WithContext(ctx).
+		Table("series_posts").
+		Select("series_posts.post_id, posts.title, posts.status, series_posts.position").
+		Joins("JOIN posts ON posts.id = series_posts.post_id").
+		Where("series_posts.series_id = ? AND posts.deleted_at IS NULL", seriesID).
+		Order("series_posts.position").
+		Scan(&entries).Error
+	return entries, err
+}
+
+// SetPosts 在一个事务中用postIDs的顺序替换系列中的全部文章
+// 文章已属于其他系列时返回 ErrPostInOtherSeries，文章不存在时返回 gorm.ErrRecordNotFound
+func (r *seriesRepository) SetPosts(ctx context.Context, seriesID uint, postIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(postIDs) > 0 {
+			var count int64
+			if err := tx.Model(&models.Post{}).Where("id IN ?", postIDs).Count(&count).Error; err != nil {
+				return err
+			}
+			if count != int64(len(postIDs)) {
+				return gorm.ErrRecordNotFound
+			}
+
+			var conflicts int64
+			err := tx.Model(&models.SeriesPost{}).
+				Where("post_id IN ? AND series_id <> ?", postIDs, seriesID).
+				Count(&conflicts).Error
+			if err != nil {
+				return err
+			}
+			if conflicts > 0 {
+				return ErrPostInOtherSeries
+			}
+		}
+
+		if err := tx.Where("series_id = ?", seriesID).Delete(&models.SeriesPost{}).Error; err != nil {
+			return err
+		}
+		if len(postIDs) == 0 {
+			return nil
+		}
+
+		rows := make([]models.SeriesPost, len(postIDs))
+		for i, postID := range postIDs {
+			rows[i] = models.SeriesPost{SeriesID: seriesID, PostID: postID, Position: i + 1}
+		}
+		return tx.Create(&rows).Error
+	})
+}
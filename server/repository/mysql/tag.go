@@ -1,20 +1,31 @@
 package mysql
 
 import (
+	"context"
+	"errors"
+
 	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // TagRepository 标签仓库接口
 type TagRepository interface {
-	Create(tag *models.Tag) error
-	Update(tag *models.Tag) error
-	Delete(id uint) error
-	FindByID(id uint) (*models.Tag, error)
-	List(page, pageSize int) ([]models.Tag, int64, error)
-	FindByName(name string) (*models.Tag, error)
-	BatchCreate(tags []models.Tag) error
-	FindOrCreateByNames(names []string) ([]models.Tag, error)
+	Create(ctx context.Context, tag *models.Tag) error
+	Update(ctx context.Context, tag *models.Tag) error
+	Delete(ctx context.Context, id uint) error
+	FindByID(ctx context.Context, id uint) (*models.Tag, error)
+	List(ctx context.Context, page, pageSize int) ([]models.Tag, int64, error)
+	FindByName(ctx context.Context, name string) (*models.Tag, error)
+	FindByKey(ctx context.Context, key string) (*models.Tag, error)
+	BatchCreate(ctx context.Context, tags []models.Tag) error
+	FindOrCreateByNames(ctx context.Context, names []string) ([]models.Tag, error)
+	FindAliasOwner(ctx context.Context, alias string) (*models.TagAlias, error)
+	AddAlias(ctx context.Context, alias *models.TagAlias) error
+	DeleteAlias(ctx context.Context, tagID, aliasID uint) error
+	Merge(ctx context.Context, sourceIDs []uint, targetID uint) ([]uint, error)
+	ListByPostCount(ctx context.Context, limit int) ([]models.TagCloudItem, error)
 }
 
 type tagRepository struct {
@@ -26,68 +37,251 @@ func NewTagRepository(db *gorm.DB) TagRepository {
 	return &tagRepository{db: db}
 }
 
-func (r *tagRepository) Create(tag *models.Tag) error {
-	return r.db.Create(tag).Error
+func (r *tagRepository) Create(ctx context.Context, tag *models.Tag) error {
+	return r.db.WithContext(ctx).Create(tag).Error
 }
 
-func (r *tagRepository) Update(tag *models.Tag) error {
-	// Updates 方法默认只更新非零值字段，且不会更新 created_at
-	return r.db.Model(tag).Updates(tag).Error
+func (r *tagRepository) Update(ctx context.Context, tag *models.Tag) error {
+	// 只更新名称，别名通过单独的接口维护
+	return r.db.WithContext(ctx).Model(tag).
+		Select("name", "normalized_name", "updated_at").
+		Updates(tag).Error
 }
 
-func (r *tagRepository) Delete(id uint) error {
-	return r.db.Delete(&models.Tag{}, id).Error
+// Delete 删除标签及其别名和文章关联
+func (r *tagRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", id).Delete(&models.TagAlias{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM post_tags WHERE tag_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Tag{}, id).Error
+	})
 }
 
-func (r *tagRepository) FindByID(id uint) (*models.Tag, error) {
+func (r *tagRepository) FindByID(ctx context.Context, id uint) (*models.Tag, error) {
 	var tag models.Tag
-	err := r.db.First(&tag, id).Error
+	err := r.db.WithContext(ctx).Preload("Aliases").First(&tag, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &tag, nil
 }
 
-func (r *tagRepository) List(page, pageSize int) ([]models.Tag, int64, error) {
+// List 分页查询标签，并填充每个标签的已发布文章数
+func (r *tagRepository) List(ctx context.Context, page, pageSize int) ([]models.Tag, int64, error) {
 	var tags []models.Tag
 	var total int64
 
-	err := r.db.Model(&models.Tag{}).Count(&total).Error
+	err := r.db.WithContext(ctx).Model(&models.Tag{}).Count(&total).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
 	offset := (page - 1) * pageSize
-	err = r.db.Offset(offset).Limit(pageSize).Find(&tags).Error
+	err = r.db.WithContext(ctx).Offset(offset).Limit(pageSize).Find(&tags).Error
 	if err != nil {
 		return nil, 0, err
 	}
+	if len(tags) == 0 {
+		return tags, total, nil
+	}
 
+	ids := make([]uint, len(tags))
+	for i, tag := range tags {
+		ids[i] = tag.ID
+	}
+	var counts []struct {
+		TagID uint
+		Count int64
+	}
+	err = r.db.WithContext(ctx).
+		Table("post_tags").
+		Select("post_tags.tag_id, COUNT(*) AS count").
+		Joins("JOIN posts ON posts.id = post_tags.post_id").
+		Where("post_tags.tag_id IN ? AND posts.status = ? AND posts.deleted_at IS NULL", ids, 1).
+		Group("post_tags.tag_id").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	byID := make(map[uint]int64, len(counts))
+	for _, c := range counts {
+		byID[c.TagID] = c.Count
+	}
+	for i := range tags {
+		tags[i].PostCount = byID[tags[i].ID]
+	}
 	return tags, total, nil
 }
 
-func (r *tagRepository) FindByName(name string) (*models.Tag, error) {
+func (r *tagRepository) FindByName(ctx context.Context, name string) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&tag).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// FindByKey 按规范化名称查找标签，规范化名称或别名匹配均可
+func (r *tagRepository) FindByKey(ctx context.Context, key string) (*models.Tag, error) {
 	var tag models.Tag
-	err := r.db.Where("name = ?", name).First(&tag).Error
+	err := r.db.WithContext(ctx).Where("normalized_name = ?", key).First(&tag).Error
+	if err == nil {
+		return &tag, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	err = r.db.WithContext(ctx).
+		Where("id = (?)", r.db.Model(&models.TagAlias{}).Select("tag_id").Where("alias = ?", key)).
+		First(&tag).Error
 	if err != nil {
 		return nil, err
 	}
 	return &tag, nil
 }
 
-func (r *tagRepository) BatchCreate(tags []models.Tag) error {
-	return r.db.Create(&tags).Error
+func (r *tagRepository) BatchCreate(ctx context.Context, tags []models.Tag) error {
+	for i := range tags {
+		tags[i].Name = utils.NormalizeTagName(tags[i].Name)
+		tags[i].NormalizedName = utils.TagKey(tags[i].Name)
+	}
+	return r.db.WithContext(ctx).Create(&tags).Error
 }
 
-func (r *tagRepository) FindOrCreateByNames(names []string) ([]models.Tag, error) {
+// FindOrCreateByNames 按名称查找标签，不存在时创建
+// 名称先规范化，同一标签的不同写法和别名都会映射到已有标签，返回结果已去重
+func (r *tagRepository) FindOrCreateByNames(ctx context.Context, names []string) ([]models.Tag, error) {
 	var tags []models.Tag
+	seen := make(map[uint]bool, len(names))
 	for _, name := range names {
-		var tag models.Tag
-		err := r.db.Where("name = ?", name).FirstOrCreate(&tag, models.Tag{Name: name}).Error
+		display := utils.NormalizeTagName(name)
+		if display == "" {
+			continue
+		}
+		key := utils.TagKey(display)
+
+		tag, err := r.FindByKey(ctx, key)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			tag = &models.Tag{Name: display, NormalizedName: key}
+			// 并发创建同一标签时以先写入的为准
+			err = r.db.WithContext(ctx).Where("normalized_name = ?", key).FirstOrCreate(tag).Error
+		}
 		if err != nil {
 			return nil, err
 		}
-		tags = append(tags, tag)
+
+		if !seen[tag.ID] {
+			seen[tag.ID] = true
+			tags = append(tags, *tag)
+		}
 	}
 	return tags, nil
 }
+
+// FindAliasOwner 查询别名记录
+func (r *tagRepository) FindAliasOwner(ctx context.Context, alias string) (*models.TagAlias, error) {
+	var a models.TagAlias
+	err := r.db.WithContext(ctx).Where("alias = ?", alias).First(&a).Error
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *tagRepository) AddAlias(ctx context.Context, alias *models.TagAlias) error {
+	return r.db.WithContext(ctx).Create(alias).Error
+}
+
+func (r *tagRepository) DeleteAlias(ctx context.Context, tagID, aliasID uint) error {
+	result := r.db.WithContext(ctx).Where("id = ? AND tag_id = ?", aliasID, tagID).Delete(&models.TagAlias{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Merge 在一个事务中将源标签合并到目标标签：
+// 文章关联改为目标标签（已有目标标签的文章不重复关联），源标签的名称和别名成为目标标签的别名，然后删除源标签。
+// 返回关联发生变化的文章ID
+func (r *tagRepository) Merge(ctx context.Context, sourceIDs []uint, targetID uint) ([]uint, error) {
+	var postIDs []uint
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var sources []models.Tag
+		if err := tx.Where("id IN ?", sourceIDs).Find(&sources).Error; err != nil {
+			return err
+		}
+		if len(sources) != len(sourceIDs) {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.First(&models.Tag{}, targetID).Error; err != nil {
+			return err
+		}
+
+		err := tx.Table("post_tags").Distinct("post_id").Where("tag_id IN ?", sourceIDs).Pluck("post_id", &postIDs).Error
+		if err != nil {
+			return err
+		}
+
+		// 改写文章关联
+		if len(postIDs) > 0 {
+			rows := make([]map[string]interface{}, len(postIDs))
+			for i, postID := range postIDs {
+				rows[i] = map[string]interface{}{"post_id": postID, "tag_id": targetID}
+			}
+			err := tx.Table("post_tags").Clauses(clause.OnConflict{DoNothing: true}).Create(rows).Error
+			if err != nil {
+				return err
+			}
+			if err := tx.Exec("DELETE FROM post_tags WHERE tag_id IN ?", sourceIDs).Error; err != nil {
+				return err
+			}
+		}
+
+		// 源标签的别名和名称转为目标标签的别名
+		err = tx.Model(&models.TagAlias{}).Where("tag_id IN ?", sourceIDs).Update("tag_id", targetID).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Tag{}, sourceIDs).Error; err != nil {
+			return err
+		}
+		for _, source := range sources {
+			alias := models.TagAlias{TagID: targetID, Alias: utils.TagKey(source.Name)}
+			err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&alias).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return postIDs, nil
+}
+
+// ListByPostCount 按已发布文章数从高到低查询标签，不返回没有文章的标签
+func (r *tagRepository) ListByPostCount(ctx context.Context, limit int) ([]models.TagCloudItem, error) {
+	var items []models.TagCloudItem
+	err := r.db.WithContext(ctx).
+		Table("tags").
+		Select("tags.id, tags.name, COUNT(*) AS post_count").
+		Joins("JOIN post_tags ON post_tags.tag_id = tags.id").
+		Joins("JOIN posts ON posts.id = post_tags.post_id").
+		Where("posts.status = ? AND posts.deleted_at IS NULL", 1).
+		Group("tags.id, tags.name").
+		Order("post_count DESC, tags.name").
+		Limit(limit).
+		Scan(&items).Error
+	return items, err
+}
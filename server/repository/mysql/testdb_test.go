@@ -0,0 +1,126 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/personal-blog/config"
+	"github.com/personal-blog/database"
+	"github.com/personal-blog/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB 打开进程内的SQLite数据库并执行全部迁移，每个测试使用独立的数据库
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	cfg := config.DatabaseConfig{
+		Driver: "sqlite",
+		DBName: fmt.Sprintf("file:%s?mode=memory&cache=shared", name),
+	}
+	db, err := database.Open(cfg, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatalf("create migrator: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	return db
+}
+
+// fixture 测试数据构造器，创建的文章按调用顺序依次晚一分钟发布
+type fixture struct {
+	t        *testing.T
+	db       *gorm.DB
+	user     *models.User
+	category *models.Category
+	clock    time.Time
+}
+
+func newFixture(t *testing.T, db *gorm.DB) *fixture {
+	f := &fixture{t: t, db: db, clock: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	f.user = f.createUser("alice")
+	f.category = &models.Category{Name: "go"}
+	f.create(f.category)
+	return f
+}
+
+func (f *fixture) create(value interface{}) {
+	f.t.Helper()
+	if err := f.db.Create(value).Error; err != nil {
+		f.t.Fatalf("create %T: %v", value, err)
+	}
+}
+
+func (f *fixture) createUser(username string) *models.User {
+	f.t.Helper()
+	user := &models.User{Username: username, Password: "x", Email: username + "@example.com", Role: models.RoleUser}
+	f.create(user)
+	return user
+}
+
+func (f *fixture) createTag(name string) *models.Tag {
+	f.t.Helper()
+	tag := &models.Tag{Name: name, NormalizedName: name}
+	f.create(tag)
+	return tag
+}
+
+// createPost 创建已发布的文章，edit 可以在写入前修改文章字段
+func (f *fixture) createPost(title string, edit func(post *models.Post)) *models.Post {
+	f.t.Helper()
+	f.clock = f.clock.Add(time.Minute)
+	published := f.clock
+	post := &models.Post{
+		Title:       title,
+		Content:     title,
+		Status:      models.PostStatusPublished,
+		Visibility:  models.VisibilityPublic,
+		UserID:      f.user.ID,
+		CategoryID:  f.category.ID,
+		PublishedAt: &published,
+		CreatedAt:   published,
+		UpdatedAt:   published,
+	}
+	if edit != nil {
+		edit(post)
+	}
+	f.create(post)
+	return post
+}
+
+// postIDs 返回文章ID，便于比较列表顺序
+func postIDs(posts []models.Post) []uint {
+	ids := make([]uint, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+	}
+	return ids
+}
+
+func equalIDs(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -1,19 +1,21 @@
 package mysql
 
 import (
+	"context"
+
 	"github.com/personal-blog/models"
 	"gorm.io/gorm"
 )
 
 // UserRepository 用户仓库接口
 type UserRepository interface {
-	Create(user *models.User) error
-	Update(user *models.User) error
-	Delete(id uint) error
-	FindByID(id uint) (*models.User, error)
-	FindByUsername(username string) (*models.User, error)
-	FindByEmail(email string) (*models.User, error)
-	List(page, pageSize int) ([]models.User, int64, error)
+	Create(ctx context.Context, user *models.User) error
+	Update(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id uint) error
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	FindByUsername(ctx context.Context, username string) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	List(ctx context.Context, page, pageSize int) ([]models.User, int64, error)
 }
 
 type userRepository struct {
@@ -25,57 +27,57 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) Create(user *models.User) error {
-	return r.db.Create(user).Error
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
 }
 
-func (r *userRepository) Update(user *models.User) error {
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 	// Updates 方法默认只更新非零值字段，且不会更新 created_at
-	return r.db.Model(user).Updates(user).Error
+	return r.db.WithContext(ctx).Model(user).Updates(user).Error
 }
 
-func (r *userRepository) Delete(id uint) error {
-	return r.db.Delete(&models.User{}, id).Error
+func (r *userRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.User{}, id).Error
 }
 
-func (r *userRepository) FindByID(id uint) (*models.User, error) {
+func (r *userRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	err := r.db.First(&user, id).Error
+	err := r.db.WithContext(ctx).First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) FindByUsername(username string) (*models.User, error) {
+func (r *userRepository) FindByUsername(ctx context.Context, username string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("username = ?", username).First(&user).Error
+	err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) FindByEmail(email string) (*models.User, error) {
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) List(page, pageSize int) ([]models.User, int64, error) {
+func (r *userRepository) List(ctx context.Context, page, pageSize int) ([]models.User, int64, error) {
 	var users []models.User
 	var total int64
 
-	err := r.db.Model(&models.User{}).Count(&total).Error
+	err := r.db.WithContext(ctx).Model(&models.User{}).Count(&total).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
 	offset := (page - 1) * pageSize
-	err = r.db.Offset(offset).Limit(pageSize).Find(&users).Error
+	err = r.db.WithContext(ctx).Offset(offset).Limit(pageSize).Find(&users).Error
 	if err != nil {
 		return nil, 0, err
 	}
@@ -0,0 +1,208 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	analyticsPendingKey  = "analytics:pending"  // 尚未写入数据库的统计增量
+	analyticsFlushingKey = "analytics:flushing" // 正在写入数据库的统计批次
+	analyticsPopularKey  = "analytics:popular:"
+	analyticsPopularTTL  = 10 * time.Minute
+	analyticsFieldSep    = "|"
+)
+
+// AnalyticsHit 一次计入统计的文章浏览
+type AnalyticsHit struct {
+	PostID   uint
+	Time     time.Time
+	Referrer string // 来源域名，(direct) 表示没有来源
+	Source   string // utm_source
+	Medium   string // utm_medium
+	Campaign string // utm_campaign
+}
+
+// AnalyticsBatch 一批待写入数据库的统计增量
+type AnalyticsBatch struct {
+	ID        string
+	Buckets   []models.PostViewBucket
+	Referrers []models.PostReferrerStat
+	Campaigns []models.PostCampaignStat
+}
+
+// AnalyticsCache 文章浏览统计缓存接口
+// 浏览先在缓存中按小时、天、来源和推广活动累加，再由后台任务按批次写入数据库
+type AnalyticsCache interface {
+	RecordHit(ctx context.Context, hit *AnalyticsHit) error
+	BeginFlush(ctx context.Context, batchID string) (*AnalyticsBatch, error)
+	EndFlush(ctx context.Context, batch *AnalyticsBatch) error
+	SetPopular(ctx context.Context, key string, posts []models.PopularPost) error
+	GetPopular(ctx context.Context, key string) ([]models.PopularPost, error)
+}
+
+type analyticsCache struct {
+	client *redis.Client
+}
+
+// NewAnalyticsCache 创建浏览统计缓存实例
+func NewAnalyticsCache(client *redis.Client) AnalyticsCache {
+	return &analyticsCache{client: client}
+}
+
+func (c *analyticsCache) RecordHit(ctx context.Context, hit *AnalyticsHit) error {
+	ctx, span := tracing.Start(ctx, "AnalyticsCache.RecordHit")
+	defer span.End()
+
+	pipe := c.client.TxPipeline()
+	for _, field := range analyticsFields(hit) {
+		pipe.HIncrBy(ctx, analyticsPendingKey, field, 1)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// BeginFlush 取出一批待写入数据库的统计增量，没有数据时返回nil
+func (c *analyticsCache) BeginFlush(ctx context.Context, batchID string) (*AnalyticsBatch, error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsCache.BeginFlush")
+	defer span.End()
+
+	values, err := beginFlushScript.Run(ctx, c.client, []string{analyticsPendingKey, analyticsFlushingKey}, batchID).StringSlice()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var id string
+	counts := make(map[string]int64, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		field, value := values[i], values[i+1]
+		if field == "batch" {
+			id = value
+			continue
+		}
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || count <= 0 {
+			continue
+		}
+		counts[field] = count
+	}
+	return decodeAnalyticsBatch(id, counts), nil
+}
+
+// EndFlush 批次写入数据库后删除
+func (c *analyticsCache) EndFlush(ctx context.Context, batch *AnalyticsBatch) error {
+	ctx, span := tracing.Start(ctx, "AnalyticsCache.EndFlush")
+	defer span.End()
+
+	return endFlushScript.Run(ctx, c.client, []string{analyticsFlushingKey}, batch.ID).Err()
+}
+
+func (c *analyticsCache) SetPopular(ctx context.Context, key string, posts []models.PopularPost) error {
+	ctx, span := tracing.Start(ctx, "AnalyticsCache.SetPopular")
+	defer span.End()
+
+	data, err := json.Marshal(posts)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, analyticsPopularKey+key, data, jitter(analyticsPopularTTL)).Err()
+}
+
+func (c *analyticsCache) GetPopular(ctx context.Context, key string) ([]models.PopularPost, error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsCache.GetPopular")
+	defer span.End()
+
+	data, err := c.client.Get(ctx, analyticsPopularKey+key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	posts := []models.PopularPost{}
+	if err := json.Unmarshal(data, &posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// analyticsFields 将一次浏览编码为待累加的哈希字段
+// h|文章|小时  d|文章|天  r|文章|天|域名  u|文章|天|source|medium|campaign，时间均为UTC秒级时间戳
+func analyticsFields(hit *AnalyticsHit) []string {
+	t := hit.Time.UTC()
+	hour := t.Truncate(time.Hour).Unix()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix()
+	post := strconv.FormatUint(uint64(hit.PostID), 10)
+
+	fields := []string{
+		joinField("h", post, strconv.FormatInt(hour, 10)),
+		joinField("d", post, strconv.FormatInt(day, 10)),
+	}
+	if hit.Referrer != "" {
+		fields = append(fields, joinField("r", post, strconv.FormatInt(day, 10), hit.Referrer))
+	}
+	if hit.Source != "" || hit.Medium != "" || hit.Campaign != "" {
+		fields = append(fields, joinField("u", post, strconv.FormatInt(day, 10), hit.Source, hit.Medium, hit.Campaign))
+	}
+	return fields
+}
+
+func joinField(parts ...string) string {
+	for i, part := range parts {
+		parts[i] = strings.ReplaceAll(part, analyticsFieldSep, "_")
+	}
+	return strings.Join(parts, analyticsFieldSep)
+}
+
+// decodeAnalyticsBatch 将哈希字段还原为统计行，无法识别的字段直接丢弃
+func decodeAnalyticsBatch(id string, counts map[string]int64) *AnalyticsBatch {
+	batch := &AnalyticsBatch{ID: id}
+	for field, count := range counts {
+		parts := strings.Split(field, analyticsFieldSep)
+		if len(parts) < 3 {
+			continue
+		}
+		postID, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		ts, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		at := time.Unix(ts, 0).UTC()
+
+		switch {
+		case parts[0] == "h" && len(parts) == 3:
+			batch.Buckets = append(batch.Buckets, models.PostViewBucket{
+				PostID: uint(postID), Granularity: models.GranularityHour, BucketStart: at, Views: count,
+			})
+		case parts[0] == "d" && len(parts) == 3:
+			batch.Buckets = append(batch.Buckets, models.PostViewBucket{
+				PostID: uint(postID), Granularity: models.GranularityDay, BucketStart: at, Views: count,
+			})
+		case parts[0] == "r" && len(parts) == 4:
+			batch.Referrers = append(batch.Referrers, models.PostReferrerStat{
+				PostID: uint(postID), Day: at, Domain: parts[3], Views: count,
+			})
+		case parts[0] == "u" && len(parts) == 6:
+			batch.Campaigns = append(batch.Campaigns, models.PostCampaignStat{
+				PostID: uint(postID), Day: at, Source: parts[3], Medium: parts[4], Campaign: parts[5], Views: count,
+			})
+		default:
+			continue
+		}
+	}
+	return batch
+}
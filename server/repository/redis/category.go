@@ -8,6 +8,7 @@ import (
 
 	"github.com/redis/go-redis/v9"
 	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
 )
 
 const (
@@ -20,60 +21,83 @@ const (
 type CategoryCache interface {
 	Set(ctx context.Context, category *models.Category) error
 	Get(ctx context.Context, id uint) (*models.Category, error)
+	GetOrLoad(ctx context.Context, id uint, load func(ctx context.Context) (*models.Category, error)) (*models.Category, error)
 	Delete(ctx context.Context, id uint) error
 	SetList(ctx context.Context, categories []models.Category) error
 	GetList(ctx context.Context) ([]models.Category, error)
+	DeleteList(ctx context.Context) error
 }
 
 type categoryCache struct {
 	client *redis.Client
+	loader *entryLoader
 }
 
 // NewCategoryCache 创建分类缓存实例
 func NewCategoryCache(client *redis.Client) CategoryCache {
-	return &categoryCache{client: client}
+	return &categoryCache{client: client, loader: newEntryLoader(redisBackend{client: client})}
 }
 
 func (c *categoryCache) Set(ctx context.Context, category *models.Category) error {
+	ctx, span := tracing.Start(ctx, "CategoryCache.Set")
+	defer span.End()
+
 	key := fmt.Sprintf("%s%d", categoryKeyPrefix, category.ID)
-	data, err := json.Marshal(category)
-	if err != nil {
-		return err
-	}
-	return c.client.Set(ctx, key, data, categoryExpiration).Err()
+	return c.loader.set(ctx, key, category, categoryTTL())
 }
 
 func (c *categoryCache) Get(ctx context.Context, id uint) (*models.Category, error) {
+	ctx, span := tracing.Start(ctx, "CategoryCache.Get")
+	defer span.End()
+
 	key := fmt.Sprintf("%s%d", categoryKeyPrefix, id)
-	data, err := c.client.Get(ctx, key).Bytes()
-	if err != nil {
-		if err == redis.Nil {
-			return nil, nil
-		}
+	var category models.Category
+	ok, err := c.loader.get(ctx, key, &category)
+	if err != nil || !ok {
 		return nil, err
 	}
+	return &category, nil
+}
+
+// GetOrLoad 读取缓存，未命中时通过load从数据库加载，并发未命中只会加载一次
+func (c *categoryCache) GetOrLoad(ctx context.Context, id uint, load func(ctx context.Context) (*models.Category, error)) (*models.Category, error) {
+	ctx, span := tracing.Start(ctx, "CategoryCache.GetOrLoad")
+	defer span.End()
 
+	key := fmt.Sprintf("%s%d", categoryKeyPrefix, id)
 	var category models.Category
-	if err := json.Unmarshal(data, &category); err != nil {
+	err := c.loader.load(ctx, key, categoryTTL(), &category, func(ctx context.Context) (interface{}, error) {
+		return load(ctx)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &category, nil
 }
 
 func (c *categoryCache) Delete(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "CategoryCache.Delete")
+	defer span.End()
+
 	key := fmt.Sprintf("%s%d", categoryKeyPrefix, id)
 	return c.client.Del(ctx, key).Err()
 }
 
 func (c *categoryCache) SetList(ctx context.Context, categories []models.Category) error {
+	ctx, span := tracing.Start(ctx, "CategoryCache.SetList")
+	defer span.End()
+
 	data, err := json.Marshal(categories)
 	if err != nil {
 		return err
 	}
-	return c.client.Set(ctx, categoryListKey, data, categoryExpiration).Err()
+	return c.client.Set(ctx, categoryListKey, data, categoryTTL()).Err()
 }
 
 func (c *categoryCache) GetList(ctx context.Context) ([]models.Category, error) {
+	ctx, span := tracing.Start(ctx, "CategoryCache.GetList")
+	defer span.End()
+
 	data, err := c.client.Get(ctx, categoryListKey).Bytes()
 	if err != nil {
 		if err == redis.Nil {
@@ -88,3 +112,10 @@ func (c *categoryCache) GetList(ctx context.Context) ([]models.Category, error)
 	}
 	return categories, nil
 }
+
+func (c *categoryCache) DeleteList(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "CategoryCache.DeleteList")
+	defer span.End()
+
+	return c.client.Del(ctx, categoryListKey).Err()
+}
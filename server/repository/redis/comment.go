@@ -8,101 +8,188 @@ import (
 
 	"github.com/redis/go-redis/v9"
 	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
 )
 
 const (
 	commentKeyPrefix = "comment:"
 	commentExpiration = 6 * time.Hour
+	commentCountKeyPrefix = "comment:count:post:" // 文章评论的近似总数，不随评论变更失效
 )
 
 // CommentCache 评论缓存接口
 type CommentCache interface {
 	Set(ctx context.Context, comment *models.Comment) error
 	Get(ctx context.Context, id uint) (*models.Comment, error)
+	GetOrLoad(ctx context.Context, id uint, load func(ctx context.Context) (*models.Comment, error)) (*models.Comment, error)
 	Delete(ctx context.Context, id uint) error
-	SetPostComments(ctx context.Context, postID uint, comments []models.Comment) error
-	GetPostComments(ctx context.Context, postID uint) ([]models.Comment, error)
-	SetUserComments(ctx context.Context, userID uint, comments []models.Comment) error
-	GetUserComments(ctx context.Context, userID uint) ([]models.Comment, error)
+	PostCommentsVersion(ctx context.Context, postID uint) (int64, error)
+	SetPostComments(ctx context.Context, postID uint, version int64, key string, page *CommentListPage) error
+	GetPostComments(ctx context.Context, postID uint, version int64, key string) (*CommentListPage, error)
+	InvalidatePostComments(ctx context.Context, postID uint) error
+	SetPostCommentCount(ctx context.Context, postID uint, count int64) error
+	GetPostCommentCount(ctx context.Context, postID uint) (int64, bool, error)
+	UserCommentsVersion(ctx context.Context, userID uint) (int64, error)
+	SetUserComments(ctx context.Context, userID uint, version int64, key string, page *CommentListPage) error
+	GetUserComments(ctx context.Context, userID uint, version int64, key string) (*CommentListPage, error)
+	InvalidateUserComments(ctx context.Context, userID uint) error
+}
+
+// CommentListPage 缓存的评论列表分页，总数与当前页一起缓存
+// 游标分页不缓存总数，NextCursor 为空表示没有下一页
+type CommentListPage struct {
+	Comments   []models.Comment `json:"comments"`
+	Total      int64            `json:"total"`
+	NextCursor string           `json:"next_cursor,omitempty"`
 }
 
 type commentCache struct {
 	client *redis.Client
+	loader *entryLoader
 }
 
 // NewCommentCache 创建评论缓存实例
 func NewCommentCache(client *redis.Client) CommentCache {
-	return &commentCache{client: client}
+	return &commentCache{client: client, loader: newEntryLoader(redisBackend{client: client})}
 }
 
 func (c *commentCache) Set(ctx context.Context, comment *models.Comment) error {
+	ctx, span := tracing.Start(ctx, "CommentCache.Set")
+	defer span.End()
+
 	key := fmt.Sprintf("%s%d", commentKeyPrefix, comment.ID)
-	data, err := json.Marshal(comment)
-	if err != nil {
-		return err
-	}
-	return c.client.Set(ctx, key, data, commentExpiration).Err()
+	return c.loader.set(ctx, key, comment, commentTTL())
 }
 
 func (c *commentCache) Get(ctx context.Context, id uint) (*models.Comment, error) {
+	ctx, span := tracing.Start(ctx, "CommentCache.Get")
+	defer span.End()
+
 	key := fmt.Sprintf("%s%d", commentKeyPrefix, id)
-	data, err := c.client.Get(ctx, key).Bytes()
-	if err != nil {
-		if err == redis.Nil {
-			return nil, nil
-		}
+	var comment models.Comment
+	ok, err := c.loader.get(ctx, key, &comment)
+	if err != nil || !ok {
 		return nil, err
 	}
+	return &comment, nil
+}
 
+// GetOrLoad 读取缓存，未命中时通过load从数据库加载，并发未命中只会加载一次
+func (c *commentCache) GetOrLoad(ctx context.Context, id uint, load func(ctx context.Context) (*models.Comment, error)) (*models.Comment, error) {
+	ctx, span := tracing.Start(ctx, "CommentCache.GetOrLoad")
+	defer span.End()
+
+	key := fmt.Sprintf("%s%d", commentKeyPrefix, id)
 	var comment models.Comment
-	if err := json.Unmarshal(data, &comment); err != nil {
+	err := c.loader.load(ctx, key, commentTTL(), &comment, func(ctx context.Context) (interface{}, error) {
+		return load(ctx)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &comment, nil
 }
 
 func (c *commentCache) Delete(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "CommentCache.Delete")
+	defer span.End()
+
 	key := fmt.Sprintf("%s%d", commentKeyPrefix, id)
 	return c.client.Del(ctx, key).Err()
 }
 
-func (c *commentCache) SetPostComments(ctx context.Context, postID uint, comments []models.Comment) error {
-	key := fmt.Sprintf("%spost:%d", commentKeyPrefix, postID)
-	data, err := json.Marshal(comments)
-	if err != nil {
-		return err
-	}
-	return c.client.Set(ctx, key, data, commentExpiration).Err()
+// PostCommentsVersion 返回文章评论列表命名空间的当前版本号
+func (c *commentCache) PostCommentsVersion(ctx context.Context, postID uint) (int64, error) {
+	ctx, span := tracing.Start(ctx, "CommentCache.PostCommentsVersion")
+	defer span.End()
+
+	return namespaceVersion(ctx, c.client, postCommentsNamespace(postID))
 }
 
-func (c *commentCache) GetPostComments(ctx context.Context, postID uint) ([]models.Comment, error) {
-	key := fmt.Sprintf("%spost:%d", commentKeyPrefix, postID)
-	data, err := c.client.Get(ctx, key).Bytes()
+func (c *commentCache) SetPostComments(ctx context.Context, postID uint, version int64, key string, page *CommentListPage) error {
+	ctx, span := tracing.Start(ctx, "CommentCache.SetPostComments")
+	defer span.End()
+
+	return c.setList(ctx, versionedKey(postCommentsNamespace(postID), version, key), page)
+}
+
+func (c *commentCache) GetPostComments(ctx context.Context, postID uint, version int64, key string) (*CommentListPage, error) {
+	ctx, span := tracing.Start(ctx, "CommentCache.GetPostComments")
+	defer span.End()
+
+	return c.getList(ctx, versionedKey(postCommentsNamespace(postID), version, key))
+}
+
+// InvalidatePostComments 使文章的所有评论列表分页失效
+func (c *commentCache) InvalidatePostComments(ctx context.Context, postID uint) error {
+	ctx, span := tracing.Start(ctx, "CommentCache.InvalidatePostComments")
+	defer span.End()
+
+	return bumpNamespace(ctx, c.client, postCommentsNamespace(postID))
+}
+
+// SetPostCommentCount 缓存文章的评论总数，在 cache.count_ttl 内作为近似总数返回
+func (c *commentCache) SetPostCommentCount(ctx context.Context, postID uint, count int64) error {
+	ctx, span := tracing.Start(ctx, "CommentCache.SetPostCommentCount")
+	defer span.End()
+
+	return c.client.Set(ctx, fmt.Sprintf("%s%d", commentCountKeyPrefix, postID), count, countTTL()).Err()
+}
+
+// GetPostCommentCount 读取缓存的文章评论总数，第二个返回值表示是否命中
+func (c *commentCache) GetPostCommentCount(ctx context.Context, postID uint) (int64, bool, error) {
+	ctx, span := tracing.Start(ctx, "CommentCache.GetPostCommentCount")
+	defer span.End()
+
+	count, err := c.client.Get(ctx, fmt.Sprintf("%s%d", commentCountKeyPrefix, postID)).Int64()
 	if err != nil {
 		if err == redis.Nil {
-			return nil, nil
+			return 0, false, nil
 		}
-		return nil, err
+		return 0, false, err
 	}
+	return count, true, nil
+}
 
-	var comments []models.Comment
-	if err := json.Unmarshal(data, &comments); err != nil {
-		return nil, err
-	}
-	return comments, nil
+// UserCommentsVersion 返回用户评论列表命名空间的当前版本号
+func (c *commentCache) UserCommentsVersion(ctx context.Context, userID uint) (int64, error) {
+	ctx, span := tracing.Start(ctx, "CommentCache.UserCommentsVersion")
+	defer span.End()
+
+	return namespaceVersion(ctx, c.client, userCommentsNamespace(userID))
+}
+
+func (c *commentCache) SetUserComments(ctx context.Context, userID uint, version int64, key string, page *CommentListPage) error {
+	ctx, span := tracing.Start(ctx, "CommentCache.SetUserComments")
+	defer span.End()
+
+	return c.setList(ctx, versionedKey(userCommentsNamespace(userID), version, key), page)
+}
+
+func (c *commentCache) GetUserComments(ctx context.Context, userID uint, version int64, key string) (*CommentListPage, error) {
+	ctx, span := tracing.Start(ctx, "CommentCache.GetUserComments")
+	defer span.End()
+
+	return c.getList(ctx, versionedKey(userCommentsNamespace(userID), version, key))
+}
+
+// InvalidateUserComments 使用户的所有评论列表分页失效
+func (c *commentCache) InvalidateUserComments(ctx context.Context, userID uint) error {
+	ctx, span := tracing.Start(ctx, "CommentCache.InvalidateUserComments")
+	defer span.End()
+
+	return bumpNamespace(ctx, c.client, userCommentsNamespace(userID))
 }
 
-func (c *commentCache) SetUserComments(ctx context.Context, userID uint, comments []models.Comment) error {
-	key := fmt.Sprintf("%suser:%d", commentKeyPrefix, userID)
-	data, err := json.Marshal(comments)
+func (c *commentCache) setList(ctx context.Context, key string, page *CommentListPage) error {
+	data, err := json.Marshal(page)
 	if err != nil {
 		return err
 	}
-	return c.client.Set(ctx, key, data, commentExpiration).Err()
+	return c.client.Set(ctx, key, data, commentTTL()).Err()
 }
 
-func (c *commentCache) GetUserComments(ctx context.Context, userID uint) ([]models.Comment, error) {
-	key := fmt.Sprintf("%suser:%d", commentKeyPrefix, userID)
+func (c *commentCache) getList(ctx context.Context, key string) (*CommentListPage, error) {
 	data, err := c.client.Get(ctx, key).Bytes()
 	if err != nil {
 		if err == redis.Nil {
@@ -111,9 +198,9 @@ func (c *commentCache) GetUserComments(ctx context.Context, userID uint) ([]mode
 		return nil, err
 	}
 
-	var comments []models.Comment
-	if err := json.Unmarshal(data, &comments); err != nil {
+	var page CommentListPage
+	if err := json.Unmarshal(data, &page); err != nil {
 		return nil, err
 	}
-	return comments, nil
+	return &page, nil
 }
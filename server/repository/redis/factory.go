@@ -18,6 +18,8 @@ type Factory interface {
 	GetCategoryCache() CategoryCache
 	GetTagCache() TagCache
 	GetCommentCache() CommentCache
+	GetAnalyticsCache() AnalyticsCache
+	GetReactionCache() ReactionCache
 }
 
 // factory 实现Factory接口
@@ -28,6 +30,8 @@ type factory struct {
 	categoryCache CategoryCache
 	tagCache     TagCache
 	commentCache CommentCache
+	analyticsCache AnalyticsCache
+	reactionCache ReactionCache
 	mu           sync.RWMutex
 }
 
@@ -120,3 +124,35 @@ func (f *factory) GetCommentCache() CommentCache {
 	}
 	return f.commentCache
 }
+
+func (f *factory) GetAnalyticsCache() AnalyticsCache {
+	f.mu.RLock()
+	if f.analyticsCache != nil {
+		defer f.mu.RUnlock()
+		return f.analyticsCache
+	}
+	f.mu.RUnlock()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.analyticsCache == nil {
+		f.analyticsCache = NewAnalyticsCache(f.client)
+	}
+	return f.analyticsCache
+}
+
+func (f *factory) GetReactionCache() ReactionCache {
+	f.mu.RLock()
+	if f.reactionCache != nil {
+		defer f.mu.RUnlock()
+		return f.reactionCache
+	}
+	f.mu.RUnlock()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.reactionCache == nil {
+		f.reactionCache = NewReactionCache(f.client)
+	}
+	return f.reactionCache
+}
@@ -0,0 +1,258 @@
+package redis
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+)
+
+const (
+	loadLockPrefix   = "lock:load:"
+	loadLockTTL      = 5 * time.Second       // 跨副本加载锁的持有时间，超时自动释放
+	loadWaitTimeout  = 2 * time.Second       // 未抢到锁时等待其他副本写入缓存的最长时间
+	loadWaitInterval = 50 * time.Millisecond // 等待期间轮询缓存的间隔
+	refreshTimeout   = 10 * time.Second      // 后台刷新旧值的超时时间
+)
+
+// entryBackend 缓存条目的底层存储，Redis与进程内缓存各有实现
+type entryBackend interface {
+	// getRaw 读取原始数据，不存在时返回false
+	getRaw(ctx context.Context, key string) ([]byte, bool, error)
+	setRaw(ctx context.Context, key string, data []byte, ttl time.Duration) error
+	// tryLock 尝试获取key的加载锁，成功时返回用于释放的token
+	tryLock(ctx context.Context, key string, ttl time.Duration) (string, bool, error)
+	unlock(ctx context.Context, key, token string) error
+}
+
+// cacheEntry 单个对象的缓存条目
+// FreshUntil 之前为新鲜数据；之后到key过期前的 stale_ttl 窗口内作为旧值返回并触发后台刷新
+type cacheEntry struct {
+	Data       json.RawMessage `json:"data,omitempty"`
+	Missing    bool            `json:"missing,omitempty"` // 负缓存：记录不存在
+	FreshUntil int64           `json:"fresh_until"`       // 毫秒时间戳
+}
+
+func (e *cacheEntry) fresh() bool {
+	return time.Now().UnixMilli() < e.FreshUntil
+}
+
+// entryLoader 带防击穿保护的对象缓存读写：
+// 进程内用singleflight合并同一key的并发未命中，跨副本用短期锁保证只有一个副本查询数据库；
+// 不存在的记录写入负缓存，过期条目在 stale_ttl 内先返回旧值再由一个goroutine后台刷新
+type entryLoader struct {
+	backend    entryBackend
+	group      singleflight.Group
+	refreshing sync.Map
+}
+
+func newEntryLoader(backend entryBackend) *entryLoader {
+	return &entryLoader{backend: backend}
+}
+
+// set 写入新鲜条目，key的实际过期时间额外保留 stale_ttl 用于返回旧值
+func (l *entryLoader) set(ctx context.Context, key string, v interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return l.setEntry(ctx, key, &cacheEntry{Data: data}, ttl)
+}
+
+// get 读取条目，不区分新鲜与否；负缓存和未命中均返回false
+func (l *entryLoader) get(ctx context.Context, key string, v interface{}) (bool, error) {
+	entry, err := l.getEntry(ctx, key)
+	if err != nil || entry == nil || entry.Missing {
+		return false, err
+	}
+	if err := json.Unmarshal(entry.Data, v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (l *entryLoader) getEntry(ctx context.Context, key string) (*cacheEntry, error) {
+	data, ok, err := l.backend.getRaw(ctx, key)
+	if err != nil || !ok {
+		return nil, err
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		// 兼容升级前直接存储对象的旧格式，按未命中处理
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+func (l *entryLoader) setEntry(ctx context.Context, key string, entry *cacheEntry, ttl time.Duration) error {
+	entry.FreshUntil = time.Now().Add(ttl).UnixMilli()
+	if !entry.Missing {
+		ttl += staleTTL()
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return l.backend.setRaw(ctx, key, data, ttl)
+}
+
+// load 从缓存读取对象，未命中时调用fn从数据库加载并写回缓存
+// fn返回 gorm.ErrRecordNotFound 时写入负缓存，之后的读取直接返回该错误
+func (l *entryLoader) load(ctx context.Context, key string, ttl time.Duration, v interface{}, fn func(ctx context.Context) (interface{}, error)) error {
+	entry, err := l.getEntry(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	if entry == nil {
+		// 合并进程内的并发未命中，每个调用方各自反序列化，避免共享同一对象
+		result, err, _ := l.group.Do(key, func() (interface{}, error) {
+			return l.fill(context.WithoutCancel(ctx), key, ttl, fn, true)
+		})
+		if err != nil {
+			return err
+		}
+		entry = result.(*cacheEntry)
+	} else if !entry.fresh() && !entry.Missing {
+		l.refresh(ctx, key, ttl, fn)
+	}
+
+	if entry.Missing {
+		return gorm.ErrRecordNotFound
+	}
+	return json.Unmarshal(entry.Data, v)
+}
+
+// fill 加载数据并写入缓存
+// wait为true时，未抢到锁会等待持锁副本写入缓存，超时后自行加载；为false时直接放弃
+func (l *entryLoader) fill(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) (interface{}, error), wait bool) (*cacheEntry, error) {
+	token, locked, err := l.backend.tryLock(ctx, key, loadLockTTL)
+	if err != nil {
+		return nil, err
+	}
+	if locked {
+		defer func() {
+			if err := l.backend.unlock(ctx, key, token); err != nil {
+				log.Printf("Cache warning: release load lock for %s: %v", key, err)
+			}
+		}()
+	} else {
+		if !wait {
+			return nil, nil
+		}
+		if entry := l.waitEntry(ctx, key); entry != nil {
+			return entry, nil
+		}
+	}
+
+	v, err := fn(ctx)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		entry := &cacheEntry{Missing: true}
+		if ttl := negativeTTL(); ttl > 0 {
+			if err := l.setEntry(ctx, key, entry, ttl); err != nil {
+				return nil, err
+			}
+		}
+		return entry, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	entry := &cacheEntry{Data: data}
+	if err := l.setEntry(ctx, key, entry, ttl); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// waitEntry 轮询等待其他副本写入缓存
+func (l *entryLoader) waitEntry(ctx context.Context, key string) *cacheEntry {
+	deadline := time.Now().Add(loadWaitTimeout)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(loadWaitInterval):
+		}
+		if entry, err := l.getEntry(ctx, key); err == nil && entry != nil {
+			return entry
+		}
+	}
+	return nil
+}
+
+// refresh 在后台刷新过期条目，同一key同时只有一个刷新任务
+func (l *entryLoader) refresh(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) (interface{}, error)) {
+	if _, running := l.refreshing.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+	go func() {
+		defer l.refreshing.Delete(key)
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), refreshTimeout)
+		defer cancel()
+		if _, err := l.fill(ctx, key, ttl, fn, false); err != nil {
+			log.Printf("Cache warning: refresh %s: %v", key, err)
+		}
+	}()
+}
+
+// unlockScript 仅当锁仍由自己持有时才删除，避免误删其他副本在锁超时后获取的锁
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// redisBackend 基于Redis的条目存储，加载锁使用 SET NX PX
+type redisBackend struct {
+	client *redis.Client
+}
+
+func (b redisBackend) getRaw(ctx context.Context, key string) ([]byte, bool, error) {
+	data, err := b.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+func (b redisBackend) setRaw(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	return b.client.Set(ctx, key, data, ttl).Err()
+}
+
+func (b redisBackend) tryLock(ctx context.Context, key string, ttl time.Duration) (string, bool, error) {
+	token := newLockToken()
+	ok, err := b.client.SetNX(ctx, loadLockPrefix+key, token, ttl).Result()
+	if err != nil {
+		return "", false, err
+	}
+	return token, ok, nil
+}
+
+func (b redisBackend) unlock(ctx context.Context, key, token string) error {
+	return unlockScript.Run(ctx, b.client, []string{loadLockPrefix + key}, token).Err()
+}
+
+// newLockToken 生成随机的锁持有者标识
+func newLockToken() string {
+	buf := make([]byte, 16)
+	_, _ = cryptorand.Read(buf)
+	return hex.EncodeToString(buf)
+}
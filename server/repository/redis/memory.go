@@ -0,0 +1,237 @@
+package redis
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// memoryStore 进程内缓存存储，按最近最少使用（LRU）淘汰并支持过期时间
+// 值以JSON保存，与Redis实现一致，调用方修改返回的对象不会影响缓存内容
+type memoryStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+	versions   map[string]int64 // 命名空间版本号，不参与淘汰，否则版本回退会让旧列表重新可见
+	locks      map[string]memoryLock
+}
+
+type memoryLock struct {
+	token    string
+	expireAt time.Time
+}
+
+type memoryEntry struct {
+	key      string
+	value    []byte
+	expireAt time.Time // 零值表示永不过期
+}
+
+func newMemoryStore(maxEntries int) *memoryStore {
+	return &memoryStore{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		versions:   make(map[string]int64),
+		locks:      make(map[string]memoryLock),
+	}
+}
+
+func (s *memoryStore) set(key string, value []byte, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.setLocked(key, value, ttl)
+}
+
+func (s *memoryStore) setLocked(key string, value []byte, ttl time.Duration) {
+	var expireAt time.Time
+	if ttl > 0 {
+		expireAt = time.Now().Add(ttl)
+	}
+
+	if el, ok := s.items[key]; ok {
+		entry := el.Value.(*memoryEntry)
+		entry.value = value
+		entry.expireAt = expireAt
+		s.ll.MoveToFront(el)
+		return
+	}
+
+	s.items[key] = s.ll.PushFront(&memoryEntry{key: key, value: value, expireAt: expireAt})
+	for s.maxEntries > 0 && s.ll.Len() > s.maxEntries {
+		s.removeElement(s.ll.Back())
+	}
+}
+
+// setNX 仅当key不存在（或已过期）时写入，写入成功返回true
+func (s *memoryStore) setNX(key string, value []byte, ttl time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.items[key]; ok {
+		entry := el.Value.(*memoryEntry)
+		if entry.expireAt.IsZero() || time.Now().Before(entry.expireAt) {
+			return false
+		}
+	}
+	s.setLocked(key, value, ttl)
+	return true
+}
+
+func (s *memoryStore) get(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*memoryEntry)
+	if !entry.expireAt.IsZero() && time.Now().After(entry.expireAt) {
+		s.removeElement(el)
+		return nil, false
+	}
+	s.ll.MoveToFront(el)
+	return entry.value, true
+}
+
+func (s *memoryStore) del(keys ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := s.items[key]; ok {
+			s.removeElement(el)
+		}
+	}
+}
+
+func (s *memoryStore) removeElement(el *list.Element) {
+	s.ll.Remove(el)
+	delete(s.items, el.Value.(*memoryEntry).key)
+}
+
+func (s *memoryStore) version(namespace string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.versions[namespace]
+}
+
+func (s *memoryStore) bump(namespace string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.versions[namespace]++
+}
+
+func (s *memoryStore) getRaw(_ context.Context, key string) ([]byte, bool, error) {
+	data, ok := s.get(key)
+	return data, ok, nil
+}
+
+func (s *memoryStore) setRaw(_ context.Context, key string, data []byte, ttl time.Duration) error {
+	s.set(key, data, ttl)
+	return nil
+}
+
+// tryLock 进程内加载锁，单实例部署下与singleflight配合使用
+func (s *memoryStore) tryLock(_ context.Context, key string, ttl time.Duration) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if lock, ok := s.locks[key]; ok && time.Now().Before(lock.expireAt) {
+		return "", false, nil
+	}
+	token := newLockToken()
+	s.locks[key] = memoryLock{token: token, expireAt: time.Now().Add(ttl)}
+	return token, true, nil
+}
+
+func (s *memoryStore) unlock(_ context.Context, key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if lock, ok := s.locks[key]; ok && lock.token == token {
+		delete(s.locks, key)
+	}
+	return nil
+}
+
+// setJSON 序列化后写入缓存
+func (s *memoryStore) setJSON(key string, v interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	s.set(key, data, ttl)
+	return nil
+}
+
+// getJSON 读取并反序列化缓存，未命中时返回false
+func (s *memoryStore) getJSON(key string, v interface{}) (bool, error) {
+	data, ok := s.get(key)
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// memoryFactory 基于进程内缓存实现Factory接口，用于没有Redis的小型部署和测试
+type memoryFactory struct {
+	userCache      UserCache
+	postCache      PostCache
+	categoryCache  CategoryCache
+	tagCache       TagCache
+	commentCache   CommentCache
+	analyticsCache AnalyticsCache
+	reactionCache  ReactionCache
+}
+
+// NewMemoryFactory 创建进程内缓存工厂实例
+// 所有缓存共享同一个容量为maxEntries的LRU存储；每次调用返回独立实例，便于测试之间相互隔离
+func NewMemoryFactory(maxEntries int) Factory {
+	store := newMemoryStore(maxEntries)
+	loader := newEntryLoader(store)
+	return &memoryFactory{
+		userCache:      &memoryUserCache{store: store, loader: loader},
+		postCache:      newMemoryPostCache(store, loader),
+		categoryCache:  &memoryCategoryCache{store: store, loader: loader},
+		tagCache:       &memoryTagCache{store: store, loader: loader},
+		commentCache:   &memoryCommentCache{store: store, loader: loader},
+		analyticsCache: newMemoryAnalyticsCache(store),
+		reactionCache:  newMemoryReactionCache(store),
+	}
+}
+
+func (f *memoryFactory) GetUserCache() UserCache {
+	return f.userCache
+}
+
+func (f *memoryFactory) GetPostCache() PostCache {
+	return f.postCache
+}
+
+func (f *memoryFactory) GetCategoryCache() CategoryCache {
+	return f.categoryCache
+}
+
+func (f *memoryFactory) GetTagCache() TagCache {
+	return f.tagCache
+}
+
+func (f *memoryFactory) GetCommentCache() CommentCache {
+	return f.commentCache
+}
+
+func (f *memoryFactory) GetAnalyticsCache() AnalyticsCache {
+	return f.analyticsCache
+}
+
+func (f *memoryFactory) GetReactionCache() ReactionCache {
+	return f.reactionCache
+}
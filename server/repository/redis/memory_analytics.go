@@ -0,0 +1,85 @@
+package redis
+
+import (
+	"context"
+	"sync"
+
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
+)
+
+// memoryAnalyticsCache 进程内浏览统计缓存，待同步的增量不参与LRU淘汰
+type memoryAnalyticsCache struct {
+	store *memoryStore
+
+	mu            sync.Mutex
+	pending       map[string]int64
+	flushingID    string
+	flushingCount map[string]int64
+}
+
+func newMemoryAnalyticsCache(store *memoryStore) *memoryAnalyticsCache {
+	return &memoryAnalyticsCache{
+		store:   store,
+		pending: make(map[string]int64),
+	}
+}
+
+func (c *memoryAnalyticsCache) RecordHit(ctx context.Context, hit *AnalyticsHit) error {
+	_, span := tracing.Start(ctx, "AnalyticsCache.RecordHit")
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, field := range analyticsFields(hit) {
+		c.pending[field]++
+	}
+	return nil
+}
+
+func (c *memoryAnalyticsCache) BeginFlush(ctx context.Context, batchID string) (*AnalyticsBatch, error) {
+	_, span := tracing.Start(ctx, "AnalyticsCache.BeginFlush")
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.flushingCount == nil {
+		if len(c.pending) == 0 {
+			return nil, nil
+		}
+		c.flushingID, c.flushingCount = batchID, c.pending
+		c.pending = make(map[string]int64)
+	}
+	return decodeAnalyticsBatch(c.flushingID, c.flushingCount), nil
+}
+
+func (c *memoryAnalyticsCache) EndFlush(ctx context.Context, batch *AnalyticsBatch) error {
+	_, span := tracing.Start(ctx, "AnalyticsCache.EndFlush")
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.flushingCount != nil && c.flushingID == batch.ID {
+		c.flushingID, c.flushingCount = "", nil
+	}
+	return nil
+}
+
+func (c *memoryAnalyticsCache) SetPopular(ctx context.Context, key string, posts []models.PopularPost) error {
+	_, span := tracing.Start(ctx, "AnalyticsCache.SetPopular")
+	defer span.End()
+
+	return c.store.setJSON(analyticsPopularKey+key, posts, jitter(analyticsPopularTTL))
+}
+
+func (c *memoryAnalyticsCache) GetPopular(ctx context.Context, key string) ([]models.PopularPost, error) {
+	_, span := tracing.Start(ctx, "AnalyticsCache.GetPopular")
+	defer span.End()
+
+	posts := []models.PopularPost{}
+	ok, err := c.store.getJSON(analyticsPopularKey+key, &posts)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return posts, nil
+}
@@ -0,0 +1,83 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
+)
+
+// memoryCategoryCache 进程内分类缓存
+type memoryCategoryCache struct {
+	store  *memoryStore
+	loader *entryLoader
+}
+
+func (c *memoryCategoryCache) Set(ctx context.Context, category *models.Category) error {
+	ctx, span := tracing.Start(ctx, "CategoryCache.Set")
+	defer span.End()
+
+	return c.loader.set(ctx, fmt.Sprintf("%s%d", categoryKeyPrefix, category.ID), category, categoryTTL())
+}
+
+func (c *memoryCategoryCache) Get(ctx context.Context, id uint) (*models.Category, error) {
+	ctx, span := tracing.Start(ctx, "CategoryCache.Get")
+	defer span.End()
+
+	var category models.Category
+	ok, err := c.loader.get(ctx, fmt.Sprintf("%s%d", categoryKeyPrefix, id), &category)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (c *memoryCategoryCache) GetOrLoad(ctx context.Context, id uint, load func(ctx context.Context) (*models.Category, error)) (*models.Category, error) {
+	ctx, span := tracing.Start(ctx, "CategoryCache.GetOrLoad")
+	defer span.End()
+
+	var category models.Category
+	err := c.loader.load(ctx, fmt.Sprintf("%s%d", categoryKeyPrefix, id), categoryTTL(), &category, func(ctx context.Context) (interface{}, error) {
+		return load(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (c *memoryCategoryCache) Delete(ctx context.Context, id uint) error {
+	_, span := tracing.Start(ctx, "CategoryCache.Delete")
+	defer span.End()
+
+	c.store.del(fmt.Sprintf("%s%d", categoryKeyPrefix, id))
+	return nil
+}
+
+func (c *memoryCategoryCache) SetList(ctx context.Context, categories []models.Category) error {
+	_, span := tracing.Start(ctx, "CategoryCache.SetList")
+	defer span.End()
+
+	return c.store.setJSON(categoryListKey, categories, categoryTTL())
+}
+
+func (c *memoryCategoryCache) GetList(ctx context.Context) ([]models.Category, error) {
+	_, span := tracing.Start(ctx, "CategoryCache.GetList")
+	defer span.End()
+
+	var categories []models.Category
+	ok, err := c.store.getJSON(categoryListKey, &categories)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (c *memoryCategoryCache) DeleteList(ctx context.Context) error {
+	_, span := tracing.Start(ctx, "CategoryCache.DeleteList")
+	defer span.End()
+
+	c.store.del(categoryListKey)
+	return nil
+}
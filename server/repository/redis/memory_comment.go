@@ -0,0 +1,139 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
+)
+
+// memoryCommentCache 进程内评论缓存
+type memoryCommentCache struct {
+	store  *memoryStore
+	loader *entryLoader
+}
+
+func (c *memoryCommentCache) Set(ctx context.Context, comment *models.Comment) error {
+	ctx, span := tracing.Start(ctx, "CommentCache.Set")
+	defer span.End()
+
+	return c.loader.set(ctx, fmt.Sprintf("%s%d", commentKeyPrefix, comment.ID), comment, commentTTL())
+}
+
+func (c *memoryCommentCache) Get(ctx context.Context, id uint) (*models.Comment, error) {
+	ctx, span := tracing.Start(ctx, "CommentCache.Get")
+	defer span.End()
+
+	var comment models.Comment
+	ok, err := c.loader.get(ctx, fmt.Sprintf("%s%d", commentKeyPrefix, id), &comment)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (c *memoryCommentCache) GetOrLoad(ctx context.Context, id uint, load func(ctx context.Context) (*models.Comment, error)) (*models.Comment, error) {
+	ctx, span := tracing.Start(ctx, "CommentCache.GetOrLoad")
+	defer span.End()
+
+	var comment models.Comment
+	err := c.loader.load(ctx, fmt.Sprintf("%s%d", commentKeyPrefix, id), commentTTL(), &comment, func(ctx context.Context) (interface{}, error) {
+		return load(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (c *memoryCommentCache) Delete(ctx context.Context, id uint) error {
+	_, span := tracing.Start(ctx, "CommentCache.Delete")
+	defer span.End()
+
+	c.store.del(fmt.Sprintf("%s%d", commentKeyPrefix, id))
+	return nil
+}
+
+func (c *memoryCommentCache) PostCommentsVersion(ctx context.Context, postID uint) (int64, error) {
+	_, span := tracing.Start(ctx, "CommentCache.PostCommentsVersion")
+	defer span.End()
+
+	return c.store.version(postCommentsNamespace(postID)), nil
+}
+
+func (c *memoryCommentCache) SetPostComments(ctx context.Context, postID uint, version int64, key string, page *CommentListPage) error {
+	_, span := tracing.Start(ctx, "CommentCache.SetPostComments")
+	defer span.End()
+
+	return c.store.setJSON(versionedKey(postCommentsNamespace(postID), version, key), page, commentTTL())
+}
+
+func (c *memoryCommentCache) GetPostComments(ctx context.Context, postID uint, version int64, key string) (*CommentListPage, error) {
+	_, span := tracing.Start(ctx, "CommentCache.GetPostComments")
+	defer span.End()
+
+	return c.getList(versionedKey(postCommentsNamespace(postID), version, key))
+}
+
+func (c *memoryCommentCache) InvalidatePostComments(ctx context.Context, postID uint) error {
+	_, span := tracing.Start(ctx, "CommentCache.InvalidatePostComments")
+	defer span.End()
+
+	c.store.bump(postCommentsNamespace(postID))
+	return nil
+}
+
+func (c *memoryCommentCache) SetPostCommentCount(ctx context.Context, postID uint, count int64) error {
+	_, span := tracing.Start(ctx, "CommentCache.SetPostCommentCount")
+	defer span.End()
+
+	return c.store.setJSON(fmt.Sprintf("%s%d", commentCountKeyPrefix, postID), count, countTTL())
+}
+
+func (c *memoryCommentCache) GetPostCommentCount(ctx context.Context, postID uint) (int64, bool, error) {
+	_, span := tracing.Start(ctx, "CommentCache.GetPostCommentCount")
+	defer span.End()
+
+	var count int64
+	ok, err := c.store.getJSON(fmt.Sprintf("%s%d", commentCountKeyPrefix, postID), &count)
+	return count, ok, err
+}
+
+func (c *memoryCommentCache) UserCommentsVersion(ctx context.Context, userID uint) (int64, error) {
+	_, span := tracing.Start(ctx, "CommentCache.UserCommentsVersion")
+	defer span.End()
+
+	return c.store.version(userCommentsNamespace(userID)), nil
+}
+
+func (c *memoryCommentCache) SetUserComments(ctx context.Context, userID uint, version int64, key string, page *CommentListPage) error {
+	_, span := tracing.Start(ctx, "CommentCache.SetUserComments")
+	defer span.End()
+
+	return c.store.setJSON(versionedKey(userCommentsNamespace(userID), version, key), page, commentTTL())
+}
+
+func (c *memoryCommentCache) GetUserComments(ctx context.Context, userID uint, version int64, key string) (*CommentListPage, error) {
+	_, span := tracing.Start(ctx, "CommentCache.GetUserComments")
+	defer span.End()
+
+	return c.getList(versionedKey(userCommentsNamespace(userID), version, key))
+}
+
+func (c *memoryCommentCache) InvalidateUserComments(ctx context.Context, userID uint) error {
+	_, span := tracing.Start(ctx, "CommentCache.InvalidateUserComments")
+	defer span.End()
+
+	c.store.bump(userCommentsNamespace(userID))
+	return nil
+}
+
+func (c *memoryCommentCache) getList(key string) (*CommentListPage, error) {
+	var page CommentListPage
+	ok, err := c.store.getJSON(key, &page)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &page, nil
+}
@@ -0,0 +1,260 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
+)
+
+// memoryPostCache 进程内文章缓存
+// 浏览量计数不参与LRU淘汰，否则尚未同步到数据库的浏览量会丢失；views 为已加载文章的实时浏览量
+type memoryPostCache struct {
+	store  *memoryStore
+	loader *entryLoader
+
+	mu       sync.Mutex
+	views    map[uint]int64
+	pending  map[uint]int64
+	flushing *ViewBatch
+}
+
+func newMemoryPostCache(store *memoryStore, loader *entryLoader) *memoryPostCache {
+	return &memoryPostCache{
+		store:   store,
+		loader:  loader,
+		views:   make(map[uint]int64),
+		pending: make(map[uint]int64),
+	}
+}
+
+func (c *memoryPostCache) Set(ctx context.Context, post *models.Post) error {
+	ctx, span := tracing.Start(ctx, "PostCache.Set")
+	defer span.End()
+
+	return c.loader.set(ctx, fmt.Sprintf("%s%d", postKeyPrefix, post.ID), post, postTTL())
+}
+
+func (c *memoryPostCache) Get(ctx context.Context, id uint) (*models.Post, error) {
+	ctx, span := tracing.Start(ctx, "PostCache.Get")
+	defer span.End()
+
+	var post models.Post
+	ok, err := c.loader.get(ctx, fmt.Sprintf("%s%d", postKeyPrefix, id), &post)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (c *memoryPostCache) GetOrLoad(ctx context.Context, id uint, load func(ctx context.Context) (*models.Post, error)) (*models.Post, error) {
+	ctx, span := tracing.Start(ctx, "PostCache.GetOrLoad")
+	defer span.End()
+
+	var post models.Post
+	err := c.loader.load(ctx, fmt.Sprintf("%s%d", postKeyPrefix, id), postTTL(), &post, func(ctx context.Context) (interface{}, error) {
+		return load(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (c *memoryPostCache) Delete(ctx context.Context, id uint) error {
+	_, span := tracing.Start(ctx, "PostCache.Delete")
+	defer span.End()
+
+	c.store.del(fmt.Sprintf("%s%d", postKeyPrefix, id))
+	return nil
+}
+
+func (c *memoryPostCache) IncrViewCount(ctx context.Context, id uint) error {
+	_, span := tracing.Start(ctx, "PostCache.IncrViewCount")
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending[id]++
+	if _, ok := c.views[id]; ok {
+		c.views[id]++
+	}
+	return nil
+}
+
+func (c *memoryPostCache) GetViewCount(ctx context.Context, id uint) (int64, bool, error) {
+	_, span := tracing.Start(ctx, "PostCache.GetViewCount")
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count, ok := c.views[id]
+	return count, ok, nil
+}
+
+func (c *memoryPostCache) SeedViewCount(ctx context.Context, id uint, stored int64) (int64, error) {
+	_, span := tracing.Start(ctx, "PostCache.SeedViewCount")
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.views[id]; !ok {
+		n := stored + c.pending[id]
+		if c.flushing != nil {
+			n += c.flushing.Counts[id]
+		}
+		c.views[id] = n
+	}
+	return c.views[id], nil
+}
+
+func (c *memoryPostCache) MarkViewed(ctx context.Context, id uint, visitor string, window time.Duration) (bool, error) {
+	_, span := tracing.Start(ctx, "PostCache.MarkViewed")
+	defer span.End()
+
+	key := fmt.Sprintf("%s%d:%s", postViewedKeyPrefix, id, visitor)
+	return c.store.setNX(key, []byte("1"), window), nil
+}
+
+func (c *memoryPostCache) BeginViewFlush(ctx context.Context, batchID string) (*ViewBatch, error) {
+	_, span := tracing.Start(ctx, "PostCache.BeginViewFlush")
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.flushing == nil {
+		if len(c.pending) == 0 {
+			return nil, nil
+		}
+		c.flushing = &ViewBatch{ID: batchID, Counts: c.pending}
+		c.pending = make(map[uint]int64)
+	}
+
+	counts := make(map[uint]int64, len(c.flushing.Counts))
+	for id, count := range c.flushing.Counts {
+		counts[id] = count
+	}
+	return &ViewBatch{ID: c.flushing.ID, Counts: counts}, nil
+}
+
+func (c *memoryPostCache) EndViewFlush(ctx context.Context, batch *ViewBatch) error {
+	_, span := tracing.Start(ctx, "PostCache.EndViewFlush")
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.flushing != nil && c.flushing.ID == batch.ID {
+		c.flushing = nil
+	}
+	return nil
+}
+
+func (c *memoryPostCache) PostListVersion(ctx context.Context) (int64, error) {
+	_, span := tracing.Start(ctx, "PostCache.PostListVersion")
+	defer span.End()
+
+	return c.store.version(postListNamespace), nil
+}
+
+func (c *memoryPostCache) SetPostList(ctx context.Context, version int64, key string, page *PostListPage) error {
+	_, span := tracing.Start(ctx, "PostCache.SetPostList")
+	defer span.End()
+
+	return c.store.setJSON(versionedKey(postListNamespace, version, key), page, postTTL())
+}
+
+func (c *memoryPostCache) GetPostList(ctx context.Context, version int64, key string) (*PostListPage, error) {
+	_, span := tracing.Start(ctx, "PostCache.GetPostList")
+	defer span.End()
+
+	var page PostListPage
+	ok, err := c.store.getJSON(versionedKey(postListNamespace, version, key), &page)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *memoryPostCache) InvalidatePostLists(ctx context.Context) error {
+	_, span := tracing.Start(ctx, "PostCache.InvalidatePostLists")
+	defer span.End()
+
+	c.store.bump(postListNamespace)
+	return nil
+}
+
+func (c *memoryPostCache) SetListCount(ctx context.Context, key string, count int64) error {
+	_, span := tracing.Start(ctx, "PostCache.SetListCount")
+	defer span.End()
+
+	return c.store.setJSON(postCountKeyPrefix+key, count, countTTL())
+}
+
+func (c *memoryPostCache) GetListCount(ctx context.Context, key string) (int64, bool, error) {
+	_, span := tracing.Start(ctx, "PostCache.GetListCount")
+	defer span.End()
+
+	var count int64
+	ok, err := c.store.getJSON(postCountKeyPrefix+key, &count)
+	return count, ok, err
+}
+
+func (c *memoryPostCache) SetRelated(ctx context.Context, version int64, id uint, posts []models.RelatedPost) error {
+	_, span := tracing.Start(ctx, "PostCache.SetRelated")
+	defer span.End()
+
+	return c.store.setJSON(versionedKey(postListNamespace, version, relatedKey(id)), posts, postTTL())
+}
+
+func (c *memoryPostCache) GetRelated(ctx context.Context, version int64, id uint) ([]models.RelatedPost, error) {
+	_, span := tracing.Start(ctx, "PostCache.GetRelated")
+	defer span.End()
+
+	posts := []models.RelatedPost{}
+	ok, err := c.store.getJSON(versionedKey(postListNamespace, version, relatedKey(id)), &posts)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return posts, nil
+}
+
+func (c *memoryPostCache) SetSeriesNav(ctx context.Context, version int64, id uint, nav *models.SeriesNavigation) error {
+	_, span := tracing.Start(ctx, "PostCache.SetSeriesNav")
+	defer span.End()
+
+	return c.store.setJSON(versionedKey(postListNamespace, version, seriesNavKey(id)), nav, postTTL())
+}
+
+func (c *memoryPostCache) GetSeriesNav(ctx context.Context, version int64, id uint) (*models.SeriesNavigation, bool, error) {
+	_, span := tracing.Start(ctx, "PostCache.GetSeriesNav")
+	defer span.End()
+
+	var nav *models.SeriesNavigation
+	ok, err := c.store.getJSON(versionedKey(postListNamespace, version, seriesNavKey(id)), &nav)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return nav, true, nil
+}
+
+func (c *memoryPostCache) SetArchive(ctx context.Context, version int64, years []models.ArchiveYear) error {
+	_, span := tracing.Start(ctx, "PostCache.SetArchive")
+	defer span.End()
+
+	return c.store.setJSON(versionedKey(postListNamespace, version, archiveKey), years, postTTL())
+}
+
+func (c *memoryPostCache) GetArchive(ctx context.Context, version int64) ([]models.ArchiveYear, error) {
+	_, span := tracing.Start(ctx, "PostCache.GetArchive")
+	defer span.End()
+
+	years := []models.ArchiveYear{}
+	ok, err := c.store.getJSON(versionedKey(postListNamespace, version, archiveKey), &years)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return years, nil
+}
@@ -0,0 +1,160 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/personal-blog/pkg/tracing"
+)
+
+// memoryReactionCache 进程内文章反应缓存
+// 实时总数和待同步的增量不参与LRU淘汰，匿名访客的去重标记存放在共享的LRU存储中
+type memoryReactionCache struct {
+	store *memoryStore
+
+	mu       sync.Mutex
+	counts   map[uint]map[string]int64
+	pending  map[uint]map[string]int64
+	flushing *ReactionBatch
+}
+
+func newMemoryReactionCache(store *memoryStore) *memoryReactionCache {
+	return &memoryReactionCache{
+		store:   store,
+		counts:  make(map[uint]map[string]int64),
+		pending: make(map[uint]map[string]int64),
+	}
+}
+
+func (c *memoryReactionCache) GetCounts(ctx context.Context, ids []uint) (map[uint]map[string]int64, error) {
+	_, span := tracing.Start(ctx, "ReactionCache.GetCounts")
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result := make(map[uint]map[string]int64, len(ids))
+	for _, id := range ids {
+		if counts, ok := c.counts[id]; ok {
+			result[id] = copyReactionCounts(counts)
+		}
+	}
+	return result, nil
+}
+
+func (c *memoryReactionCache) SeedCounts(ctx context.Context, id uint, counts map[string]int64) (map[string]int64, error) {
+	_, span := tracing.Start(ctx, "ReactionCache.SeedCounts")
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.counts[id]; !ok {
+		seeded := make(map[string]int64, len(counts))
+		for reactionType, n := range counts {
+			n += c.pending[id][reactionType]
+			if c.flushing != nil {
+				n += c.flushing.Counts[id][reactionType]
+			}
+			seeded[reactionType] = n
+		}
+		c.counts[id] = seeded
+	}
+	return copyReactionCounts(c.counts[id]), nil
+}
+
+func (c *memoryReactionCache) Incr(ctx context.Context, id uint, reactionType string, delta int64) error {
+	_, span := tracing.Start(ctx, "ReactionCache.Incr")
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pending[id] == nil {
+		c.pending[id] = make(map[string]int64)
+	}
+	c.pending[id][reactionType] += delta
+	if counts, ok := c.counts[id]; ok {
+		counts[reactionType] += delta
+	}
+	return nil
+}
+
+func (c *memoryReactionCache) MarkReacted(ctx context.Context, id uint, reactionType, visitor string, ttl time.Duration) (bool, error) {
+	_, span := tracing.Start(ctx, "ReactionCache.MarkReacted")
+	defer span.End()
+
+	return c.store.setNX(reactionMarkKey(id, reactionType, visitor), []byte("1"), ttl), nil
+}
+
+func (c *memoryReactionCache) UnmarkReacted(ctx context.Context, id uint, reactionType, visitor string) (bool, error) {
+	_, span := tracing.Start(ctx, "ReactionCache.UnmarkReacted")
+	defer span.End()
+
+	key := reactionMarkKey(id, reactionType, visitor)
+	if _, ok := c.store.get(key); !ok {
+		return false, nil
+	}
+	c.store.del(key)
+	return true, nil
+}
+
+func (c *memoryReactionCache) ReactedTypes(ctx context.Context, id uint, visitor string, types []string) ([]string, error) {
+	_, span := tracing.Start(ctx, "ReactionCache.ReactedTypes")
+	defer span.End()
+
+	reacted := []string{}
+	for _, reactionType := range types {
+		if _, ok := c.store.get(reactionMarkKey(id, reactionType, visitor)); ok {
+			reacted = append(reacted, reactionType)
+		}
+	}
+	return reacted, nil
+}
+
+func (c *memoryReactionCache) BeginFlush(ctx context.Context, batchID string) (*ReactionBatch, error) {
+	_, span := tracing.Start(ctx, "ReactionCache.BeginFlush")
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.flushing == nil {
+		if len(c.pending) == 0 {
+			return nil, nil
+		}
+		c.flushing = &ReactionBatch{ID: batchID, Counts: c.pending}
+		c.pending = make(map[uint]map[string]int64)
+	}
+
+	// 增量可能为负数，不能按总数的规则处理
+	counts := make(map[uint]map[string]int64, len(c.flushing.Counts))
+	for id, types := range c.flushing.Counts {
+		counts[id] = make(map[string]int64, len(types))
+		for reactionType, delta := range types {
+			counts[id][reactionType] = delta
+		}
+	}
+	return &ReactionBatch{ID: c.flushing.ID, Counts: counts}, nil
+}
+
+func (c *memoryReactionCache) EndFlush(ctx context.Context, batch *ReactionBatch) error {
+	_, span := tracing.Start(ctx, "ReactionCache.EndFlush")
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.flushing != nil && c.flushing.ID == batch.ID {
+		c.flushing = nil
+	}
+	return nil
+}
+
+// copyReactionCounts 复制反应总数，负数按0处理，与Redis实现一致
+func copyReactionCounts(counts map[string]int64) map[string]int64 {
+	result := make(map[string]int64, len(counts))
+	for reactionType, n := range counts {
+		if n < 0 {
+			n = 0
+		}
+		result[reactionType] = n
+	}
+	return result
+}
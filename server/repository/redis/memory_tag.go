@@ -0,0 +1,110 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
+)
+
+// memoryTagCache 进程内标签缓存
+type memoryTagCache struct {
+	store  *memoryStore
+	loader *entryLoader
+}
+
+func (c *memoryTagCache) Set(ctx context.Context, tag *models.Tag) error {
+	ctx, span := tracing.Start(ctx, "TagCache.Set")
+	defer span.End()
+
+	return c.loader.set(ctx, fmt.Sprintf("%s%d", tagKeyPrefix, tag.ID), tag, tagTTL())
+}
+
+func (c *memoryTagCache) Get(ctx context.Context, id uint) (*models.Tag, error) {
+	ctx, span := tracing.Start(ctx, "TagCache.Get")
+	defer span.End()
+
+	var tag models.Tag
+	ok, err := c.loader.get(ctx, fmt.Sprintf("%s%d", tagKeyPrefix, id), &tag)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (c *memoryTagCache) GetOrLoad(ctx context.Context, id uint, load func(ctx context.Context) (*models.Tag, error)) (*models.Tag, error) {
+	ctx, span := tracing.Start(ctx, "TagCache.GetOrLoad")
+	defer span.End()
+
+	var tag models.Tag
+	err := c.loader.load(ctx, fmt.Sprintf("%s%d", tagKeyPrefix, id), tagTTL(), &tag, func(ctx context.Context) (interface{}, error) {
+		return load(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (c *memoryTagCache) Delete(ctx context.Context, id uint) error {
+	_, span := tracing.Start(ctx, "TagCache.Delete")
+	defer span.End()
+
+	c.store.del(fmt.Sprintf("%s%d", tagKeyPrefix, id))
+	return nil
+}
+
+func (c *memoryTagCache) SetList(ctx context.Context, tags []models.Tag) error {
+	_, span := tracing.Start(ctx, "TagCache.SetList")
+	defer span.End()
+
+	return c.store.setJSON(tagListKey, tags, tagTTL())
+}
+
+func (c *memoryTagCache) GetList(ctx context.Context) ([]models.Tag, error) {
+	_, span := tracing.Start(ctx, "TagCache.GetList")
+	defer span.End()
+
+	var tags []models.Tag
+	ok, err := c.store.getJSON(tagListKey, &tags)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (c *memoryTagCache) SetPostTags(ctx context.Context, postID uint, tags []models.Tag) error {
+	_, span := tracing.Start(ctx, "TagCache.SetPostTags")
+	defer span.End()
+
+	return c.store.setJSON(fmt.Sprintf("%spost:%d", tagKeyPrefix, postID), tags, tagTTL())
+}
+
+func (c *memoryTagCache) GetPostTags(ctx context.Context, postID uint) ([]models.Tag, error) {
+	_, span := tracing.Start(ctx, "TagCache.GetPostTags")
+	defer span.End()
+
+	var tags []models.Tag
+	ok, err := c.store.getJSON(fmt.Sprintf("%spost:%d", tagKeyPrefix, postID), &tags)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (c *memoryTagCache) DeleteList(ctx context.Context) error {
+	_, span := tracing.Start(ctx, "TagCache.DeleteList")
+	defer span.End()
+
+	c.store.del(tagListKey)
+	return nil
+}
+
+func (c *memoryTagCache) DeletePostTags(ctx context.Context, postID uint) error {
+	_, span := tracing.Start(ctx, "TagCache.DeletePostTags")
+	defer span.End()
+
+	c.store.del(fmt.Sprintf("%spost:%d", tagKeyPrefix, postID))
+	return nil
+}
@@ -0,0 +1,78 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
+	"github.com/redis/go-redis/v9"
+)
+
+// memoryUserCache 进程内用户缓存
+type memoryUserCache struct {
+	store  *memoryStore
+	loader *entryLoader
+}
+
+func (c *memoryUserCache) Set(ctx context.Context, user *models.User) error {
+	ctx, span := tracing.Start(ctx, "UserCache.Set")
+	defer span.End()
+
+	return c.loader.set(ctx, fmt.Sprintf("%s%d", userKeyPrefix, user.ID), user, userTTL())
+}
+
+func (c *memoryUserCache) Get(ctx context.Context, id uint) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserCache.Get")
+	defer span.End()
+
+	var user models.User
+	ok, err := c.loader.get(ctx, fmt.Sprintf("%s%d", userKeyPrefix, id), &user)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *memoryUserCache) GetOrLoad(ctx context.Context, id uint, load func(ctx context.Context) (*models.User, error)) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserCache.GetOrLoad")
+	defer span.End()
+
+	var user models.User
+	err := c.loader.load(ctx, fmt.Sprintf("%s%d", userKeyPrefix, id), userTTL(), &user, func(ctx context.Context) (interface{}, error) {
+		return load(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *memoryUserCache) Delete(ctx context.Context, id uint) error {
+	_, span := tracing.Start(ctx, "UserCache.Delete")
+	defer span.End()
+
+	c.store.del(fmt.Sprintf("%s%d", userKeyPrefix, id))
+	return nil
+}
+
+func (c *memoryUserCache) SetUserToken(ctx context.Context, userID uint, token string) error {
+	_, span := tracing.Start(ctx, "UserCache.SetUserToken")
+	defer span.End()
+
+	c.store.set(fmt.Sprintf("%stoken:%d", userKeyPrefix, userID), []byte(token), 7*24*time.Hour)
+	return nil
+}
+
+// GetUserToken 未命中时与Redis实现一致返回 redis.Nil
+func (c *memoryUserCache) GetUserToken(ctx context.Context, userID uint) (string, error) {
+	_, span := tracing.Start(ctx, "UserCache.GetUserToken")
+	defer span.End()
+
+	token, ok := c.store.get(fmt.Sprintf("%stoken:%d", userKeyPrefix, userID))
+	if !ok {
+		return "", redis.Nil
+	}
+	return string(token), nil
+}
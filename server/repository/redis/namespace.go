@@ -0,0 +1,45 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 列表缓存使用版本化命名空间失效：
+// 每个命名空间有一个版本号，列表缓存的key中带有版本号，失效时只需递增版本号，
+// 旧版本下的所有列表随之不可见并在过期后自动清理，无需逐个查找和删除依赖的key。
+// 调用方应在查询数据库之前读取版本号，并使用同一版本号写回缓存，
+// 这样查询期间发生的失效不会让旧数据写入新版本。
+
+const (
+	postListNamespace = "post:list"
+)
+
+func postCommentsNamespace(postID uint) string {
+	return fmt.Sprintf("%spost:%d", commentKeyPrefix, postID)
+}
+
+func userCommentsNamespace(userID uint) string {
+	return fmt.Sprintf("%suser:%d", commentKeyPrefix, userID)
+}
+
+// versionedKey 生成命名空间指定版本下的缓存key
+func versionedKey(namespace string, version int64, key string) string {
+	return fmt.Sprintf("%s:v%d:%s", namespace, version, key)
+}
+
+// namespaceVersion 读取命名空间当前版本号，不存在时为0
+func namespaceVersion(ctx context.Context, client *redis.Client, namespace string) (int64, error) {
+	version, err := client.Get(ctx, namespace+":version").Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return version, err
+}
+
+// bumpNamespace 递增命名空间版本号，使该命名空间下的所有缓存失效
+func bumpNamespace(ctx context.Context, client *redis.Client, namespace string) error {
+	return client.Incr(ctx, namespace+":version").Err()
+}
@@ -4,93 +4,337 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
 )
 
 const (
 	postKeyPrefix = "post:"
 	postExpiration = 1 * time.Hour
-	postViewCountPrefix = "post:view:"
+	postViewCountPrefix = "post:view:"                 // 实时浏览量，包含尚未同步到数据库的浏览量
+	postViewPendingKey = "post:view:pending" // 尚未同步到数据库的浏览量
+	postViewFlushingKey = "post:view:flushing" // 正在写入数据库的浏览量批次
+	postViewedKeyPrefix = "post:viewed:"       // 访客去重标记
+	postCountKeyPrefix = "post:count:"         // 游标分页的近似总数，不随文章变更失效
+	countExpiration = 5 * time.Minute
 )
 
 // PostCache 文章缓存接口
+// 浏览量同时累加到实时浏览量和待同步计数中，由后台任务按批次写入数据库，文章详情缓存中的浏览量不随之更新；
+// 实时浏览量未命中时由 SeedViewCount 以数据库中的浏览量加上尚未写入的浏览量重建
 type PostCache interface {
 	Set(ctx context.Context, post *models.Post) error
 	Get(ctx context.Context, id uint) (*models.Post, error)
+	GetOrLoad(ctx context.Context, id uint, load func(ctx context.Context) (*models.Post, error)) (*models.Post, error)
 	Delete(ctx context.Context, id uint) error
-	IncrViewCount(ctx context.Context, id uint) (int64, error)
-	GetViewCount(ctx context.Context, id uint) (int64, error)
-	SetPostList(ctx context.Context, key string, posts []models.Post) error
-	GetPostList(ctx context.Context, key string) ([]models.Post, error)
+	IncrViewCount(ctx context.Context, id uint) error
+	GetViewCount(ctx context.Context, id uint) (int64, bool, error)
+	SeedViewCount(ctx context.Context, id uint, stored int64) (int64, error)
+	MarkViewed(ctx context.Context, id uint, visitor string, window time.Duration) (bool, error)
+	BeginViewFlush(ctx context.Context, batchID string) (*ViewBatch, error)
+	EndViewFlush(ctx context.Context, batch *ViewBatch) error
+	PostListVersion(ctx context.Context) (int64, error)
+	SetPostList(ctx context.Context, version int64, key string, page *PostListPage) error
+	GetPostList(ctx context.Context, version int64, key string) (*PostListPage, error)
+	InvalidatePostLists(ctx context.Context) error
+	SetListCount(ctx context.Context, key string, count int64) error
+	GetListCount(ctx context.Context, key string) (int64, bool, error)
+	SetRelated(ctx context.Context, version int64, id uint, posts []models.RelatedPost) error
+	GetRelated(ctx context.Context, version int64, id uint) ([]models.RelatedPost, error)
+	SetSeriesNav(ctx context.Context, version int64, id uint, nav *models.SeriesNavigation) error
+	GetSeriesNav(ctx context.Context, version int64, id uint) (*models.SeriesNavigation, bool, error)
+	SetArchive(ctx context.Context, version int64, years []models.ArchiveYear) error
+	GetArchive(ctx context.Context, version int64) ([]models.ArchiveYear, error)
+}
+
+// ViewBatch 一批待写入数据库的浏览量增量
+type ViewBatch struct {
+	ID     string
+	Counts map[uint]int64
+}
+
+// PostListPage 缓存的文章列表分页，总数与当前页一起缓存
+// 游标分页不缓存总数，NextCursor 为空表示没有下一页
+type PostListPage struct {
+	Posts      []models.Post `json:"posts"`
+	Total      int64         `json:"total"`
+	NextCursor string        `json:"next_cursor,omitempty"`
 }
 
 type postCache struct {
 	client *redis.Client
+	loader *entryLoader
 }
 
 // NewPostCache 创建文章缓存实例
 func NewPostCache(client *redis.Client) PostCache {
-	return &postCache{client: client}
+	return &postCache{client: client, loader: newEntryLoader(redisBackend{client: client})}
 }
 
 func (c *postCache) Set(ctx context.Context, post *models.Post) error {
+	ctx, span := tracing.Start(ctx, "PostCache.Set")
+	defer span.End()
+
 	key := fmt.Sprintf("%s%d", postKeyPrefix, post.ID)
-	data, err := json.Marshal(post)
-	if err != nil {
-		return err
-	}
-	return c.client.Set(ctx, key, data, postExpiration).Err()
+	return c.loader.set(ctx, key, post, postTTL())
 }
 
 func (c *postCache) Get(ctx context.Context, id uint) (*models.Post, error) {
+	ctx, span := tracing.Start(ctx, "PostCache.Get")
+	defer span.End()
+
 	key := fmt.Sprintf("%s%d", postKeyPrefix, id)
-	data, err := c.client.Get(ctx, key).Bytes()
-	if err != nil {
-		if err == redis.Nil {
-			return nil, nil
-		}
+	var post models.Post
+	ok, err := c.loader.get(ctx, key, &post)
+	if err != nil || !ok {
 		return nil, err
 	}
+	return &post, nil
+}
 
+// GetOrLoad 读取缓存，未命中时通过load从数据库加载，并发未命中只会加载一次
+func (c *postCache) GetOrLoad(ctx context.Context, id uint, load func(ctx context.Context) (*models.Post, error)) (*models.Post, error) {
+	ctx, span := tracing.Start(ctx, "PostCache.GetOrLoad")
+	defer span.End()
+
+	key := fmt.Sprintf("%s%d", postKeyPrefix, id)
 	var post models.Post
-	if err := json.Unmarshal(data, &post); err != nil {
+	err := c.loader.load(ctx, key, postTTL(), &post, func(ctx context.Context) (interface{}, error) {
+		return load(ctx)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &post, nil
 }
 
 func (c *postCache) Delete(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "PostCache.Delete")
+	defer span.End()
+
 	key := fmt.Sprintf("%s%d", postKeyPrefix, id)
 	return c.client.Del(ctx, key).Err()
 }
 
-func (c *postCache) IncrViewCount(ctx context.Context, id uint) (int64, error) {
-	key := fmt.Sprintf("%s%d", postViewCountPrefix, id)
-	return c.client.Incr(ctx, key).Result()
+// incrViewScript 浏览量计入待同步计数（KEYS[2]），实时浏览量（KEYS[1]）已加载时同时累加
+var incrViewScript = redis.NewScript(`
+redis.call("HINCRBY", KEYS[2], ARGV[1], 1)
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	redis.call("INCR", KEYS[1])
+end
+return 1
+`)
+
+func (c *postCache) IncrViewCount(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "PostCache.IncrViewCount")
+	defer span.End()
+
+	keys := []string{fmt.Sprintf("%s%d", postViewCountPrefix, id), postViewPendingKey}
+	return incrViewScript.Run(ctx, c.client, keys, strconv.FormatUint(uint64(id), 10)).Err()
 }
 
-func (c *postCache) GetViewCount(ctx context.Context, id uint) (int64, error) {
+// GetViewCount 读取文章的实时浏览量，第二个返回值表示是否已加载
+func (c *postCache) GetViewCount(ctx context.Context, id uint) (int64, bool, error) {
+	ctx, span := tracing.Start(ctx, "PostCache.GetViewCount")
+	defer span.End()
+
 	key := fmt.Sprintf("%s%d", postViewCountPrefix, id)
 	count, err := c.client.Get(ctx, key).Int64()
 	if err == redis.Nil {
-		return 0, nil
+		return 0, false, nil
+	}
+	return count, err == nil, err
+}
+
+// seedViewScript 实时浏览量（KEYS[1]）不存在时，以数据库中的浏览量加上待同步计数（KEYS[2]）和
+// 正在写入的批次（KEYS[3]）中的浏览量重建；ARGV[1]为文章ID，ARGV[2]为数据库中的浏览量，ARGV[3]为过期时间（毫秒）
+var seedViewScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	local n = tonumber(ARGV[2])
+	n = n + tonumber(redis.call("HGET", KEYS[2], ARGV[1]) or 0)
+	n = n + tonumber(redis.call("HGET", KEYS[3], ARGV[1]) or 0)
+	redis.call("SET", KEYS[1], n)
+end
+redis.call("PEXPIRE", KEYS[1], ARGV[3])
+return redis.call("GET", KEYS[1])
+`)
+
+// SeedViewCount 以数据库中的浏览量重建文章的实时浏览量，已存在时直接返回现有的值
+func (c *postCache) SeedViewCount(ctx context.Context, id uint, stored int64) (int64, error) {
+	ctx, span := tracing.Start(ctx, "PostCache.SeedViewCount")
+	defer span.End()
+
+	keys := []string{fmt.Sprintf("%s%d", postViewCountPrefix, id), postViewPendingKey, postViewFlushingKey}
+	return seedViewScript.Run(ctx, c.client, keys, strconv.FormatUint(uint64(id), 10), stored, postTTL().Milliseconds()).Int64()
+}
+
+// MarkViewed 标记访客在window内已浏览过文章，首次浏览返回true
+func (c *postCache) MarkViewed(ctx context.Context, id uint, visitor string, window time.Duration) (bool, error) {
+	ctx, span := tracing.Start(ctx, "PostCache.MarkViewed")
+	defer span.End()
+
+	key := fmt.Sprintf("%s%d:%s", postViewedKeyPrefix, id, visitor)
+	return c.client.SetNX(ctx, key, 1, window).Result()
+}
+
+// beginFlushScript 将待同步计数哈希（KEYS[1]）原子地转为批次（KEYS[2]）
+// 上一个批次尚未完成（如进程在写库过程中退出）时返回该批次，否则将待同步计数改名为新批次
+var beginFlushScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[2]) == 0 then
+	if redis.call("EXISTS", KEYS[1]) == 0 then
+		return false
+	end
+	redis.call("RENAME", KEYS[1], KEYS[2])
+	redis.call("HSET", KEYS[2], "batch", ARGV[1])
+end
+return redis.call("HGETALL", KEYS[2])
+`)
+
+// endFlushScript 仅删除指定ID的批次
+var endFlushScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], "batch") == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// BeginViewFlush 取出一批待写入数据库的浏览量，没有待同步的浏览量时返回nil
+// 批次在 EndViewFlush 之前一直保留，期间新增的浏览量计入新的待同步计数
+func (c *postCache) BeginViewFlush(ctx context.Context, batchID string) (*ViewBatch, error) {
+	ctx, span := tracing.Start(ctx, "PostCache.BeginViewFlush")
+	defer span.End()
+
+	values, err := beginFlushScript.Run(ctx, c.client, []string{postViewPendingKey, postViewFlushingKey}, batchID).StringSlice()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &ViewBatch{Counts: make(map[uint]int64, len(values)/2)}
+	for i := 0; i+1 < len(values); i += 2 {
+		field, value := values[i], values[i+1]
+		if field == "batch" {
+			batch.ID = value
+			continue
+		}
+		id, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			continue
+		}
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || count <= 0 {
+			continue
+		}
+		batch.Counts[uint(id)] = count
 	}
-	return count, err
+	return batch, nil
 }
 
-func (c *postCache) SetPostList(ctx context.Context, key string, posts []models.Post) error {
+// EndViewFlush 批次写入数据库后删除
+func (c *postCache) EndViewFlush(ctx context.Context, batch *ViewBatch) error {
+	ctx, span := tracing.Start(ctx, "PostCache.EndViewFlush")
+	defer span.End()
+
+	return endFlushScript.Run(ctx, c.client, []string{postViewFlushingKey}, batch.ID).Err()
+}
+
+// PostListVersion 返回文章列表命名空间的当前版本号
+func (c *postCache) PostListVersion(ctx context.Context) (int64, error) {
+	ctx, span := tracing.Start(ctx, "PostCache.PostListVersion")
+	defer span.End()
+
+	return namespaceVersion(ctx, c.client, postListNamespace)
+}
+
+func (c *postCache) SetPostList(ctx context.Context, version int64, key string, page *PostListPage) error {
+	ctx, span := tracing.Start(ctx, "PostCache.SetPostList")
+	defer span.End()
+
+	data, err := json.Marshal(page)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, versionedKey(postListNamespace, version, key), data, postTTL()).Err()
+}
+
+func (c *postCache) GetPostList(ctx context.Context, version int64, key string) (*PostListPage, error) {
+	ctx, span := tracing.Start(ctx, "PostCache.GetPostList")
+	defer span.End()
+
+	data, err := c.client.Get(ctx, versionedKey(postListNamespace, version, key)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var page PostListPage
+	if err := json.Unmarshal(data, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// InvalidatePostLists 使所有文章列表缓存失效，文章的新增、修改、删除都可能影响任意列表
+func (c *postCache) InvalidatePostLists(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "PostCache.InvalidatePostLists")
+	defer span.End()
+
+	return bumpNamespace(ctx, c.client, postListNamespace)
+}
+
+// SetListCount 缓存文章列表的总数，在 cache.count_ttl 内作为近似总数返回
+func (c *postCache) SetListCount(ctx context.Context, key string, count int64) error {
+	ctx, span := tracing.Start(ctx, "PostCache.SetListCount")
+	defer span.End()
+
+	return c.client.Set(ctx, postCountKeyPrefix+key, count, countTTL()).Err()
+}
+
+// GetListCount 读取缓存的文章列表总数，第二个返回值表示是否命中
+func (c *postCache) GetListCount(ctx context.Context, key string) (int64, bool, error) {
+	ctx, span := tracing.Start(ctx, "PostCache.GetListCount")
+	defer span.End()
+
+	count, err := c.client.Get(ctx, postCountKeyPrefix+key).Int64()
+	if err != nil {
+		if err == redis.Nil {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return count, true, nil
+}
+
+// relatedKey 相关文章缓存的key，与文章列表使用同一命名空间，任意文章变更后重新计算
+func relatedKey(id uint) string {
+	return fmt.Sprintf("related:%d", id)
+}
+
+func (c *postCache) SetRelated(ctx context.Context, version int64, id uint, posts []models.RelatedPost) error {
+	ctx, span := tracing.Start(ctx, "PostCache.SetRelated")
+	defer span.End()
+
 	data, err := json.Marshal(posts)
 	if err != nil {
 		return err
 	}
-	return c.client.Set(ctx, key, data, postExpiration).Err()
+	return c.client.Set(ctx, versionedKey(postListNamespace, version, relatedKey(id)), data, postTTL()).Err()
 }
 
-func (c *postCache) GetPostList(ctx context.Context, key string) ([]models.Post, error) {
-	data, err := c.client.Get(ctx, key).Bytes()
+// GetRelated 读取相关文章缓存，未命中时返回nil
+func (c *postCache) GetRelated(ctx context.Context, version int64, id uint) ([]models.RelatedPost, error) {
+	ctx, span := tracing.Start(ctx, "PostCache.GetRelated")
+	defer span.End()
+
+	data, err := c.client.Get(ctx, versionedKey(postListNamespace, version, relatedKey(id))).Bytes()
 	if err != nil {
 		if err == redis.Nil {
 			return nil, nil
@@ -98,9 +342,80 @@ func (c *postCache) GetPostList(ctx context.Context, key string) ([]models.Post,
 		return nil, err
 	}
 
-	var posts []models.Post
+	posts := []models.RelatedPost{}
 	if err := json.Unmarshal(data, &posts); err != nil {
 		return nil, err
 	}
 	return posts, nil
 }
+
+// seriesNavKey 文章系列导航缓存的key，导航中包含其他文章的标题，与文章列表一起失效
+func seriesNavKey(id uint) string {
+	return fmt.Sprintf("series_nav:%d", id)
+}
+
+// SetSeriesNav 缓存文章的系列导航，nav为nil表示文章不属于任何系列
+func (c *postCache) SetSeriesNav(ctx context.Context, version int64, id uint, nav *models.SeriesNavigation) error {
+	ctx, span := tracing.Start(ctx, "PostCache.SetSeriesNav")
+	defer span.End()
+
+	data, err := json.Marshal(nav)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, versionedKey(postListNamespace, version, seriesNavKey(id)), data, postTTL()).Err()
+}
+
+// GetSeriesNav 读取文章的系列导航缓存，第二个返回值表示是否命中
+func (c *postCache) GetSeriesNav(ctx context.Context, version int64, id uint) (*models.SeriesNavigation, bool, error) {
+	ctx, span := tracing.Start(ctx, "PostCache.GetSeriesNav")
+	defer span.End()
+
+	data, err := c.client.Get(ctx, versionedKey(postListNamespace, version, seriesNavKey(id))).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	var nav *models.SeriesNavigation
+	if err := json.Unmarshal(data, &nav); err != nil {
+		return nil, false, err
+	}
+	return nav, true, nil
+}
+
+// archiveKey 文章归档缓存的key，与文章列表使用同一命名空间，文章发布、撤回或删除后重新统计
+const archiveKey = "archive"
+
+func (c *postCache) SetArchive(ctx context.Context, version int64, years []models.ArchiveYear) error {
+	ctx, span := tracing.Start(ctx, "PostCache.SetArchive")
+	defer span.End()
+
+	data, err := json.Marshal(years)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, versionedKey(postListNamespace, version, archiveKey), data, postTTL()).Err()
+}
+
+// GetArchive 读取文章归档缓存，未命中时返回nil
+func (c *postCache) GetArchive(ctx context.Context, version int64) ([]models.ArchiveYear, error) {
+	ctx, span := tracing.Start(ctx, "PostCache.GetArchive")
+	defer span.End()
+
+	data, err := c.client.Get(ctx, versionedKey(postListNamespace, version, archiveKey)).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	years := []models.ArchiveYear{}
+	if err := json.Unmarshal(data, &years); err != nil {
+		return nil, err
+	}
+	return years, nil
+}
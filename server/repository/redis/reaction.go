@@ -0,0 +1,264 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/personal-blog/pkg/tracing"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	reactionCountsPrefix = "reaction:counts:"  // 文章各类反应的实时总数，包含尚未写入数据库的增量
+	reactionPendingKey   = "reaction:pending"  // 尚未写入数据库的反应数增量
+	reactionFlushingKey  = "reaction:flushing" // 正在写入数据库的反应数批次
+	reactionMarkPrefix   = "reaction:visitor:" // 匿名访客的反应去重标记
+	reactionSeededField  = "_"                 // 实时总数已从数据库加载的标记，没有任何反应的文章也会缓存
+	reactionFieldSep     = "|"
+)
+
+// ReactionBatch 一批待写入数据库的反应数增量，增量可以为负数（撤销反应）
+type ReactionBatch struct {
+	ID     string
+	Counts map[uint]map[string]int64
+}
+
+// ReactionCache 文章反应缓存接口
+// 反应数的增量同时累加到实时总数和待同步计数中，由后台任务按批次写入数据库；
+// 实时总数未命中时由 SeedCounts 以数据库中的总数加上尚未写入的增量重建
+type ReactionCache interface {
+	GetCounts(ctx context.Context, ids []uint) (map[uint]map[string]int64, error)
+	SeedCounts(ctx context.Context, id uint, counts map[string]int64) (map[string]int64, error)
+	Incr(ctx context.Context, id uint, reactionType string, delta int64) error
+	MarkReacted(ctx context.Context, id uint, reactionType, visitor string, ttl time.Duration) (bool, error)
+	UnmarkReacted(ctx context.Context, id uint, reactionType, visitor string) (bool, error)
+	ReactedTypes(ctx context.Context, id uint, visitor string, types []string) ([]string, error)
+	BeginFlush(ctx context.Context, batchID string) (*ReactionBatch, error)
+	EndFlush(ctx context.Context, batch *ReactionBatch) error
+}
+
+type reactionCache struct {
+	client *redis.Client
+}
+
+// NewReactionCache 创建文章反应缓存实例
+func NewReactionCache(client *redis.Client) ReactionCache {
+	return &reactionCache{client: client}
+}
+
+// GetCounts 读取文章的实时反应总数，结果只包含已加载过的文章
+func (c *reactionCache) GetCounts(ctx context.Context, ids []uint) (map[uint]map[string]int64, error) {
+	ctx, span := tracing.Start(ctx, "ReactionCache.GetCounts")
+	defer span.End()
+
+	pipe := c.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.HGetAll(ctx, reactionCountsKey(id))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	counts := make(map[uint]map[string]int64, len(ids))
+	for i, id := range ids {
+		values := cmds[i].Val()
+		if _, ok := values[reactionSeededField]; !ok {
+			continue
+		}
+		counts[id] = parseReactionCounts(values)
+	}
+	return counts, nil
+}
+
+// seedCountsScript 实时总数（KEYS[1]）不存在时，以数据库中的总数加上待同步计数（KEYS[2]）和
+// 正在写入的批次（KEYS[3]）中的增量重建；ARGV[1]为文章ID，之后为反应类型和数据库中的总数，最后为过期时间（毫秒）
+var seedCountsScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	redis.call("HSET", KEYS[1], "_", 1)
+	for i = 2, #ARGV - 1, 2 do
+		local field = ARGV[1] .. "|" .. ARGV[i]
+		local n = tonumber(ARGV[i + 1])
+		n = n + tonumber(redis.call("HGET", KEYS[2], field) or 0)
+		n = n + tonumber(redis.call("HGET", KEYS[3], field) or 0)
+		redis.call("HSET", KEYS[1], ARGV[i], n)
+	end
+end
+redis.call("PEXPIRE", KEYS[1], ARGV[#ARGV])
+return redis.call("HGETALL", KEYS[1])
+`)
+
+// SeedCounts 以数据库中的总数重建文章的实时反应总数，已存在时直接返回现有的值
+// counts应包含所有可用的反应类型，没有反应的类型传入0
+func (c *reactionCache) SeedCounts(ctx context.Context, id uint, counts map[string]int64) (map[string]int64, error) {
+	ctx, span := tracing.Start(ctx, "ReactionCache.SeedCounts")
+	defer span.End()
+
+	args := make([]interface{}, 0, len(counts)*2+2)
+	args = append(args, id)
+	for reactionType, n := range counts {
+		args = append(args, reactionType, n)
+	}
+	args = append(args, postTTL().Milliseconds())
+
+	keys := []string{reactionCountsKey(id), reactionPendingKey, reactionFlushingKey}
+	values, err := seedCountsScript.Run(ctx, c.client, keys, args...).StringSlice()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		result[values[i]] = values[i+1]
+	}
+	return parseReactionCounts(result), nil
+}
+
+// incrScript 增量计入待同步计数（KEYS[2]），实时总数（KEYS[1]）已加载时同时累加
+var incrScript = redis.NewScript(`
+redis.call("HINCRBY", KEYS[2], ARGV[1], ARGV[3])
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	redis.call("HINCRBY", KEYS[1], ARGV[2], ARGV[3])
+end
+return 1
+`)
+
+func (c *reactionCache) Incr(ctx context.Context, id uint, reactionType string, delta int64) error {
+	ctx, span := tracing.Start(ctx, "ReactionCache.Incr")
+	defer span.End()
+
+	keys := []string{reactionCountsKey(id), reactionPendingKey}
+	return incrScript.Run(ctx, c.client, keys, reactionField(id, reactionType), reactionType, delta).Err()
+}
+
+// MarkReacted 标记匿名访客已对文章做出该反应，首次标记返回true
+func (c *reactionCache) MarkReacted(ctx context.Context, id uint, reactionType, visitor string, ttl time.Duration) (bool, error) {
+	ctx, span := tracing.Start(ctx, "ReactionCache.MarkReacted")
+	defer span.End()
+
+	return c.client.SetNX(ctx, reactionMarkKey(id, reactionType, visitor), 1, ttl).Result()
+}
+
+// UnmarkReacted 清除匿名访客的反应标记，没有标记时返回false
+func (c *reactionCache) UnmarkReacted(ctx context.Context, id uint, reactionType, visitor string) (bool, error) {
+	ctx, span := tracing.Start(ctx, "ReactionCache.UnmarkReacted")
+	defer span.End()
+
+	n, err := c.client.Del(ctx, reactionMarkKey(id, reactionType, visitor)).Result()
+	return n > 0, err
+}
+
+// ReactedTypes 返回匿名访客已对文章做出的反应类型
+func (c *reactionCache) ReactedTypes(ctx context.Context, id uint, visitor string, types []string) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "ReactionCache.ReactedTypes")
+	defer span.End()
+
+	reacted := []string{}
+	if len(types) == 0 {
+		return reacted, nil
+	}
+	keys := make([]string, len(types))
+	for i, reactionType := range types {
+		keys[i] = reactionMarkKey(id, reactionType, visitor)
+	}
+	values, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range values {
+		if v != nil {
+			reacted = append(reacted, types[i])
+		}
+	}
+	return reacted, nil
+}
+
+// BeginFlush 取出一批待写入数据库的反应数增量，没有待同步的增量时返回nil
+func (c *reactionCache) BeginFlush(ctx context.Context, batchID string) (*ReactionBatch, error) {
+	ctx, span := tracing.Start(ctx, "ReactionCache.BeginFlush")
+	defer span.End()
+
+	values, err := beginFlushScript.Run(ctx, c.client, []string{reactionPendingKey, reactionFlushingKey}, batchID).StringSlice()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &ReactionBatch{Counts: make(map[uint]map[string]int64)}
+	for i := 0; i+1 < len(values); i += 2 {
+		field, value := values[i], values[i+1]
+		if field == "batch" {
+			batch.ID = value
+			continue
+		}
+		id, reactionType, ok := parseReactionField(field)
+		if !ok {
+			continue
+		}
+		delta, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || delta == 0 {
+			continue
+		}
+		if batch.Counts[id] == nil {
+			batch.Counts[id] = make(map[string]int64)
+		}
+		batch.Counts[id][reactionType] = delta
+	}
+	return batch, nil
+}
+
+// EndFlush 批次写入数据库后删除
+func (c *reactionCache) EndFlush(ctx context.Context, batch *ReactionBatch) error {
+	ctx, span := tracing.Start(ctx, "ReactionCache.EndFlush")
+	defer span.End()
+
+	return endFlushScript.Run(ctx, c.client, []string{reactionFlushingKey}, batch.ID).Err()
+}
+
+func reactionCountsKey(id uint) string {
+	return fmt.Sprintf("%s%d", reactionCountsPrefix, id)
+}
+
+func reactionMarkKey(id uint, reactionType, visitor string) string {
+	return fmt.Sprintf("%s%d:%s:%s", reactionMarkPrefix, id, reactionType, visitor)
+}
+
+// reactionField 待同步计数中的字段：<文章ID>|<反应类型>
+func reactionField(id uint, reactionType string) string {
+	return strconv.FormatUint(uint64(id), 10) + reactionFieldSep + reactionType
+}
+
+func parseReactionField(field string) (uint, string, bool) {
+	idPart, reactionType, ok := strings.Cut(field, reactionFieldSep)
+	if !ok || reactionType == "" {
+		return 0, "", false
+	}
+	id, err := strconv.ParseUint(idPart, 10, 64)
+	if err != nil {
+		return 0, "", false
+	}
+	return uint(id), reactionType, true
+}
+
+// parseReactionCounts 解析实时总数哈希，忽略加载标记，撤销反应与写库交错时可能短暂出现的负数按0处理
+func parseReactionCounts(values map[string]string) map[string]int64 {
+	counts := make(map[string]int64, len(values))
+	for field, value := range values {
+		if field == reactionSeededField {
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		if n < 0 {
+			n = 0
+		}
+		counts[field] = n
+	}
+	return counts
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/redis/go-redis/v9"
 	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
 )
 
 const (
@@ -20,62 +21,86 @@ const (
 type TagCache interface {
 	Set(ctx context.Context, tag *models.Tag) error
 	Get(ctx context.Context, id uint) (*models.Tag, error)
+	GetOrLoad(ctx context.Context, id uint, load func(ctx context.Context) (*models.Tag, error)) (*models.Tag, error)
 	Delete(ctx context.Context, id uint) error
 	SetList(ctx context.Context, tags []models.Tag) error
 	GetList(ctx context.Context) ([]models.Tag, error)
+	DeleteList(ctx context.Context) error
 	SetPostTags(ctx context.Context, postID uint, tags []models.Tag) error
 	GetPostTags(ctx context.Context, postID uint) ([]models.Tag, error)
+	DeletePostTags(ctx context.Context, postID uint) error
 }
 
 type tagCache struct {
 	client *redis.Client
+	loader *entryLoader
 }
 
 // NewTagCache 创建标签缓存实例
 func NewTagCache(client *redis.Client) TagCache {
-	return &tagCache{client: client}
+	return &tagCache{client: client, loader: newEntryLoader(redisBackend{client: client})}
 }
 
 func (c *tagCache) Set(ctx context.Context, tag *models.Tag) error {
+	ctx, span := tracing.Start(ctx, "TagCache.Set")
+	defer span.End()
+
 	key := fmt.Sprintf("%s%d", tagKeyPrefix, tag.ID)
-	data, err := json.Marshal(tag)
-	if err != nil {
-		return err
-	}
-	return c.client.Set(ctx, key, data, tagExpiration).Err()
+	return c.loader.set(ctx, key, tag, tagTTL())
 }
 
 func (c *tagCache) Get(ctx context.Context, id uint) (*models.Tag, error) {
+	ctx, span := tracing.Start(ctx, "TagCache.Get")
+	defer span.End()
+
 	key := fmt.Sprintf("%s%d", tagKeyPrefix, id)
-	data, err := c.client.Get(ctx, key).Bytes()
-	if err != nil {
-		if err == redis.Nil {
-			return nil, nil
-		}
+	var tag models.Tag
+	ok, err := c.loader.get(ctx, key, &tag)
+	if err != nil || !ok {
 		return nil, err
 	}
+	return &tag, nil
+}
+
+// GetOrLoad 读取缓存，未命中时通过load从数据库加载，并发未命中只会加载一次
+func (c *tagCache) GetOrLoad(ctx context.Context, id uint, load func(ctx context.Context) (*models.Tag, error)) (*models.Tag, error) {
+	ctx, span := tracing.Start(ctx, "TagCache.GetOrLoad")
+	defer span.End()
 
+	key := fmt.Sprintf("%s%d", tagKeyPrefix, id)
 	var tag models.Tag
-	if err := json.Unmarshal(data, &tag); err != nil {
+	err := c.loader.load(ctx, key, tagTTL(), &tag, func(ctx context.Context) (interface{}, error) {
+		return load(ctx)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &tag, nil
 }
 
 func (c *tagCache) Delete(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "TagCache.Delete")
+	defer span.End()
+
 	key := fmt.Sprintf("%s%d", tagKeyPrefix, id)
 	return c.client.Del(ctx, key).Err()
 }
 
 func (c *tagCache) SetList(ctx context.Context, tags []models.Tag) error {
+	ctx, span := tracing.Start(ctx, "TagCache.SetList")
+	defer span.End()
+
 	data, err := json.Marshal(tags)
 	if err != nil {
 		return err
 	}
-	return c.client.Set(ctx, tagListKey, data, tagExpiration).Err()
+	return c.client.Set(ctx, tagListKey, data, tagTTL()).Err()
 }
 
 func (c *tagCache) GetList(ctx context.Context) ([]models.Tag, error) {
+	ctx, span := tracing.Start(ctx, "TagCache.GetList")
+	defer span.End()
+
 	data, err := c.client.Get(ctx, tagListKey).Bytes()
 	if err != nil {
 		if err == redis.Nil {
@@ -92,15 +117,21 @@ func (c *tagCache) GetList(ctx context.Context) ([]models.Tag, error) {
 }
 
 func (c *tagCache) SetPostTags(ctx context.Context, postID uint, tags []models.Tag) error {
+	ctx, span := tracing.Start(ctx, "TagCache.SetPostTags")
+	defer span.End()
+
 	key := fmt.Sprintf("%spost:%d", tagKeyPrefix, postID)
 	data, err := json.Marshal(tags)
 	if err != nil {
 		return err
 	}
-	return c.client.Set(ctx, key, data, tagExpiration).Err()
+	return c.client.Set(ctx, key, data, tagTTL()).Err()
 }
 
 func (c *tagCache) GetPostTags(ctx context.Context, postID uint) ([]models.Tag, error) {
+	ctx, span := tracing.Start(ctx, "TagCache.GetPostTags")
+	defer span.End()
+
 	key := fmt.Sprintf("%spost:%d", tagKeyPrefix, postID)
 	data, err := c.client.Get(ctx, key).Bytes()
 	if err != nil {
@@ -116,3 +147,18 @@ func (c *tagCache) GetPostTags(ctx context.Context, postID uint) ([]models.Tag,
 	}
 	return tags, nil
 }
+
+func (c *tagCache) DeleteList(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "TagCache.DeleteList")
+	defer span.End()
+
+	return c.client.Del(ctx, tagListKey).Err()
+}
+
+func (c *tagCache) DeletePostTags(ctx context.Context, postID uint) error {
+	ctx, span := tracing.Start(ctx, "TagCache.DeletePostTags")
+	defer span.End()
+
+	key := fmt.Sprintf("%spost:%d", tagKeyPrefix, postID)
+	return c.client.Del(ctx, key).Err()
+}
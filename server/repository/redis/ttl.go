@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/personal-blog/config"
+)
+
+// ttlOrDefault 返回配置的过期时间，未配置时回退到默认值
+// 每次写入缓存时读取当前配置，热更新后新写入的缓存即使用新的过期时间
+func ttlOrDefault(seconds int, fallback time.Duration) time.Duration {
+	if seconds <= 0 {
+		return jitter(fallback)
+	}
+	return jitter(time.Duration(seconds) * time.Second)
+}
+
+// jitter 按 cache.ttl_jitter 对过期时间做随机抖动，避免同时写入的key同时过期
+func jitter(ttl time.Duration) time.Duration {
+	ratio := config.Get().Cache.TTLJitter
+	if ratio <= 0 || ttl <= 0 {
+		return ttl
+	}
+	delta := time.Duration((rand.Float64()*2 - 1) * ratio * float64(ttl))
+	return ttl + delta
+}
+
+func postTTL() time.Duration {
+	return ttlOrDefault(config.Get().Cache.PostTTL, postExpiration)
+}
+
+func userTTL() time.Duration {
+	return ttlOrDefault(config.Get().Cache.UserTTL, userExpiration)
+}
+
+func categoryTTL() time.Duration {
+	return ttlOrDefault(config.Get().Cache.CategoryTTL, categoryExpiration)
+}
+
+func tagTTL() time.Duration {
+	return ttlOrDefault(config.Get().Cache.TagTTL, tagExpiration)
+}
+
+func commentTTL() time.Duration {
+	return ttlOrDefault(config.Get().Cache.CommentTTL, commentExpiration)
+}
+
+func countTTL() time.Duration {
+	return ttlOrDefault(config.Get().Cache.CountTTL, countExpiration)
+}
+
+// staleTTL 条目过期后仍可作为旧值返回的时间，0表示关闭
+func staleTTL() time.Duration {
+	return time.Duration(config.Get().Cache.StaleTTL) * time.Second
+}
+
+// negativeTTL 负缓存时间，0表示关闭
+func negativeTTL() time.Duration {
+	return jitter(time.Duration(config.Get().Cache.NegativeTTL) * time.Second)
+}
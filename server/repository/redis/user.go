@@ -2,12 +2,12 @@ package redis
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
 )
 
 const (
@@ -19,6 +19,7 @@ const (
 type UserCache interface {
 	Set(ctx context.Context, user *models.User) error
 	Get(ctx context.Context, id uint) (*models.User, error)
+	GetOrLoad(ctx context.Context, id uint, load func(ctx context.Context) (*models.User, error)) (*models.User, error)
 	Delete(ctx context.Context, id uint) error
 	SetUserToken(ctx context.Context, userID uint, token string) error
 	GetUserToken(ctx context.Context, userID uint) (string, error)
@@ -26,50 +27,71 @@ type UserCache interface {
 
 type userCache struct {
 	client *redis.Client
+	loader *entryLoader
 }
 
 // NewUserCache 创建用户缓存实例
 func NewUserCache(client *redis.Client) UserCache {
-	return &userCache{client: client}
+	return &userCache{client: client, loader: newEntryLoader(redisBackend{client: client})}
 }
 
 func (c *userCache) Set(ctx context.Context, user *models.User) error {
+	ctx, span := tracing.Start(ctx, "UserCache.Set")
+	defer span.End()
+
 	key := fmt.Sprintf("%s%d", userKeyPrefix, user.ID)
-	data, err := json.Marshal(user)
-	if err != nil {
-		return err
-	}
-	return c.client.Set(ctx, key, data, userExpiration).Err()
+	return c.loader.set(ctx, key, user, userTTL())
 }
 
 func (c *userCache) Get(ctx context.Context, id uint) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserCache.Get")
+	defer span.End()
+
 	key := fmt.Sprintf("%s%d", userKeyPrefix, id)
-	data, err := c.client.Get(ctx, key).Bytes()
-	if err != nil {
-		if err == redis.Nil {
-			return nil, nil
-		}
+	var user models.User
+	ok, err := c.loader.get(ctx, key, &user)
+	if err != nil || !ok {
 		return nil, err
 	}
+	return &user, nil
+}
+
+// GetOrLoad 读取缓存，未命中时通过load从数据库加载，并发未命中只会加载一次
+func (c *userCache) GetOrLoad(ctx context.Context, id uint, load func(ctx context.Context) (*models.User, error)) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserCache.GetOrLoad")
+	defer span.End()
 
+	key := fmt.Sprintf("%s%d", userKeyPrefix, id)
 	var user models.User
-	if err := json.Unmarshal(data, &user); err != nil {
+	err := c.loader.load(ctx, key, userTTL(), &user, func(ctx context.Context) (interface{}, error) {
+		return load(ctx)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
 func (c *userCache) Delete(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "UserCache.Delete")
+	defer span.End()
+
 	key := fmt.Sprintf("%s%d", userKeyPrefix, id)
 	return c.client.Del(ctx, key).Err()
 }
 
 func (c *userCache) SetUserToken(ctx context.Context, userID uint, token string) error {
+	ctx, span := tracing.Start(ctx, "UserCache.SetUserToken")
+	defer span.End()
+
 	key := fmt.Sprintf("%stoken:%d", userKeyPrefix, userID)
 	return c.client.Set(ctx, key, token, 7*24*time.Hour).Err()
 }
 
 func (c *userCache) GetUserToken(ctx context.Context, userID uint) (string, error) {
+	ctx, span := tracing.Start(ctx, "UserCache.GetUserToken")
+	defer span.End()
+
 	key := fmt.Sprintf("%stoken:%d", userKeyPrefix, userID)
 	return c.client.Get(ctx, key).Result()
 }
@@ -1,7 +1,11 @@
 package router
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/personal-blog/config"
+	"github.com/personal-blog/database"
 	"github.com/personal-blog/handler"
 	"github.com/personal-blog/middleware"
 	"github.com/personal-blog/service"
@@ -14,19 +18,39 @@ func SetupRouter(factory service.Factory) *gin.Engine {
 	r := gin.Default()
 
 	// Add middleware
+	logger := middleware.InitLogger()
+	r.Use(middleware.TracingMiddleware())
+	r.Use(middleware.ErrorMiddleware(logger))
 	r.Use(middleware.CORSMiddleware())
 
 	// Swagger documentation
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Health checks
+	healthChecks := map[string]handler.HealthCheck{
+		"database": database.PingDB,
+	}
+	if config.Get().Cache.Driver == "redis" {
+		healthChecks["redis"] = database.PingRedis
+	}
+	healthHandler := handler.NewHealthHandler(healthChecks, time.Duration(config.Get().Server.ReadyTimeout)*time.Second)
+	r.GET("/healthz", healthHandler.Liveness) // 存活检查
+	r.GET("/readyz", healthHandler.Readiness) // 就绪检查
+
 	// Create handlers
 	userHandler := handler.NewUserHandler(factory.GetUserService())
-	postHandler := handler.NewPostHandler(factory.GetPostService())
+	postHandler := handler.NewPostHandler(factory.GetPostService(), factory.GetSeriesService(), factory.GetCategoryService(), factory.GetDraftService(), factory.GetReactionService())
 	categoryHandler := handler.NewCategoryHandler(factory.GetCategoryService())
 	tagHandler := handler.NewTagHandler(factory.GetTagService())
+	analyticsHandler := handler.NewAnalyticsHandler(factory.GetAnalyticsService())
+	seriesHandler := handler.NewSeriesHandler(factory.GetSeriesService())
+	commentHandler := handler.NewCommentHandler(factory.GetCommentService(), factory.GetPostService())
+	draftHandler := handler.NewDraftHandler(factory.GetDraftService(), factory.GetPostService())
+	reactionHandler := handler.NewReactionHandler(factory.GetReactionService(), factory.GetPostService())
 
 	// API v1 routes
 	v1 := r.Group("/api/v1")
+	v1.Use(middleware.RateLimitMiddleware(middleware.NewIPRateLimiterFromConfig()))
 	{
 		// Public routes
 		// User routes
@@ -38,23 +62,43 @@ func SetupRouter(factory service.Factory) *gin.Engine {
 
 		// Post routes (public)
 		posts := v1.Group("/posts")
+		posts.Use(middleware.OptionalJWTAuthMiddleware()) // 登录用户可以看到自己的草稿，编辑和管理员可以看到所有文章
 		{
 			posts.GET("", postHandler.List)                     // 获取文章列表
+			posts.GET("/popular", analyticsHandler.Popular)     // 本周最多阅读
+			posts.GET("/featured", postHandler.Featured)                 // 精选文章
+			posts.GET("/archive", postHandler.Archive)                   // 按年月的文章归档
+			posts.GET("/archive/:year", postHandler.ArchivePosts)        // 某年发布的文章
+			posts.GET("/archive/:year/:month", postHandler.ArchivePosts) // 某月发布的文章
 			posts.GET("/:id", postHandler.Get)                  // 获取文章详情
 			posts.GET("/:id/tags", tagHandler.GetPostTags) // 获取文章标签
+			posts.GET("/:id/related", postHandler.Related) // 获取相关文章
+			posts.GET("/:id/comments", commentHandler.ListByPost) // 获取文章评论
+			posts.GET("/:id/reactions", reactionHandler.State)              // 获取文章反应
+			posts.POST("/:id/reactions", reactionHandler.React)             // 对文章做出反应，未登录时按访客去重
+			posts.DELETE("/:id/reactions/:type", reactionHandler.Unreact)   // 撤销反应
 		}
 
 		// Category routes (public)
 		categories := v1.Group("/categories")
 		{
 			categories.GET("", categoryHandler.List)    // 获取分类列表
+			categories.GET("/tree", categoryHandler.Tree) // 获取分类树
 			categories.GET("/:id", categoryHandler.Get) // 获取分类详情
 		}
 
+		// Series routes (public)
+		series := v1.Group("/series")
+		{
+			series.GET("", seriesHandler.List)    // 获取系列列表
+			series.GET("/:id", seriesHandler.Get) // 获取系列详情（ID或slug）
+		}
+
 		// Tag routes (public)
 		tags := v1.Group("/tags")
 		{
 			tags.GET("", tagHandler.List)    // 获取标签列表
+			tags.GET("/cloud", tagHandler.Cloud) // 获取标签云
 			tags.GET("/:id", tagHandler.Get) // 获取标签详情
 		}
 
@@ -68,7 +112,9 @@ func SetupRouter(factory service.Factory) *gin.Engine {
 				authUsers.GET("/profile", userHandler.GetProfile)                // 获取个人信息
 				authUsers.PUT("/profile", userHandler.UpdateProfile)            // 更新个人信息
 				authUsers.PUT("/password", userHandler.ChangePassword)          // 修改密码
+				authUsers.GET("/reactions", reactionHandler.ListMine)           // 我点赞过的文章
 				authUsers.GET("", middleware.AdminAuthMiddleware(), userHandler.ListUsers) // 获取用户列表（管理员）
+				authUsers.PUT("/:id/role", middleware.AdminAuthMiddleware(), userHandler.UpdateRole) // 分配用户角色（管理员）
 			}
 
 			// Post routes (authenticated)
@@ -76,7 +122,36 @@ func SetupRouter(factory service.Factory) *gin.Engine {
 			{
 				authPosts.POST("", postHandler.Create)      // 创建文章
 				authPosts.PUT("/:id", postHandler.Update)   // 更新文章
-				authPosts.DELETE("/:id", postHandler.Delete) // 删除文章
+				authPosts.DELETE("/:id", postHandler.Delete) // 删除文章（移入回收站）
+				authPosts.GET("/trash", postHandler.Trash)              // 回收站中的文章
+				authPosts.POST("/:id/restore", postHandler.Restore)     // 从回收站恢复文章
+				authPosts.POST("/:id/preview", postHandler.PreviewLink) // 生成预览链接
+				authPosts.PUT("/:id/pin", middleware.AdminAuthMiddleware(), postHandler.Pin)             // 置顶文章（管理员）
+				authPosts.DELETE("/:id/pin", middleware.AdminAuthMiddleware(), postHandler.Unpin)        // 取消置顶（管理员）
+				authPosts.PUT("/:id/featured", middleware.AdminAuthMiddleware(), postHandler.Feature)    // 设为精选（管理员）
+				authPosts.DELETE("/:id/featured", middleware.AdminAuthMiddleware(), postHandler.Unfeature) // 取消精选（管理员）
+			}
+
+			// Draft routes (authenticated)
+			authDrafts := protected.Group("/drafts")
+			{
+				authDrafts.GET("", draftHandler.List)          // 当前用户的草稿
+				authDrafts.POST("", draftHandler.Save)         // 自动保存草稿
+				authDrafts.GET("/:id", draftHandler.Get)       // 获取草稿
+				authDrafts.DELETE("/:id", draftHandler.Delete) // 删除草稿
+			}
+
+			// Comment routes (authenticated)
+			authComments := protected.Group("/comments")
+			{
+				authComments.POST("", commentHandler.Create)              // 发表评论
+				authComments.DELETE("/:id", commentHandler.Delete)        // 删除评论（移入回收站）
+				authComments.GET("/trash", commentHandler.Trash)          // 回收站中的评论
+				authComments.POST("/:id/restore", commentHandler.Restore) // 从回收站恢复评论
+				authComments.POST("/:id/upvote", commentHandler.Upvote)         // 点赞评论
+				authComments.DELETE("/:id/upvote", commentHandler.RemoveUpvote) // 取消点赞
+				authComments.PUT("/:id/pin", commentHandler.Pin)                // 置顶评论（文章作者或管理员）
+				authComments.DELETE("/:id/pin", commentHandler.Unpin)           // 取消置顶评论
 			}
 
 			// Category routes (admin only)
@@ -96,6 +171,29 @@ func SetupRouter(factory service.Factory) *gin.Engine {
 				authTags.POST("/batch", tagHandler.CreateBatch) // 批量创建标签
 				authTags.PUT("/:id", tagHandler.Update)        // 更新标签
 				authTags.DELETE("/:id", tagHandler.Delete)      // 删除标签
+				authTags.POST("/merge", tagHandler.Merge)       // 合并标签
+				authTags.POST("/:id/aliases", tagHandler.AddAlias)              // 添加标签别名
+				authTags.DELETE("/:id/aliases/:alias_id", tagHandler.DeleteAlias) // 删除标签别名
+			}
+
+			// Series routes (admin only)
+			authSeries := protected.Group("/series")
+			authSeries.Use(middleware.AdminAuthMiddleware())
+			{
+				authSeries.POST("", seriesHandler.Create)           // 创建系列
+				authSeries.PUT("/:id", seriesHandler.Update)        // 更新系列
+				authSeries.DELETE("/:id", seriesHandler.Delete)     // 删除系列
+				authSeries.PUT("/:id/posts", seriesHandler.SetPosts) // 设置系列文章及顺序
+			}
+
+			// Analytics routes (admin only)
+			analytics := protected.Group("/analytics")
+			analytics.Use(middleware.AdminAuthMiddleware())
+			{
+				analytics.GET("/popular", analyticsHandler.TopPosts)                  // 热门文章排行
+				analytics.GET("/posts/:id/views", analyticsHandler.PostViews)         // 文章浏览量时间序列
+				analytics.GET("/posts/:id/referrers", analyticsHandler.PostReferrers) // 文章来源域名排行
+				analytics.GET("/posts/:id/campaigns", analyticsHandler.PostCampaigns) // 文章UTM推广活动排行
 			}
 		}
 	}
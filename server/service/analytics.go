@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
+	"github.com/personal-blog/repository/mysql"
+	"github.com/personal-blog/repository/redis"
+)
+
+const (
+	hourlySeriesWindow = 48 * time.Hour       // 按小时的浏览量只保留最近48小时
+	maxAnalyticsRange  = 366 * 24 * time.Hour // 单次查询的最大时间范围
+	popularWindow      = 7 * 24 * time.Hour   // “本周最多阅读”统计最近7天
+)
+
+// AnalyticsService 文章浏览统计服务接口
+type AnalyticsService interface {
+	Flush(ctx context.Context) error
+	ViewSeries(ctx context.Context, postID uint, granularity string, from, to time.Time) ([]models.PostViewBucket, error)
+	TopPosts(ctx context.Context, from, to time.Time, limit int) ([]models.PopularPost, error)
+	TopReferrers(ctx context.Context, postID uint, from, to time.Time, limit int) ([]models.ReferrerCount, error)
+	TopCampaigns(ctx context.Context, postID uint, from, to time.Time, limit int) ([]models.CampaignCount, error)
+	MostReadThisWeek(ctx context.Context, limit int) ([]models.PopularPost, error)
+}
+
+type analyticsService struct {
+	analyticsRepo  mysql.AnalyticsRepository
+	analyticsCache redis.AnalyticsCache
+}
+
+// NewAnalyticsService 创建浏览统计服务实例
+func NewAnalyticsService(analyticsRepo mysql.AnalyticsRepository, analyticsCache redis.AnalyticsCache) AnalyticsService {
+	return &analyticsService{
+		analyticsRepo:  analyticsRepo,
+		analyticsCache: analyticsCache,
+	}
+}
+
+// Flush 将缓存中累加的统计数据写入数据库
+// 由后台任务定期调用，进程退出前也会调用一次
+func (s *analyticsService) Flush(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "AnalyticsService.Flush")
+	defer span.End()
+
+	// 第一轮可能取到上次未完成的批次，第二轮再处理当前的待同步数据
+	for i := 0; i < 2; i++ {
+		batch, err := s.analyticsCache.BeginFlush(ctx, "analytics-"+newViewBatchID())
+		if err != nil {
+			return err
+		}
+		if batch == nil {
+			return nil
+		}
+
+		if err := s.analyticsRepo.ApplyBatch(ctx, batch.ID, batch.Buckets, batch.Referrers, batch.Campaigns); err != nil {
+			return err
+		}
+		if err := s.analyticsCache.EndFlush(ctx, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ViewSeries 返回文章在 [from, to) 内按小时或按天的浏览量，没有浏览的时间段补0
+// 按小时查询时起始时间不早于48小时前
+func (s *analyticsService) ViewSeries(ctx context.Context, postID uint, granularity string, from, to time.Time) ([]models.PostViewBucket, error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsService.ViewSeries")
+	defer span.End()
+
+	var step time.Duration
+	switch granularity {
+	case models.GranularityHour:
+		step = time.Hour
+		from = from.UTC().Truncate(time.Hour)
+		if earliest := time.Now().UTC().Add(-hourlySeriesWindow).Truncate(time.Hour); from.Before(earliest) {
+			from = earliest
+		}
+	case models.GranularityDay:
+		step = 24 * time.Hour
+		from = startOfDay(from)
+	default:
+		return nil, fmt.Errorf("unsupported granularity %q", granularity)
+	}
+	if err := checkRange(from, to); err != nil {
+		return nil, err
+	}
+
+	buckets, err := s.analyticsRepo.ListViewBuckets(ctx, postID, granularity, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make(map[int64]int64, len(buckets))
+	for _, b := range buckets {
+		views[b.BucketStart.Unix()] = b.Views
+	}
+	series := make([]models.PostViewBucket, 0, int(to.Sub(from)/step)+1)
+	for t := from; t.Before(to); t = t.Add(step) {
+		series = append(series, models.PostViewBucket{
+			PostID:      postID,
+			Granularity: granularity,
+			BucketStart: t,
+			Views:       views[t.Unix()],
+		})
+	}
+	return series, nil
+}
+
+// TopPosts 返回 [from, to) 内浏览量最高的文章
+func (s *analyticsService) TopPosts(ctx context.Context, from, to time.Time, limit int) ([]models.PopularPost, error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsService.TopPosts")
+	defer span.End()
+
+	if err := checkRange(from, to); err != nil {
+		return nil, err
+	}
+	return s.analyticsRepo.TopPosts(ctx, startOfDay(from), to, limit)
+}
+
+// TopReferrers 返回文章在 [from, to) 内浏览量最高的来源域名
+func (s *analyticsService) TopReferrers(ctx context.Context, postID uint, from, to time.Time, limit int) ([]models.ReferrerCount, error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsService.TopReferrers")
+	defer span.End()
+
+	if err := checkRange(from, to); err != nil {
+		return nil, err
+	}
+	return s.analyticsRepo.TopReferrers(ctx, postID, startOfDay(from), to, limit)
+}
+
+// TopCampaigns 返回文章在 [from, to) 内浏览量最高的UTM推广活动
+func (s *analyticsService) TopCampaigns(ctx context.Context, postID uint, from, to time.Time, limit int) ([]models.CampaignCount, error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsService.TopCampaigns")
+	defer span.End()
+
+	if err := checkRange(from, to); err != nil {
+		return nil, err
+	}
+	return s.analyticsRepo.TopCampaigns(ctx, postID, startOfDay(from), to, limit)
+}
+
+// MostReadThisWeek 返回最近7天（含今天）浏览量最高的文章，结果缓存几分钟
+func (s *analyticsService) MostReadThisWeek(ctx context.Context, limit int) ([]models.PopularPost, error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsService.MostReadThisWeek")
+	defer span.End()
+
+	to := startOfDay(time.Now()).Add(24 * time.Hour)
+	from := to.Add(-popularWindow)
+	key := fmt.Sprintf("%d_%d", from.Unix(), limit)
+
+	if posts, err := s.analyticsCache.GetPopular(ctx, key); err == nil && posts != nil {
+		return posts, nil
+	}
+
+	posts, err := s.analyticsRepo.TopPosts(ctx, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	if posts == nil {
+		posts = []models.PopularPost{}
+	}
+	_ = s.analyticsCache.SetPopular(ctx, key, posts)
+	return posts, nil
+}
+
+// startOfDay 返回t所在UTC日期的零点，统计数据均按UTC日期聚合
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func checkRange(from, to time.Time) error {
+	if !from.Before(to) {
+		return errors.New("invalid time range")
+	}
+	if to.Sub(from) > maxAnalyticsRange {
+		return errors.New("time range too large")
+	}
+	return nil
+}
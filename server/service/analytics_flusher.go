@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/personal-blog/config"
+)
+
+// analyticsFlushWorker 定期将缓存中的浏览统计写入数据库，与浏览量共用 view.flush_interval
+type analyticsFlushWorker struct {
+	analyticsService AnalyticsService
+}
+
+func newAnalyticsFlushWorker(analyticsService AnalyticsService) Worker {
+	return &analyticsFlushWorker{analyticsService: analyticsService}
+}
+
+func (w *analyticsFlushWorker) Name() string {
+	return "analytics-flusher"
+}
+
+func (w *analyticsFlushWorker) Run(ctx context.Context) {
+	for {
+		interval := time.Duration(config.Get().View.FlushInterval) * time.Second
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		if err := w.analyticsService.Flush(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("worker %s: flush analytics: %v", w.Name(), err)
+		}
+	}
+}
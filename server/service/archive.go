@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
+)
+
+// Archive 返回按年、月统计的已发布文章数，年份和月份均倒序
+// 结果与文章列表一起缓存，文章发布、撤回、删除或恢复后重新统计
+func (s *postService) Archive(ctx context.Context) ([]models.ArchiveYear, error) {
+	ctx, span := tracing.Start(ctx, "PostService.Archive")
+	defer span.End()
+
+	version, err := s.postCache.PostListVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	years, err := s.postCache.GetArchive(ctx, version)
+	if err != nil || years != nil {
+		return years, err
+	}
+
+	times, err := s.postRepo.ListPublishedTimes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	years = groupArchive(times)
+	if err := s.postCache.SetArchive(ctx, version, years); err != nil {
+		return nil, err
+	}
+	return years, nil
+}
+
+// groupArchive 按服务器时区的年月分组统计，与文章列表的 year、month 筛选使用相同的划分
+func groupArchive(times []time.Time) []models.ArchiveYear {
+	counts := make(map[int]map[int]int64)
+	for _, t := range times {
+		t = t.In(time.Local)
+		if counts[t.Year()] == nil {
+			counts[t.Year()] = make(map[int]int64)
+		}
+		counts[t.Year()][int(t.Month())]++
+	}
+
+	years := make([]models.ArchiveYear, 0, len(counts))
+	for year, months := range counts {
+		item := models.ArchiveYear{Year: year, Months: make([]models.ArchiveMonth, 0, len(months))}
+		for month := 12; month >= 1; month-- {
+			if n := months[month]; n > 0 {
+				item.Months = append(item.Months, models.ArchiveMonth{Month: month, Count: n})
+				item.Count += n
+			}
+		}
+		years = append(years, item)
+	}
+	sort.Slice(years, func(i, j int) bool { return years[i].Year > years[j].Year })
+	return years
+}
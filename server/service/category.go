@@ -7,47 +7,66 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
 	"github.com/personal-blog/repository/mysql"
 	"github.com/personal-blog/repository/redis"
 )
 
+var (
+	// ErrParentNotFound 父分类或删除时指定的转移目标分类不存在
+	ErrParentNotFound = errors.New("parent category not found")
+	// ErrCategoryCycle 父分类或转移目标是分类自身或其子孙分类，会形成环
+	ErrCategoryCycle = errors.New("parent category cannot be the category itself or its subcategory")
+)
+
 // CategoryService 分类服务接口
 type CategoryService interface {
 	CreateCategory(ctx context.Context, category *models.Category) error
 	UpdateCategory(ctx context.Context, category *models.Category) error
-	DeleteCategory(ctx context.Context, id uint) error
+	DeleteCategory(ctx context.Context, id uint, reassignTo *uint) error
 	GetCategoryByID(ctx context.Context, id uint) (*models.Category, error)
 	ListCategories(ctx context.Context, page, pageSize int) ([]models.Category, int64, error)
+	Tree(ctx context.Context) ([]*models.CategoryNode, error)
+	DescendantIDs(ctx context.Context, id uint) ([]uint, error)
+	AttachBreadcrumbs(ctx context.Context, posts ...*models.Post) error
 }
 
 type categoryService struct {
 	categoryRepo  mysql.CategoryRepository
 	categoryCache redis.CategoryCache
+	postCache     redis.PostCache
 }
 
 // NewCategoryService 创建分类服务实例
-func NewCategoryService(categoryRepo mysql.CategoryRepository, categoryCache redis.CategoryCache) CategoryService {
+func NewCategoryService(categoryRepo mysql.CategoryRepository, categoryCache redis.CategoryCache, postCache redis.PostCache) CategoryService {
 	return &categoryService{
 		categoryRepo:  categoryRepo,
 		categoryCache: categoryCache,
+		postCache:     postCache,
 	}
 }
 
 func (s *categoryService) CreateCategory(ctx context.Context, category *models.Category) error {
+	ctx, span := tracing.Start(ctx, "CategoryService.CreateCategory")
+	defer span.End()
+
 	// 检查名称是否已存在
-	existing, err := s.categoryRepo.FindByName(category.Name)
+	existing, err := s.categoryRepo.FindByName(ctx, category.Name)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return err
 	}
 	if existing != nil {
 		return errors.New("category name already exists")
 	}
+	if err := s.checkParent(ctx, category); err != nil {
+		return err
+	}
 
 	category.CreatedAt = time.Now()
 	category.UpdatedAt = time.Now()
 
 	// 创建分类
-	if err := s.categoryRepo.Create(category); err != nil {
+	if err := s.categoryRepo.Create(ctx, category); err != nil {
 		return err
 	}
 
@@ -57,23 +76,29 @@ func (s *categoryService) CreateCategory(ctx context.Context, category *models.C
 	}
 
 	// 清除分类列表缓存
-	return s.categoryCache.Delete(ctx, 0)
+	return s.categoryCache.DeleteList(ctx)
 }
 
 func (s *categoryService) UpdateCategory(ctx context.Context, category *models.Category) error {
+	ctx, span := tracing.Start(ctx, "CategoryService.UpdateCategory")
+	defer span.End()
+
 	// 检查名称是否已存在（排除自身）
-	existing, err := s.categoryRepo.FindByName(category.Name)
+	existing, err := s.categoryRepo.FindByName(ctx, category.Name)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return err
 	}
 	if existing != nil && existing.ID != category.ID {
 		return errors.New("category name already exists")
 	}
+	if err := s.checkParent(ctx, category); err != nil {
+		return err
+	}
 
 	category.UpdatedAt = time.Now()
 
 	// 更新分类
-	if err := s.categoryRepo.Update(category); err != nil {
+	if err := s.categoryRepo.Update(ctx, category); err != nil {
 		return err
 	}
 
@@ -82,13 +107,38 @@ func (s *categoryService) UpdateCategory(ctx context.Context, category *models.C
 		return err
 	}
 
+	// 文章列表中包含分类信息
+	if err := s.postCache.InvalidatePostLists(ctx); err != nil {
+		return err
+	}
+
 	// 清除分类列表缓存
-	return s.categoryCache.Delete(ctx, 0)
+	return s.categoryCache.DeleteList(ctx)
 }
 
-func (s *categoryService) DeleteCategory(ctx context.Context, id uint) error {
-	// 删除分类
-	if err := s.categoryRepo.Delete(id); err != nil {
+// DeleteCategory 删除分类
+// 分类下有子分类或文章时必须指定reassignTo，子分类和文章会转移到该分类，目标不能是被删除分类自身或其子孙分类
+func (s *categoryService) DeleteCategory(ctx context.Context, id uint, reassignTo *uint) error {
+	ctx, span := tracing.Start(ctx, "CategoryService.DeleteCategory")
+	defer span.End()
+
+	if reassignTo != nil {
+		categories, err := s.allCategories(ctx)
+		if err != nil {
+			return err
+		}
+		byID := indexCategories(categories)
+		if _, ok := byID[*reassignTo]; !ok {
+			return ErrParentNotFound
+		}
+		if isDescendant(byID, *reassignTo, id) {
+			return ErrCategoryCycle
+		}
+	}
+
+	// 删除分类并转移子分类和文章
+	moved, err := s.categoryRepo.DeleteAndReassign(ctx, id, reassignTo)
+	if err != nil {
 		return err
 	}
 
@@ -96,35 +146,202 @@ func (s *categoryService) DeleteCategory(ctx context.Context, id uint) error {
 	if err := s.categoryCache.Delete(ctx, id); err != nil {
 		return err
 	}
+	for _, postID := range moved {
+		if err := s.postCache.Delete(ctx, postID); err != nil {
+			return err
+		}
+	}
+
+	// 文章列表中包含分类信息
+	if err := s.postCache.InvalidatePostLists(ctx); err != nil {
+		return err
+	}
 
 	// 清除分类列表缓存
-	return s.categoryCache.Delete(ctx, 0)
+	return s.categoryCache.DeleteList(ctx)
 }
 
 func (s *categoryService) GetCategoryByID(ctx context.Context, id uint) (*models.Category, error) {
-	// 先从缓存获取
-	category, err := s.categoryCache.Get(ctx, id)
+	ctx, span := tracing.Start(ctx, "CategoryService.GetCategoryByID")
+	defer span.End()
+
+	// 缓存未命中时从数据库加载，并发请求合并为一次查询，不存在的记录会写入负缓存
+	return s.categoryCache.GetOrLoad(ctx, id, func(ctx context.Context) (*models.Category, error) {
+		return s.categoryRepo.FindByID(ctx, id)
+	})
+}
+
+func (s *categoryService) ListCategories(ctx context.Context, page, pageSize int) ([]models.Category, int64, error) {
+	ctx, span := tracing.Start(ctx, "CategoryService.ListCategories")
+	defer span.End()
+
+	return s.categoryRepo.List(ctx, page, pageSize)
+}
+
+// Tree 返回完整的分类树，每个节点包含自身及所有子孙分类的已发布文章数
+func (s *categoryService) Tree(ctx context.Context) ([]*models.CategoryNode, error) {
+	ctx, span := tracing.Start(ctx, "CategoryService.Tree")
+	defer span.End()
+
+	categories, err := s.allCategories(ctx)
 	if err != nil {
 		return nil, err
 	}
-	if category != nil {
-		return category, nil
+	counts, err := s.categoryRepo.CountPosts(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// 缓存未命中，从数据库获取
-	category, err = s.categoryRepo.FindByID(id)
+	nodes := make(map[uint]*models.CategoryNode, len(categories))
+	for _, c := range categories {
+		nodes[c.ID] = &models.CategoryNode{
+			ID:          c.ID,
+			Name:        c.Name,
+			Description: c.Description,
+			ParentID:    c.ParentID,
+			PostCount:   counts[c.ID],
+			Children:    []*models.CategoryNode{},
+		}
+	}
+
+	roots := []*models.CategoryNode{}
+	for _, c := range categories {
+		if c.ParentID != nil {
+			if parent, ok := nodes[*c.ParentID]; ok {
+				parent.Children = append(parent.Children, nodes[c.ID])
+				continue
+			}
+		}
+		roots = append(roots, nodes[c.ID])
+	}
+	for _, root := range roots {
+		sumCounts(root)
+	}
+	return roots, nil
+}
+
+// DescendantIDs 返回分类自身及所有子孙分类的ID
+func (s *categoryService) DescendantIDs(ctx context.Context, id uint) ([]uint, error) {
+	ctx, span := tracing.Start(ctx, "CategoryService.DescendantIDs")
+	defer span.End()
+
+	categories, err := s.allCategories(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// 写入缓存
-	if err := s.categoryCache.Set(ctx, category); err != nil {
+	children := make(map[uint][]uint, len(categories))
+	for _, c := range categories {
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+	}
+
+	ids := []uint{id}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids, nil
+}
+
+// AttachBreadcrumbs 为文章填充从顶级分类到所属分类的面包屑
+func (s *categoryService) AttachBreadcrumbs(ctx context.Context, posts ...*models.Post) error {
+	ctx, span := tracing.Start(ctx, "CategoryService.AttachBreadcrumbs")
+	defer span.End()
+
+	categories, err := s.allCategories(ctx)
+	if err != nil {
+		return err
+	}
+	byID := indexCategories(categories)
+
+	for _, post := range posts {
+		var crumbs []models.CategoryCrumb
+		// 层级深度不会超过分类总数，防止数据异常时死循环
+		for id, depth := post.CategoryID, 0; depth <= len(categories); depth++ {
+			c, ok := byID[id]
+			if !ok {
+				break
+			}
+			crumbs = append([]models.CategoryCrumb{{ID: c.ID, Name: c.Name}}, crumbs...)
+			if c.ParentID == nil {
+				break
+			}
+			id = *c.ParentID
+		}
+		post.Breadcrumb = crumbs
+	}
+	return nil
+}
+
+// allCategories 读取全部分类，分类数量有限，整体缓存并在任意分类变更时清除
+func (s *categoryService) allCategories(ctx context.Context) ([]models.Category, error) {
+	categories, err := s.categoryCache.GetList(ctx)
+	if err != nil {
 		return nil, err
 	}
+	if categories != nil {
+		return categories, nil
+	}
 
-	return category, nil
+	categories, err = s.categoryRepo.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.categoryCache.SetList(ctx, categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
 }
 
-func (s *categoryService) ListCategories(ctx context.Context, page, pageSize int) ([]models.Category, int64, error) {
-	return s.categoryRepo.List(page, pageSize)
+// checkParent 校验父分类存在，且不是分类自身或其子孙分类，避免形成环
+func (s *categoryService) checkParent(ctx context.Context, category *models.Category) error {
+	if category.ParentID == nil {
+		return nil
+	}
+
+	categories, err := s.categoryRepo.ListAll(ctx)
+	if err != nil {
+		return err
+	}
+	byID := indexCategories(categories)
+	if _, ok := byID[*category.ParentID]; !ok {
+		return ErrParentNotFound
+	}
+	if category.ID != 0 && isDescendant(byID, *category.ParentID, category.ID) {
+		return ErrCategoryCycle
+	}
+	return nil
+}
+
+func indexCategories(categories []models.Category) map[uint]*models.Category {
+	byID := make(map[uint]*models.Category, len(categories))
+	for i := range categories {
+		byID[categories[i].ID] = &categories[i]
+	}
+	return byID
+}
+
+// isDescendant 判断id是否为ancestor自身或其子孙分类
+func isDescendant(byID map[uint]*models.Category, id, ancestor uint) bool {
+	for depth := 0; depth <= len(byID); depth++ {
+		if id == ancestor {
+			return true
+		}
+		c, ok := byID[id]
+		if !ok || c.ParentID == nil {
+			return false
+		}
+		id = *c.ParentID
+	}
+	return false
+}
+
+// sumCounts 自底向上累加子孙分类的文章数
+func sumCounts(node *models.CategoryNode) int64 {
+	node.TotalCount = node.PostCount
+	for _, child := range node.Children {
+		node.TotalCount += sumCounts(child)
+	}
+	return node.TotalCount
 }
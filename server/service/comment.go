@@ -2,9 +2,13 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"github.com/personal-blog/config"
 	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
+	"github.com/personal-blog/pkg/utils"
 	"github.com/personal-blog/repository/mysql"
 	"github.com/personal-blog/repository/redis"
 )
@@ -14,30 +18,51 @@ type CommentService interface {
 	CreateComment(ctx context.Context, comment *models.Comment) error
 	UpdateComment(ctx context.Context, comment *models.Comment) error
 	DeleteComment(ctx context.Context, id uint) error
+	RestoreComment(ctx context.Context, id uint, ownerID *uint) error
+	ListTrashedComments(ctx context.Context, ownerID *uint, page, pageSize int) ([]models.TrashedComment, int64, error)
 	GetCommentByID(ctx context.Context, id uint) (*models.Comment, error)
-	ListCommentsByPost(ctx context.Context, postID uint, page, pageSize int) ([]models.Comment, int64, error)
+	// ListCommentsByPost 按sort分页查询文章的评论，置顶的评论排在最前，文章作者的评论带有作者标记
+	ListCommentsByPost(ctx context.Context, postID uint, sort models.CommentSort, page, pageSize int) ([]models.Comment, int64, error)
+	// ListCommentsByPostCursor 按sort游标分页查询文章的评论
+	ListCommentsByPostCursor(ctx context.Context, postID uint, sort models.CommentSort, query *CursorQuery) (*CommentCursorPage, error)
 	ListCommentsByUser(ctx context.Context, userID uint, page, pageSize int) ([]models.Comment, int64, error)
+	UpvoteComment(ctx context.Context, id, userID uint) (*models.CommentVoteState, error)
+	RemoveCommentUpvote(ctx context.Context, id, userID uint) (*models.CommentVoteState, error)
+	// PinComment 置顶或取消置顶评论，调用方负责确认操作者是文章作者或管理员
+	PinComment(ctx context.Context, comment *models.Comment, pinned bool) error
 }
 
 type commentService struct {
 	commentRepo  mysql.CommentRepository
 	commentCache redis.CommentCache
+	postCache    redis.PostCache
 }
 
 // NewCommentService 创建评论服务实例
-func NewCommentService(commentRepo mysql.CommentRepository, commentCache redis.CommentCache) CommentService {
+func NewCommentService(commentRepo mysql.CommentRepository, commentCache redis.CommentCache, postCache redis.PostCache) CommentService {
 	return &commentService{
 		commentRepo:  commentRepo,
 		commentCache: commentCache,
+		postCache:    postCache,
 	}
 }
 
 func (s *commentService) CreateComment(ctx context.Context, comment *models.Comment) error {
+	ctx, span := tracing.Start(ctx, "CommentService.CreateComment")
+	defer span.End()
+
 	comment.CreatedAt = time.Now()
 	comment.UpdatedAt = time.Now()
 
+	// 根据审核模式设置状态
+	if config.Get().Comment.Moderation == "all" {
+		comment.Status = models.CommentStatusPending
+	} else {
+		comment.Status = models.CommentStatusApproved
+	}
+
 	// 创建评论
-	if err := s.commentRepo.Create(comment); err != nil {
+	if err := s.commentRepo.Create(ctx, comment); err != nil {
 		return err
 	}
 
@@ -46,15 +71,20 @@ func (s *commentService) CreateComment(ctx context.Context, comment *models.Comm
 		return err
 	}
 
-	// 清除文章评论列表缓存
-	return s.commentCache.Delete(ctx, comment.PostID)
+	if err := s.invalidateCommentCount(ctx, comment); err != nil {
+		return err
+	}
+	return s.invalidateContent(ctx, comment)
 }
 
 func (s *commentService) UpdateComment(ctx context.Context, comment *models.Comment) error {
+	ctx, span := tracing.Start(ctx, "CommentService.UpdateComment")
+	defer span.End()
+
 	comment.UpdatedAt = time.Now()
 
 	// 更新评论
-	if err := s.commentRepo.Update(comment); err != nil {
+	if err := s.commentRepo.Update(ctx, comment); err != nil {
 		return err
 	}
 
@@ -63,19 +93,21 @@ func (s *commentService) UpdateComment(ctx context.Context, comment *models.Comm
 		return err
 	}
 
-	// 清除文章评论列表缓存
-	return s.commentCache.Delete(ctx, comment.PostID)
+	return s.invalidateContent(ctx, comment)
 }
 
 func (s *commentService) DeleteComment(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "CommentService.DeleteComment")
+	defer span.End()
+
 	// 获取评论信息（用于后续清除缓存）
-	comment, err := s.commentRepo.FindByID(id)
+	comment, err := s.commentRepo.FindByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
 	// 删除评论
-	if err := s.commentRepo.Delete(id); err != nil {
+	if err := s.commentRepo.Delete(ctx, id); err != nil {
 		return err
 	}
 
@@ -84,96 +116,293 @@ func (s *commentService) DeleteComment(ctx context.Context, id uint) error {
 		return err
 	}
 
-	// 清除文章评论列表缓存
-	return s.commentCache.Delete(ctx, comment.PostID)
+	if err := s.invalidateCommentCount(ctx, comment); err != nil {
+		return err
+	}
+	return s.invalidateContent(ctx, comment)
 }
 
-func (s *commentService) GetCommentByID(ctx context.Context, id uint) (*models.Comment, error) {
-	// 先从缓存获取
-	comment, err := s.commentCache.Get(ctx, id)
+// RestoreComment 从回收站恢复单独删除的评论，ownerID非空时只能恢复该用户的评论
+func (s *commentService) RestoreComment(ctx context.Context, id uint, ownerID *uint) error {
+	ctx, span := tracing.Start(ctx, "CommentService.RestoreComment")
+	defer span.End()
+
+	comment, err := s.commentRepo.Restore(ctx, id, ownerID)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	// 删除缓存同时清除删除期间写入的负缓存
+	if err := s.commentCache.Delete(ctx, id); err != nil {
+		return err
 	}
-	if comment != nil {
-		return comment, nil
+
+	if err := s.invalidateCommentCount(ctx, comment); err != nil {
+		return err
 	}
+	return s.invalidateContent(ctx, comment)
+}
 
-	// 缓存未命中，从数据库获取
-	comment, err = s.commentRepo.FindByID(id)
+func (s *commentService) ListTrashedComments(ctx context.Context, ownerID *uint, page, pageSize int) ([]models.TrashedComment, int64, error) {
+	ctx, span := tracing.Start(ctx, "CommentService.ListTrashedComments")
+	defer span.End()
+
+	comments, total, err := s.commentRepo.ListTrashed(ctx, ownerID, page, pageSize)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	// 写入缓存
-	if err := s.commentCache.Set(ctx, comment); err != nil {
-		return nil, err
+	items := make([]models.TrashedComment, 0, len(comments))
+	for _, comment := range comments {
+		items = append(items, models.TrashedComment{
+			ID:        comment.ID,
+			PostID:    comment.PostID,
+			UserID:    comment.UserID,
+			Content:   comment.Content,
+			DeletedAt: comment.DeletedAt.Time,
+			PurgeAt:   purgeTime(comment.DeletedAt.Time),
+		})
+	}
+	return items, total, nil
+}
+
+// invalidateComments 清除一批评论的缓存及其所属文章和用户的评论列表缓存
+func invalidateComments(ctx context.Context, cache redis.CommentCache, comments []models.Comment) error {
+	posts := make(map[uint]bool)
+	users := make(map[uint]bool)
+	for _, comment := range comments {
+		if err := cache.Delete(ctx, comment.ID); err != nil {
+			return err
+		}
+		posts[comment.PostID] = true
+		users[comment.UserID] = true
+	}
+	for postID := range posts {
+		if err := cache.InvalidatePostComments(ctx, postID); err != nil {
+			return err
+		}
+	}
+	for userID := range users {
+		if err := cache.InvalidateUserComments(ctx, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// invalidateLists 清除评论所属文章和用户的评论列表缓存
+func (s *commentService) invalidateLists(ctx context.Context, comment *models.Comment) error {
+	if err := s.commentCache.InvalidatePostComments(ctx, comment.PostID); err != nil {
+		return err
+	}
+	return s.commentCache.InvalidateUserComments(ctx, comment.UserID)
+}
+
+// invalidateContent 评论增删改后清除评论列表缓存和所属文章的详情缓存，文章详情中预加载了评论
+func (s *commentService) invalidateContent(ctx context.Context, comment *models.Comment) error {
+	if err := s.invalidateLists(ctx, comment); err != nil {
+		return err
+	}
+	return s.postCache.Delete(ctx, comment.PostID)
+}
+
+// invalidateCommentCount 已通过审核的评论增减后，文章列表中的评论数和按评论数的排序都已过期，需要清除文章列表缓存
+func (s *commentService) invalidateCommentCount(ctx context.Context, comment *models.Comment) error {
+	if comment.Status != models.CommentStatusApproved {
+		return nil
 	}
+	return s.postCache.InvalidatePostLists(ctx)
+}
+
+func (s *commentService) GetCommentByID(ctx context.Context, id uint) (*models.Comment, error) {
+	ctx, span := tracing.Start(ctx, "CommentService.GetCommentByID")
+	defer span.End()
 
-	return comment, nil
+	// 缓存未命中时从数据库加载，并发请求合并为一次查询，不存在的记录会写入负缓存
+	return s.commentCache.GetOrLoad(ctx, id, func(ctx context.Context) (*models.Comment, error) {
+		return s.commentRepo.FindByID(ctx, id)
+	})
 }
 
-func (s *commentService) ListCommentsByPost(ctx context.Context, postID uint, page, pageSize int) ([]models.Comment, int64, error) {
+func (s *commentService) ListCommentsByPost(ctx context.Context, postID uint, sort models.CommentSort, page, pageSize int) ([]models.Comment, int64, error) {
+	ctx, span := tracing.Start(ctx, "CommentService.ListCommentsByPost")
+	defer span.End()
+
+	// 先读取版本号再查询数据库，查询期间发生的失效不会让旧数据写入新版本
+	version, err := s.commentCache.PostCommentsVersion(ctx, postID)
+	if err != nil {
+		return nil, 0, err
+	}
+	cacheKey := fmt.Sprintf("%d_%d_%s", page, pageSize, sort)
+
 	// 先从缓存获取
-	comments, err := s.commentCache.GetPostComments(ctx, postID)
+	cached, err := s.commentCache.GetPostComments(ctx, postID, version, cacheKey)
 	if err != nil {
 		return nil, 0, err
 	}
-	if len(comments) > 0 {
-		// 处理分页
-		start := (page - 1) * pageSize
-		end := start + pageSize
-		if start >= len(comments) {
-			return []models.Comment{}, int64(len(comments)), nil
-		}
-		if end > len(comments) {
-			end = len(comments)
-		}
-		return comments[start:end], int64(len(comments)), nil
+	if cached != nil {
+		return cached.Comments, cached.Total, nil
 	}
 
 	// 从数据库获取
-	comments, total, err := s.commentRepo.ListByPostID(postID, page, pageSize)
+	comments, total, err := s.commentRepo.ListByPostID(ctx, postID, sort, page, pageSize)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// 写入缓存
-	if err := s.commentCache.SetPostComments(ctx, postID, comments); err != nil {
+	if err := s.commentCache.SetPostComments(ctx, postID, version, cacheKey, &redis.CommentListPage{Comments: comments, Total: total}); err != nil {
 		return nil, 0, err
 	}
 
 	return comments, total, nil
 }
 
+func (s *commentService) ListCommentsByPostCursor(ctx context.Context, postID uint, sort models.CommentSort, query *CursorQuery) (*CommentCursorPage, error) {
+	ctx, span := tracing.Start(ctx, "CommentService.ListCommentsByPostCursor")
+	defer span.End()
+
+	after, err := utils.DecodeCursor(query.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	// 与页码分页共用文章评论列表缓存的版本号
+	version, err := s.commentCache.PostCommentsVersion(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := fmt.Sprintf("cursor_%s_%d_%s", query.Cursor, query.Limit, sort)
+
+	cached, err := s.commentCache.GetPostComments(ctx, postID, version, cacheKey)
+	if err != nil {
+		return nil, err
+	}
+	if cached == nil {
+		// 多取一条判断是否还有下一页
+		comments, err := s.commentRepo.ListByPostIDCursor(ctx, postID, sort, after, query.Limit+1)
+		if err != nil {
+			return nil, err
+		}
+		fetched := len(comments)
+		if fetched > query.Limit {
+			comments = comments[:query.Limit]
+		}
+		cached = &redis.CommentListPage{Comments: comments}
+		if len(comments) > 0 {
+			last := comments[len(comments)-1]
+			cached.NextCursor = nextCursor(fetched, query.Limit, mysql.CommentCursor(sort, &last))
+		}
+		if err := s.commentCache.SetPostComments(ctx, postID, version, cacheKey, cached); err != nil {
+			return nil, err
+		}
+	}
+
+	page := &CommentCursorPage{Comments: cached.Comments, NextCursor: cached.NextCursor}
+	page.Total, err = cursorCount(ctx, query.Count,
+		func(ctx context.Context) (int64, error) { return s.commentRepo.CountByPostID(ctx, postID) },
+		func(ctx context.Context) (int64, bool, error) { return s.commentCache.GetPostCommentCount(ctx, postID) },
+		func(ctx context.Context, total int64) error {
+			return s.commentCache.SetPostCommentCount(ctx, postID, total)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
 func (s *commentService) ListCommentsByUser(ctx context.Context, userID uint, page, pageSize int) ([]models.Comment, int64, error) {
+	ctx, span := tracing.Start(ctx, "CommentService.ListCommentsByUser")
+	defer span.End()
+
+	// 先读取版本号再查询数据库，查询期间发生的失效不会让旧数据写入新版本
+	version, err := s.commentCache.UserCommentsVersion(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	cacheKey := fmt.Sprintf("%d_%d", page, pageSize)
+
 	// 先从缓存获取
-	comments, err := s.commentCache.GetUserComments(ctx, userID)
+	cached, err := s.commentCache.GetUserComments(ctx, userID, version, cacheKey)
 	if err != nil {
 		return nil, 0, err
 	}
-	if len(comments) > 0 {
-		// 处理分页
-		start := (page - 1) * pageSize
-		end := start + pageSize
-		if start >= len(comments) {
-			return []models.Comment{}, int64(len(comments)), nil
-		}
-		if end > len(comments) {
-			end = len(comments)
-		}
-		return comments[start:end], int64(len(comments)), nil
+	if cached != nil {
+		return cached.Comments, cached.Total, nil
 	}
 
 	// 从数据库获取
-	comments, total, err := s.commentRepo.ListByUserID(userID, page, pageSize)
+	comments, total, err := s.commentRepo.ListByUserID(ctx, userID, page, pageSize)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// 写入缓存
-	if err := s.commentCache.SetUserComments(ctx, userID, comments); err != nil {
+	if err := s.commentCache.SetUserComments(ctx, userID, version, cacheKey, &redis.CommentListPage{Comments: comments, Total: total}); err != nil {
 		return nil, 0, err
 	}
 
 	return comments, total, nil
 }
+
+// UpvoteComment 点赞评论，每个用户对每条评论只计一次
+func (s *commentService) UpvoteComment(ctx context.Context, id, userID uint) (*models.CommentVoteState, error) {
+	ctx, span := tracing.Start(ctx, "CommentService.UpvoteComment")
+	defer span.End()
+
+	comment, err := s.GetCommentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.commentRepo.Upvote(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	if err := s.invalidateVotes(ctx, comment); err != nil {
+		return nil, err
+	}
+	return s.commentRepo.VoteState(ctx, id, userID)
+}
+
+// RemoveCommentUpvote 取消点赞评论
+func (s *commentService) RemoveCommentUpvote(ctx context.Context, id, userID uint) (*models.CommentVoteState, error) {
+	ctx, span := tracing.Start(ctx, "CommentService.RemoveCommentUpvote")
+	defer span.End()
+
+	comment, err := s.GetCommentByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.commentRepo.RemoveUpvote(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	if err := s.invalidateVotes(ctx, comment); err != nil {
+		return nil, err
+	}
+	return s.commentRepo.VoteState(ctx, id, userID)
+}
+
+// invalidateVotes 点赞数变化后清除评论缓存和评论列表缓存，按点赞数排序的列表顺序也会变化
+func (s *commentService) invalidateVotes(ctx context.Context, comment *models.Comment) error {
+	if err := s.commentCache.Delete(ctx, comment.ID); err != nil {
+		return err
+	}
+	return s.invalidateLists(ctx, comment)
+}
+
+func (s *commentService) PinComment(ctx context.Context, comment *models.Comment, pinned bool) error {
+	ctx, span := tracing.Start(ctx, "CommentService.PinComment")
+	defer span.End()
+
+	unpinned, err := s.commentRepo.SetPinned(ctx, comment, pinned)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range append(unpinned, comment.ID) {
+		if err := s.commentCache.Delete(ctx, id); err != nil {
+			return err
+		}
+	}
+	return s.invalidateContent(ctx, comment)
+}
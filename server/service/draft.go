@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/personal-blog/config"
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
+	"github.com/personal-blog/repository/mysql"
+)
+
+const (
+	maxNewDrafts      = 50             // 每个用户最多保留的新文章草稿数
+	maxListedDrafts   = 100            // 草稿列表最多返回的条数
+	defaultPreviewTTL = 24 * time.Hour // 预览链接默认有效期
+	maxPreviewTTL     = 7 * 24 * time.Hour
+)
+
+// DraftService 文章草稿与预览服务接口
+type DraftService interface {
+	// SaveDraft 自动保存草稿：PostID非空时覆盖该文章的草稿，ID非空时覆盖该新文章草稿，否则新建
+	SaveDraft(ctx context.Context, draft *models.PostDraft) error
+	GetDraft(ctx context.Context, userID, id uint) (*models.PostDraft, error)
+	ListDrafts(ctx context.Context, userID uint) ([]models.PostDraft, error)
+	DeleteDraft(ctx context.Context, userID, id uint) error
+	// DiscardPostDraft 文章保存成功后删除用户对该文章的草稿
+	DiscardPostDraft(ctx context.Context, userID, postID uint) error
+	// PreviewLink 为文章生成带签名的预览链接，持有链接的人无需登录即可在有效期内阅读该文章
+	// ttl为0时使用默认有效期
+	PreviewLink(ctx context.Context, postID uint, ttl time.Duration) (*models.PreviewLink, error)
+	// VerifyPreview 校验预览令牌是否为该文章签发且未过期
+	VerifyPreview(postID uint, token string) bool
+}
+
+type draftService struct {
+	draftRepo mysql.DraftRepository
+}
+
+// NewDraftService 创建文章草稿服务实例
+func NewDraftService(draftRepo mysql.DraftRepository) DraftService {
+	return &draftService{draftRepo: draftRepo}
+}
+
+func (s *draftService) SaveDraft(ctx context.Context, draft *models.PostDraft) error {
+	ctx, span := tracing.Start(ctx, "DraftService.SaveDraft")
+	defer span.End()
+
+	draft.UpdatedAt = time.Now()
+	if draft.PostID != nil {
+		draft.ID = 0
+		draft.CreatedAt = draft.UpdatedAt
+		return s.draftRepo.SaveForPost(ctx, draft)
+	}
+	if draft.ID != 0 {
+		return s.draftRepo.Update(ctx, draft)
+	}
+
+	count, err := s.draftRepo.CountNew(ctx, draft.UserID)
+	if err != nil {
+		return err
+	}
+	if count >= maxNewDrafts {
+		return errors.New("too many drafts, delete some before creating new ones")
+	}
+	draft.CreatedAt = draft.UpdatedAt
+	return s.draftRepo.Create(ctx, draft)
+}
+
+func (s *draftService) GetDraft(ctx context.Context, userID, id uint) (*models.PostDraft, error) {
+	ctx, span := tracing.Start(ctx, "DraftService.GetDraft")
+	defer span.End()
+
+	return s.draftRepo.FindByID(ctx, userID, id)
+}
+
+func (s *draftService) ListDrafts(ctx context.Context, userID uint) ([]models.PostDraft, error) {
+	ctx, span := tracing.Start(ctx, "DraftService.ListDrafts")
+	defer span.End()
+
+	return s.draftRepo.ListByUser(ctx, userID, maxListedDrafts)
+}
+
+func (s *draftService) DeleteDraft(ctx context.Context, userID, id uint) error {
+	ctx, span := tracing.Start(ctx, "DraftService.DeleteDraft")
+	defer span.End()
+
+	return s.draftRepo.Delete(ctx, userID, id)
+}
+
+func (s *draftService) DiscardPostDraft(ctx context.Context, userID, postID uint) error {
+	ctx, span := tracing.Start(ctx, "DraftService.DiscardPostDraft")
+	defer span.End()
+
+	return s.draftRepo.DeleteForPost(ctx, userID, postID)
+}
+
+func (s *draftService) PreviewLink(ctx context.Context, postID uint, ttl time.Duration) (*models.PreviewLink, error) {
+	_, span := tracing.Start(ctx, "DraftService.PreviewLink")
+	defer span.End()
+
+	if ttl == 0 {
+		ttl = defaultPreviewTTL
+	}
+	if ttl < 0 || ttl > maxPreviewTTL {
+		return nil, errors.New("invalid preview ttl")
+	}
+	expiresAt := time.Now().Add(ttl).Truncate(time.Second)
+	token := signPreview(postID, expiresAt.Unix())
+	return &models.PreviewLink{
+		PostID:    postID,
+		Token:     token,
+		URL:       fmt.Sprintf("/api/v1/posts/%d?preview=%s", postID, token),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (s *draftService) VerifyPreview(postID uint, token string) bool {
+	exp, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	expiresAt, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() >= expiresAt {
+		return false
+	}
+	return hmac.Equal([]byte(token), []byte(signPreview(postID, expiresAt)))
+}
+
+// signPreview 生成预览令牌：<过期时间戳>.<签名>，签名覆盖文章ID和过期时间
+// 使用JWT密钥签名，与登录令牌的格式不同，二者不能互相替代
+func signPreview(postID uint, expiresAt int64) string {
+	mac := hmac.New(sha256.New, []byte(config.Get().JWT.Secret))
+	fmt.Fprintf(mac, "post-preview:%d:%d", postID, expiresAt)
+	return strconv.FormatInt(expiresAt, 10) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"sync"
 
 	"github.com/personal-blog/repository/mysql"
@@ -19,6 +20,13 @@ type Factory interface {
 	GetCategoryService() CategoryService
 	GetTagService() TagService
 	GetCommentService() CommentService
+	GetAnalyticsService() AnalyticsService
+	GetSeriesService() SeriesService
+	GetTrashService() TrashService
+	GetDraftService() DraftService
+	GetReactionService() ReactionService
+	StartWorkers()
+	Shutdown(ctx context.Context) error
 }
 
 // factory 实现Factory接口
@@ -30,6 +38,12 @@ type factory struct {
 	categorySrv  CategoryService
 	tagSrv       TagService
 	commentSrv   CommentService
+	analyticsSrv AnalyticsService
+	seriesSrv    SeriesService
+	trashSrv     TrashService
+	draftSrv     DraftService
+	reactionSrv  ReactionService
+	workers      workerGroup
 	mu           sync.RWMutex
 }
 
@@ -76,6 +90,10 @@ func (f *factory) GetPostService() PostService {
 			f.redisFactory.GetPostCache(),
 			f.mysqlFactory.GetTagRepository(),
 			f.mysqlFactory.GetCategoryRepository(),
+			f.redisFactory.GetTagCache(),
+			f.redisFactory.GetAnalyticsCache(),
+			f.mysqlFactory,
+			f.redisFactory.GetCommentCache(),
 		)
 	}
 	return f.postSrv
@@ -92,7 +110,11 @@ func (f *factory) GetCategoryService() CategoryService {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 	if f.categorySrv == nil {
-		f.categorySrv = NewCategoryService(f.mysqlFactory.GetCategoryRepository(), f.redisFactory.GetCategoryCache())
+		f.categorySrv = NewCategoryService(
+			f.mysqlFactory.GetCategoryRepository(),
+			f.redisFactory.GetCategoryCache(),
+			f.redisFactory.GetPostCache(),
+		)
 	}
 	return f.categorySrv
 }
@@ -112,6 +134,7 @@ func (f *factory) GetTagService() TagService {
 			f.mysqlFactory.GetTagRepository(),
 			f.redisFactory.GetTagCache(),
 			f.mysqlFactory.GetPostRepository(),
+			f.redisFactory.GetPostCache(),
 		)
 	}
 	return f.tagSrv
@@ -128,7 +151,111 @@ func (f *factory) GetCommentService() CommentService {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 	if f.commentSrv == nil {
-		f.commentSrv = NewCommentService(f.mysqlFactory.GetCommentRepository(), f.redisFactory.GetCommentCache())
+		f.commentSrv = NewCommentService(f.mysqlFactory.GetCommentRepository(), f.redisFactory.GetCommentCache(), f.redisFactory.GetPostCache())
 	}
 	return f.commentSrv
 }
+
+func (f *factory) GetAnalyticsService() AnalyticsService {
+	f.mu.RLock()
+	if f.analyticsSrv != nil {
+		defer f.mu.RUnlock()
+		return f.analyticsSrv
+	}
+	f.mu.RUnlock()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.analyticsSrv == nil {
+		f.analyticsSrv = NewAnalyticsService(f.mysqlFactory.GetAnalyticsRepository(), f.redisFactory.GetAnalyticsCache())
+	}
+	return f.analyticsSrv
+}
+
+func (f *factory) GetSeriesService() SeriesService {
+	f.mu.RLock()
+	if f.seriesSrv != nil {
+		defer f.mu.RUnlock()
+		return f.seriesSrv
+	}
+	f.mu.RUnlock()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.seriesSrv == nil {
+		f.seriesSrv = NewSeriesService(f.mysqlFactory.GetSeriesRepository(), f.redisFactory.GetPostCache())
+	}
+	return f.seriesSrv
+}
+
+func (f *factory) GetTrashService() TrashService {
+	f.mu.RLock()
+	if f.trashSrv != nil {
+		defer f.mu.RUnlock()
+		return f.trashSrv
+	}
+	f.mu.RUnlock()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.trashSrv == nil {
+		f.trashSrv = NewTrashService(f.mysqlFactory, f.redisFactory.GetPostCache(), f.redisFactory.GetCommentCache())
+	}
+	return f.trashSrv
+}
+
+func (f *factory) GetDraftService() DraftService {
+	f.mu.RLock()
+	if f.draftSrv != nil {
+		defer f.mu.RUnlock()
+		return f.draftSrv
+	}
+	f.mu.RUnlock()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.draftSrv == nil {
+		f.draftSrv = NewDraftService(f.mysqlFactory.GetDraftRepository())
+	}
+	return f.draftSrv
+}
+
+func (f *factory) GetReactionService() ReactionService {
+	f.mu.RLock()
+	if f.reactionSrv != nil {
+		defer f.mu.RUnlock()
+		return f.reactionSrv
+	}
+	f.mu.RUnlock()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.reactionSrv == nil {
+		f.reactionSrv = NewReactionService(f.mysqlFactory.GetReactionRepository(), f.redisFactory.GetReactionCache())
+	}
+	return f.reactionSrv
+}
+
+// StartWorkers 启动后台任务
+func (f *factory) StartWorkers() {
+	f.workers.add(newViewFlushWorker(f.GetPostService()))
+	f.workers.add(newAnalyticsFlushWorker(f.GetAnalyticsService()))
+	f.workers.add(newTrashPurgeWorker(f.GetTrashService()))
+	f.workers.add(newPinExpireWorker(f.GetPostService()))
+	f.workers.add(newReactionFlushWorker(f.GetReactionService()))
+	f.workers.start()
+}
+
+// Shutdown 停止后台任务并将缓冲数据写回数据库，在进程退出前调用
+func (f *factory) Shutdown(ctx context.Context) error {
+	if err := f.workers.stop(ctx); err != nil {
+		return err
+	}
+	if err := f.GetPostService().FlushViewCounts(ctx); err != nil {
+		return err
+	}
+	if err := f.GetReactionService().FlushCounts(ctx); err != nil {
+		return err
+	}
+	return f.GetAnalyticsService().Flush(ctx)
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/utils"
+)
+
+// CountMode 游标分页时总数的计算方式
+type CountMode string
+
+const (
+	CountNone   CountMode = "none"   // 不返回总数
+	CountExact  CountMode = "exact"  // 每次查询数据库精确计数
+	CountApprox CountMode = "approx" // 使用缓存的计数，最多滞后 cache.count_ttl
+)
+
+// CursorQuery 游标分页参数
+type CursorQuery struct {
+	Cursor string // 上一页返回的 NextCursor，为空时查询第一页
+	Limit  int
+	Count  CountMode
+}
+
+// PostCursorPage 游标分页的文章列表
+type PostCursorPage struct {
+	Posts      []models.Post
+	NextCursor string // 为空表示没有下一页
+	Total      *int64 // 未请求总数时为nil
+}
+
+// CommentCursorPage 游标分页的评论列表
+type CommentCursorPage struct {
+	Comments   []models.Comment
+	NextCursor string // 为空表示没有下一页
+	Total      *int64 // 未请求总数时为nil
+}
+
+// cursorCount 按mode计算总数：exact 查询数据库，approx 优先读取缓存的计数并在未命中时写回，none 返回nil
+func cursorCount(ctx context.Context, mode CountMode,
+	count func(ctx context.Context) (int64, error),
+	get func(ctx context.Context) (int64, bool, error),
+	set func(ctx context.Context, total int64) error,
+) (*int64, error) {
+	switch mode {
+	case CountExact:
+		total, err := count(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &total, nil
+	case CountApprox:
+		total, ok, err := get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			if total, err = count(ctx); err != nil {
+				return nil, err
+			}
+			if err := set(ctx, total); err != nil {
+				return nil, err
+			}
+		}
+		return &total, nil
+	default:
+		return nil, nil
+	}
+}
+
+// nextCursor 查询多取一条用于判断是否还有下一页，有下一页时返回以当前页最后一条记录为位置的游标
+func nextCursor(fetched, limit int, last utils.Cursor) string {
+	if fetched <= limit {
+		return ""
+	}
+	return utils.EncodeCursor(&last)
+}
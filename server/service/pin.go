@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
+)
+
+// ErrPinExpired 置顶的到期时间早于当前时间
+var ErrPinExpired = errors.New("pin expiry must be in the future")
+
+// PinPost 置顶文章，order越小越靠前，until非空时到期后由后台任务自动取消置顶
+// 已置顶的文章再次置顶时覆盖原来的顺序和到期时间
+func (s *postService) PinPost(ctx context.Context, id uint, order int, until *time.Time) error {
+	ctx, span := tracing.Start(ctx, "PostService.PinPost")
+	defer span.End()
+
+	if until != nil && !until.After(time.Now()) {
+		return ErrPinExpired
+	}
+	if _, err := s.postRepo.FindByID(ctx, id); err != nil {
+		return err
+	}
+	if err := s.postRepo.SetPin(ctx, id, true, order, until); err != nil {
+		return err
+	}
+	return s.invalidatePinned(ctx, id)
+}
+
+// UnpinPost 取消文章置顶
+func (s *postService) UnpinPost(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "PostService.UnpinPost")
+	defer span.End()
+
+	if _, err := s.postRepo.FindByID(ctx, id); err != nil {
+		return err
+	}
+	if err := s.postRepo.SetPin(ctx, id, false, 0, nil); err != nil {
+		return err
+	}
+	return s.invalidatePinned(ctx, id)
+}
+
+// SetFeatured 设置或取消文章的精选标记
+func (s *postService) SetFeatured(ctx context.Context, id uint, featured bool) error {
+	ctx, span := tracing.Start(ctx, "PostService.SetFeatured")
+	defer span.End()
+
+	if _, err := s.postRepo.FindByID(ctx, id); err != nil {
+		return err
+	}
+	if err := s.postRepo.SetFeatured(ctx, id, featured); err != nil {
+		return err
+	}
+	return s.invalidatePinned(ctx, id)
+}
+
+// FeaturedPosts 返回当前的精选文章，只包含已发布且公开列出的文章，排序与文章列表默认排序相同
+func (s *postService) FeaturedPosts(ctx context.Context, limit int) ([]models.Post, error) {
+	ctx, span := tracing.Start(ctx, "PostService.FeaturedPosts")
+	defer span.End()
+
+	featured := true
+	posts, _, err := s.ListPosts(ctx, 1, limit, &models.PostQuery{Featured: &featured}, nil)
+	return posts, err
+}
+
+// ExpirePins 取消所有已到期的置顶，返回被取消置顶的文章数
+func (s *postService) ExpirePins(ctx context.Context) (int, error) {
+	ctx, span := tracing.Start(ctx, "PostService.ExpirePins")
+	defer span.End()
+
+	ids, err := s.postRepo.ExpirePins(ctx, time.Now())
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	for _, id := range ids {
+		if err := s.postCache.Delete(ctx, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), s.postCache.InvalidatePostLists(ctx)
+}
+
+// invalidatePinned 文章的置顶或精选状态变化后清除文章和列表缓存
+func (s *postService) invalidatePinned(ctx context.Context, id uint) error {
+	if err := s.postCache.Delete(ctx, id); err != nil {
+		return err
+	}
+	return s.postCache.InvalidatePostLists(ctx)
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/personal-blog/config"
+)
+
+// pinExpireWorker 定期取消已到期的文章置顶
+type pinExpireWorker struct {
+	postService PostService
+}
+
+func newPinExpireWorker(postService PostService) Worker {
+	return &pinExpireWorker{postService: postService}
+}
+
+func (w *pinExpireWorker) Name() string {
+	return "pin-expirer"
+}
+
+func (w *pinExpireWorker) Run(ctx context.Context) {
+	for {
+		interval := time.Duration(config.Get().Pin.ExpireInterval) * time.Second
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		n, err := w.postService.ExpirePins(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("worker %s: expire pins: %v", w.Name(), err)
+		}
+		if n > 0 {
+			log.Printf("worker %s: unpinned %d posts", w.Name(), n)
+		}
+	}
+}
@@ -2,32 +2,85 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/personal-blog/config"
 	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
 	"github.com/personal-blog/pkg/utils"
 	"github.com/personal-blog/repository/mysql"
 	"github.com/personal-blog/repository/redis"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
+const (
+	directReferrer    = "(direct)" // 没有来源的浏览
+	maxReferrerLength = 255
+	maxUTMLength      = 100
+)
+
+// ErrPostPasswordMissing 密码保护的文章没有设置密码
+var ErrPostPasswordMissing = errors.New("password is required for password protected posts")
+
 // PostService 文章服务接口
 type PostService interface {
 	CreatePost(ctx context.Context, post *models.Post, tagNames []string) error
 	UpdatePost(ctx context.Context, post *models.Post, tagNames []string) error
 	DeletePost(ctx context.Context, id uint) error
+	RestorePost(ctx context.Context, id uint, ownerID *uint) error
+	ListTrashedPosts(ctx context.Context, ownerID *uint, page, pageSize int) ([]models.TrashedPost, int64, error)
 	GetPostByID(ctx context.Context, id uint) (*models.Post, error)
-	ListPosts(ctx context.Context, page, pageSize int, conditions map[string]interface{}) ([]models.Post, int64, error)
-	IncrementViewCount(ctx context.Context, id uint) error
-	ListPostsByCategory(ctx context.Context, categoryID uint, page, pageSize int) ([]models.Post, int64, error)
-	ListPostsByTag(ctx context.Context, tagID uint, page, pageSize int) ([]models.Post, int64, error)
-	ListPostsByUser(ctx context.Context, userID uint, page, pageSize int) ([]models.Post, int64, error)
+	// GetVisiblePost 按访问者的权限读取文章，无权查看的文章与不存在的文章一样返回 gorm.ErrRecordNotFound
+	GetVisiblePost(ctx context.Context, id uint, access *PostAccess) (*models.Post, error)
+	// ListPosts 按访问者的可见范围查询文章列表，viewer为nil表示匿名访客
+	ListPosts(ctx context.Context, page, pageSize int, query *models.PostQuery, viewer *Viewer) ([]models.Post, int64, error)
+	// ListPostsByCursor 按查询的排序方式游标分页查询文章列表，可见范围与 ListPosts 相同
+	ListPostsByCursor(ctx context.Context, query *models.PostQuery, viewer *Viewer, cursor *CursorQuery) (*PostCursorPage, error)
+	RecordView(ctx context.Context, id uint, view *ViewInfo) error
+	// AttachViewCount 以包含尚未写入数据库的实时浏览量替换文章的浏览量
+	AttachViewCount(ctx context.Context, post *models.Post) error
+	FlushViewCounts(ctx context.Context) error
+	RelatedPosts(ctx context.Context, id uint, limit int) ([]models.RelatedPost, error)
+	// Archive 返回按年月统计的已发布文章数
+	Archive(ctx context.Context) ([]models.ArchiveYear, error)
+	PinPost(ctx context.Context, id uint, order int, until *time.Time) error
+	UnpinPost(ctx context.Context, id uint) error
+	SetFeatured(ctx context.Context, id uint, featured bool) error
+	FeaturedPosts(ctx context.Context, limit int) ([]models.Post, error)
+	ExpirePins(ctx context.Context) (int, error)
 }
 
 type postService struct {
+	repos        mysql.Factory // 用于开启事务
 	postRepo     mysql.PostRepository
 	tagRepo      mysql.TagRepository
 	categoryRepo mysql.CategoryRepository
 	postCache    redis.PostCache
+	tagCache     redis.TagCache
+	commentCache redis.CommentCache
+	analytics    redis.AnalyticsCache
+}
+
+// ViewInfo 一次文章浏览的访客与来源信息
+type ViewInfo struct {
+	ClientIP  string
+	UserAgent string
+	Host      string // 本站域名，来自本站的跳转不计入来源统计
+	Referrer  string // 来源页面URL
+	Source    string // utm_source
+	Medium    string // utm_medium
+	Campaign  string // utm_campaign
 }
 
 // NewPostService 创建文章服务实例
@@ -36,145 +89,578 @@ func NewPostService(
 	postCache redis.PostCache,
 	tagRepo mysql.TagRepository,
 	categoryRepo mysql.CategoryRepository,
+	tagCache redis.TagCache,
+	analytics redis.AnalyticsCache,
+	repos mysql.Factory,
+	commentCache redis.CommentCache,
 ) PostService {
 	return &postService{
+		repos:        repos,
+		commentCache: commentCache,
 		postRepo:     postRepo,
 		postCache:    postCache,
 		tagRepo:      tagRepo,
 		categoryRepo: categoryRepo,
+		tagCache:     tagCache,
+		analytics:    analytics,
 	}
 }
 
 func (s *postService) CreatePost(ctx context.Context, post *models.Post, tagNames []string) error {
-	// 处理标签
-	if len(tagNames) > 0 {
-		tags, err := s.tagRepo.FindOrCreateByNames(tagNames)
+	ctx, span := tracing.Start(ctx, "PostService.CreatePost")
+	defer span.End()
+
+	// 设置时间
+	post.CreatedAt = time.Now()
+	post.UpdatedAt = time.Now()
+
+	if post.Visibility == "" {
+		post.Visibility = models.VisibilityPublic
+	}
+	if post.Status == models.PostStatusPublished && post.PublishedAt == nil {
+		post.PublishedAt = &post.CreatedAt
+	}
+	if post.Visibility == models.VisibilityPassword && post.Password == "" {
+		return ErrPostPasswordMissing
+	}
+	if err := hashPostPassword(post); err != nil {
+		return err
+	}
+
+	// 文章与标签在同一事务中写入，任一步失败都不会留下半成品
+	err := s.repos.Transaction(ctx, func(repos mysql.Factory) error {
+		if err := repos.GetPostRepository().Create(ctx, post); err != nil {
+			return err
+		}
+		tags, err := s.replaceTags(ctx, repos, post.ID, tagNames)
 		if err != nil {
 			return err
 		}
 		post.Tags = tags
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// 设置时间
-	post.CreatedAt = time.Now()
-	post.UpdatedAt = time.Now()
-
-	// 创建文章
-	if err := s.postRepo.Create(post); err != nil {
+	// 事务提交后再写入缓存
+	if err := s.postCache.Set(ctx, post); err != nil {
 		return err
 	}
+	if len(post.Tags) > 0 {
+		// 标签的文章数已变化
+		if err := s.tagCache.DeleteList(ctx); err != nil {
+			return err
+		}
+	}
 
-	// 写入缓存
-	return s.postCache.Set(ctx, post)
+	// 新文章会出现在列表中
+	return s.postCache.InvalidatePostLists(ctx)
 }
 
+// UpdatePost 更新文章，tagNames为nil时保留原有标签，为空切片时清空标签
 func (s *postService) UpdatePost(ctx context.Context, post *models.Post, tagNames []string) error {
-	// 处理标签
-	if len(tagNames) > 0 {
-		tags, err := s.tagRepo.FindOrCreateByNames(tagNames)
-		if err != nil {
+	ctx, span := tracing.Start(ctx, "PostService.UpdatePost")
+	defer span.End()
+
+	post.UpdatedAt = time.Now()
+	if err := hashPostPassword(post); err != nil {
+		return err
+	}
+
+	var updated *models.Post
+	err := s.repos.Transaction(ctx, func(repos mysql.Factory) error {
+		postRepo := repos.GetPostRepository()
+		if err := postRepo.Update(ctx, post); err != nil {
 			return err
 		}
-		post.Tags = tags
+		// 首次发布时记录发布时间
+		if err := postRepo.MarkPublished(ctx, post.ID, time.Now()); err != nil {
+			return err
+		}
+		// 在事务内读取更新后的完整文章，文章不存在时回滚
+		var err error
+		if updated, err = postRepo.FindByID(ctx, post.ID); err != nil {
+			return err
+		}
+		// 改为密码保护时可以沿用之前设置过的密码
+		if updated.Visibility == models.VisibilityPassword && updated.PasswordHash == "" {
+			return ErrPostPasswordMissing
+		}
+		if tagNames != nil {
+			if updated.Tags, err = s.replaceTags(ctx, repos, post.ID, tagNames); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	*post = *updated
 
-	post.UpdatedAt = time.Now()
+	// 事务提交后再更新缓存
+	if err := s.postCache.Set(ctx, post); err != nil {
+		return err
+	}
+	if err := s.tagCache.DeletePostTags(ctx, post.ID); err != nil {
+		return err
+	}
+	if tagNames != nil {
+		if err := s.tagCache.DeleteList(ctx); err != nil {
+			return err
+		}
+	}
 
-	// 更新文章
-	if err := s.postRepo.Update(post); err != nil {
+	// 清除依赖该文章的列表缓存
+	if err := s.postCache.InvalidatePostLists(ctx); err != nil {
 		return err
 	}
 
-	// 更新缓存
-	return s.postCache.Set(ctx, post)
+	// 标签或内容可能已变化，立即按数据库中的最新数据重新计算该文章的相关文章
+	version, err := s.postCache.PostListVersion(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = s.computeRelated(ctx, version, updated)
+	return err
 }
 
-func (s *postService) DeletePost(ctx context.Context, id uint) error {
-	// 删除文章
-	if err := s.postRepo.Delete(id); err != nil {
+// hashPostPassword 将文章的明文访问密码哈希后保存，未传入密码时保留原密码
+func hashPostPassword(post *models.Post) error {
+	if post.Password == "" {
+		return nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(post.Password), bcrypt.DefaultCost)
+	if err != nil {
 		return err
 	}
-
-	// 删除缓存
-	return s.postCache.Delete(ctx, id)
+	post.PasswordHash = string(hash)
+	post.Password = ""
+	return nil
 }
 
-func (s *postService) GetPostByID(ctx context.Context, id uint) (*models.Post, error) {
-	// 先从缓存获取
-	post, err := s.postCache.Get(ctx, id)
+// replaceTags 查找或创建标签，并将文章的标签精确替换为这些标签
+func (s *postService) replaceTags(ctx context.Context, repos mysql.Factory, postID uint, tagNames []string) ([]models.Tag, error) {
+	tags, err := repos.GetTagRepository().FindOrCreateByNames(ctx, tagNames)
 	if err != nil {
 		return nil, err
 	}
-	if post != nil {
-		return post, nil
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+	ids := make([]uint, 0, len(tags))
+	for _, tag := range tags {
+		ids = append(ids, tag.ID)
+	}
+	if err := repos.GetPostRepository().ReplaceTags(ctx, postID, ids); err != nil {
+		return nil, err
 	}
+	return tags, nil
+}
+
+// DeletePost 将文章及其评论移入回收站
+func (s *postService) DeletePost(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "PostService.DeletePost")
+	defer span.End()
 
-	// 缓存未命中，从数据库获取
-	post, err = s.postRepo.FindByID(id)
+	// 评论使用与文章相同的删除时间，恢复文章时据此只恢复随文章删除的评论
+	at := trashTime()
+	var comments []models.Comment
+	err := s.repos.Transaction(ctx, func(repos mysql.Factory) error {
+		if err := repos.GetPostRepository().Trash(ctx, id, at); err != nil {
+			return err
+		}
+		var err error
+		comments, err = repos.GetCommentRepository().TrashByPost(ctx, id, at)
+		return err
+	})
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// 写入缓存
-	if err := s.postCache.Set(ctx, post); err != nil {
+	return s.invalidateTrashed(ctx, id, comments)
+}
+
+// RestorePost 从回收站恢复文章及随文章删除的评论，ownerID非空时只能恢复该用户的文章
+func (s *postService) RestorePost(ctx context.Context, id uint, ownerID *uint) error {
+	ctx, span := tracing.Start(ctx, "PostService.RestorePost")
+	defer span.End()
+
+	var comments []models.Comment
+	err := s.repos.Transaction(ctx, func(repos mysql.Factory) error {
+		post, err := repos.GetPostRepository().Restore(ctx, id, ownerID)
+		if err != nil {
+			return err
+		}
+		comments, err = repos.GetCommentRepository().RestoreByPost(ctx, id, post.DeletedAt.Time)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// 删除文章缓存同时清除删除期间写入的负缓存
+	return s.invalidateTrashed(ctx, id, comments)
+}
+
+func (s *postService) ListTrashedPosts(ctx context.Context, ownerID *uint, page, pageSize int) ([]models.TrashedPost, int64, error) {
+	ctx, span := tracing.Start(ctx, "PostService.ListTrashedPosts")
+	defer span.End()
+
+	posts, total, err := s.postRepo.ListTrashed(ctx, ownerID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]models.TrashedPost, 0, len(posts))
+	for _, post := range posts {
+		items = append(items, models.TrashedPost{
+			ID:         post.ID,
+			Title:      post.Title,
+			Status:     post.Status,
+			UserID:     post.UserID,
+			CategoryID: post.CategoryID,
+			DeletedAt:  post.DeletedAt.Time,
+			PurgeAt:    purgeTime(post.DeletedAt.Time),
+		})
+	}
+	return items, total, nil
+}
+
+// invalidateTrashed 文章移入或移出回收站后清除文章、标签、评论和列表缓存
+func (s *postService) invalidateTrashed(ctx context.Context, id uint, comments []models.Comment) error {
+	if err := s.postCache.Delete(ctx, id); err != nil {
+		return err
+	}
+	if err := s.tagCache.DeletePostTags(ctx, id); err != nil {
+		return err
+	}
+	// 标签的文章数已变化
+	if err := s.tagCache.DeleteList(ctx); err != nil {
+		return err
+	}
+	if err := invalidateComments(ctx, s.commentCache, comments); err != nil {
+		return err
+	}
+
+	// 清除依赖该文章的列表缓存
+	return s.postCache.InvalidatePostLists(ctx)
+}
+
+func (s *postService) GetPostByID(ctx context.Context, id uint) (*models.Post, error) {
+	ctx, span := tracing.Start(ctx, "PostService.GetPostByID")
+	defer span.End()
+
+	// 缓存未命中时从数据库加载，并发请求合并为一次查询，不存在的记录会写入负缓存
+	return s.postCache.GetOrLoad(ctx, id, func(ctx context.Context) (*models.Post, error) {
+		return s.postRepo.FindByID(ctx, id)
+	})
+}
+
+func (s *postService) GetVisiblePost(ctx context.Context, id uint, access *PostAccess) (*models.Post, error) {
+	ctx, span := tracing.Start(ctx, "PostService.GetVisiblePost")
+	defer span.End()
+
+	post, err := s.GetPostByID(ctx, id)
+	if err != nil {
 		return nil, err
 	}
+	// 作者、编辑、管理员和持有预览链接的人不受状态和可见性限制
+	if access.Viewer.owns(post) || access.Preview {
+		return post, nil
+	}
+	if post.Status != models.PostStatusPublished {
+		return nil, gorm.ErrRecordNotFound
+	}
 
+	if post.Visibility == models.VisibilityPassword {
+		if access.Password == "" {
+			return nil, ErrPostPasswordRequired
+		}
+		// 密码哈希不写入缓存，校验时单独查询
+		hash, err := s.postRepo.PasswordHash(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(access.Password)) != nil {
+			return nil, ErrPostPasswordRequired
+		}
+	}
 	return post, nil
 }
 
-func (s *postService) ListPosts(ctx context.Context, page, pageSize int, conditions map[string]interface{}) ([]models.Post, int64, error) {
-	// 生成缓存key
-	cacheKey := "posts:list:" + utils.GenerateCacheKey(conditions, page, pageSize)
+func (s *postService) ListPosts(ctx context.Context, page, pageSize int, query *models.PostQuery, viewer *Viewer) ([]models.Post, int64, error) {
+	ctx, span := tracing.Start(ctx, "PostService.ListPosts")
+	defer span.End()
+
+	// 可见范围作为查询条件的一部分，不同范围的访问者使用不同的缓存
+	q, err := s.prepareQuery(ctx, query, viewer)
+	if err != nil || q == nil {
+		return []models.Post{}, 0, err
+	}
+
+	// 先读取版本号再查询数据库，查询期间发生的失效不会让旧数据写入新版本
+	version, err := s.postCache.PostListVersion(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	cacheKey := postQueryKey(q, page, pageSize)
 
 	// 尝试从缓存获取
-	posts, err := s.postCache.GetPostList(ctx, cacheKey)
+	cached, err := s.postCache.GetPostList(ctx, version, cacheKey)
 	if err != nil {
 		return nil, 0, err
 	}
-	if len(posts) > 0 {
-		return posts, int64(len(posts)), nil
+	if cached != nil {
+		return lockPosts(cached.Posts, viewer), cached.Total, nil
 	}
 
 	// 从数据库获取
-	posts, total, err := s.postRepo.List(page, pageSize, conditions)
+	posts, total, err := s.postRepo.List(ctx, page, pageSize, q)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// 写入缓存
-	if err := s.postCache.SetPostList(ctx, cacheKey, posts); err != nil {
+	if err := s.postCache.SetPostList(ctx, version, cacheKey, &redis.PostListPage{Posts: posts, Total: total}); err != nil {
 		return nil, 0, err
 	}
 
-	return posts, total, nil
+	return lockPosts(posts, viewer), total, nil
 }
 
-func (s *postService) IncrementViewCount(ctx context.Context, id uint) error {
-	// 增加缓存中的计数
-	count, err := s.postCache.IncrViewCount(ctx, id)
+func (s *postService) ListPostsByCursor(ctx context.Context, query *models.PostQuery, viewer *Viewer, cursor *CursorQuery) (*PostCursorPage, error) {
+	ctx, span := tracing.Start(ctx, "PostService.ListPostsByCursor")
+	defer span.End()
+
+	after, err := utils.DecodeCursor(cursor.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	q, err := s.prepareQuery(ctx, query, viewer)
+	if err != nil {
+		return nil, err
+	}
+	if q == nil {
+		page := &PostCursorPage{Posts: []models.Post{}}
+		if cursor.Count != CountNone {
+			page.Total = new(int64)
+		}
+		return page, nil
+	}
+
+	// 与页码分页共用列表缓存的版本号，任意文章变更后失效
+	version, err := s.postCache.PostListVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := postQueryKey(q, "cursor", cursor.Cursor, cursor.Limit)
+
+	cached, err := s.postCache.GetPostList(ctx, version, cacheKey)
+	if err != nil {
+		return nil, err
+	}
+	if cached == nil {
+		// 多取一条判断是否还有下一页
+		posts, err := s.postRepo.ListByCursor(ctx, q, after, cursor.Limit+1)
+		if err != nil {
+			return nil, err
+		}
+		fetched := len(posts)
+		if fetched > cursor.Limit {
+			posts = posts[:cursor.Limit]
+		}
+		cached = &redis.PostListPage{Posts: posts}
+		if len(posts) > 0 {
+			cached.NextCursor = nextCursor(fetched, cursor.Limit, mysql.PostCursor(q, &posts[len(posts)-1]))
+		}
+		if err := s.postCache.SetPostList(ctx, version, cacheKey, cached); err != nil {
+			return nil, err
+		}
+	}
+
+	page := &PostCursorPage{Posts: lockPosts(cached.Posts, viewer), NextCursor: cached.NextCursor}
+	countKey := postQueryKey(q)
+	page.Total, err = cursorCount(ctx, cursor.Count,
+		func(ctx context.Context) (int64, error) { return s.postRepo.Count(ctx, q) },
+		func(ctx context.Context) (int64, bool, error) { return s.postCache.GetListCount(ctx, countKey) },
+		func(ctx context.Context, total int64) error { return s.postCache.SetListCount(ctx, countKey, total) },
+	)
 	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// prepareQuery 复制查询条件，加入访问者的可见范围，并将标签名称或别名解析为标签ID
+// 标签条件不可能满足时（标签都不存在，或要求同时包含的标签中有不存在的）返回nil
+func (s *postService) prepareQuery(ctx context.Context, query *models.PostQuery, viewer *Viewer) (*models.PostQuery, error) {
+	q := *query
+	scopeQuery(&q, viewer)
+	if len(query.Tags) == 0 {
+		return &q, nil
+	}
+
+	seen := make(map[uint]bool, len(query.Tags))
+	q.Tags, q.TagIDs = nil, nil
+	for _, name := range query.Tags {
+		tag, err := s.tagRepo.FindByKey(ctx, utils.TagKey(name))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if query.MatchAllTags {
+				return nil, nil
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag.ID] {
+			seen[tag.ID] = true
+			q.TagIDs = append(q.TagIDs, tag.ID)
+		}
+	}
+	if len(q.TagIDs) == 0 {
+		return nil, nil
+	}
+	// 同一组标签的不同写法和顺序使用相同的缓存
+	sort.Slice(q.TagIDs, func(i, j int) bool { return q.TagIDs[i] < q.TagIDs[j] })
+	return &q, nil
+}
+
+// postQueryKey 由查询条件和分页参数生成列表缓存的key
+func postQueryKey(q *models.PostQuery, args ...interface{}) string {
+	data, _ := json.Marshal(q)
+	return utils.GenerateCacheKey(nil, append([]interface{}{string(data)}, args...)...)
+}
+
+// RecordView 记录一次文章浏览
+// 爬虫不计数，同一访客在 view.dedup_window 内重复浏览只计一次；浏览量和统计数据先累加在缓存中，由后台任务定期写入数据库
+func (s *postService) RecordView(ctx context.Context, id uint, view *ViewInfo) error {
+	ctx, span := tracing.Start(ctx, "PostService.RecordView")
+	defer span.End()
+
+	if utils.IsBot(view.UserAgent) {
+		return nil
+	}
+
+	if window := time.Duration(config.Get().View.DedupWindow) * time.Second; window > 0 {
+		first, err := s.postCache.MarkViewed(ctx, id, visitorKey(view.ClientIP, view.UserAgent), window)
+		if err != nil {
+			return err
+		}
+		if !first {
+			return nil
+		}
+	}
+
+	if err := s.postCache.IncrViewCount(ctx, id); err != nil {
 		return err
 	}
+	return s.analytics.RecordHit(ctx, &redis.AnalyticsHit{
+		PostID:   id,
+		Time:     time.Now(),
+		Referrer: referrerDomain(view.Referrer, view.Host),
+		Source:   utmValue(view.Source),
+		Medium:   utmValue(view.Medium),
+		Campaign: utmValue(view.Campaign),
+	})
+}
+
+// AttachViewCount 文章详情缓存中的浏览量只在加载时从数据库读取，返回前替换为实时浏览量
+func (s *postService) AttachViewCount(ctx context.Context, post *models.Post) error {
+	ctx, span := tracing.Start(ctx, "PostService.AttachViewCount")
+	defer span.End()
 
-	// 定期同步到数据库
-	if count%10 == 0 { // 每10次访问同步一次
-		if err := s.postRepo.IncrementViewCount(id); err != nil {
+	count, ok, err := s.postCache.GetViewCount(ctx, post.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// 缓存的文章详情可能早于最近一次写库，重建时从数据库读取当前的浏览量
+		stored, err := s.postRepo.ViewCount(ctx, post.ID)
+		if err != nil {
+			return err
+		}
+		if count, err = s.postCache.SeedViewCount(ctx, post.ID, stored); err != nil {
 			return err
 		}
 	}
+	post.ViewCount = count
+	return nil
+}
+
+// FlushViewCounts 将缓存中尚未同步的浏览量写入数据库
+// 由后台任务定期调用，进程退出前也会调用一次
+func (s *postService) FlushViewCounts(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "PostService.FlushViewCounts")
+	defer span.End()
 
+	// 第一轮可能取到上次未完成的批次，第二轮再处理当前的待同步计数
+	for i := 0; i < 2; i++ {
+		batch, err := s.postCache.BeginViewFlush(ctx, newViewBatchID())
+		if err != nil {
+			return err
+		}
+		if batch == nil {
+			return nil
+		}
+
+		// 数据库按批次ID去重，写库成功但删除批次前退出时，重启后重放该批次不会重复计数
+		if err := s.postRepo.ApplyViewCounts(ctx, batch.ID, batch.Counts); err != nil {
+			return err
+		}
+		if err := s.postCache.EndViewFlush(ctx, batch); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func (s *postService) ListPostsByCategory(ctx context.Context, categoryID uint, page, pageSize int) ([]models.Post, int64, error) {
-	return s.postRepo.ListByCategoryID(categoryID, page, pageSize)
+// visitorKey 根据IP和User-Agent生成访客标识，不直接保存IP
+func visitorKey(clientIP, userAgent string) string {
+	sum := sha256.Sum256([]byte(clientIP + "|" + userAgent))
+	return hex.EncodeToString(sum[:12])
 }
 
-func (s *postService) ListPostsByTag(ctx context.Context, tagID uint, page, pageSize int) ([]models.Post, int64, error) {
-	return s.postRepo.ListByTagID(tagID, page, pageSize)
+// newViewBatchID 生成浏览量批次ID
+func newViewBatchID() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return fmt.Sprintf("%d-%s", time.Now().UnixNano(), hex.EncodeToString(buf))
 }
 
-func (s *postService) ListPostsByUser(ctx context.Context, userID uint, page, pageSize int) ([]models.Post, int64, error) {
-	return s.postRepo.ListByUserID(userID, page, pageSize)
+// referrerDomain 提取来源域名，没有来源时返回 (direct)，来自本站的跳转返回空字符串不计入统计
+func referrerDomain(referrer, host string) string {
+	if referrer == "" {
+		return directReferrer
+	}
+	u, err := url.Parse(referrer)
+	if err != nil || u.Hostname() == "" {
+		return directReferrer
+	}
+	domain := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if host != "" {
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if strings.TrimPrefix(strings.ToLower(host), "www.") == domain {
+			return ""
+		}
+	}
+	return truncate(domain, maxReferrerLength)
+}
+
+// utmValue 规范化UTM参数
+func utmValue(v string) string {
+	return truncate(strings.ToLower(strings.TrimSpace(v)), maxUTMLength)
+}
+
+// truncate 按字符截断，与数据库列的长度限制一致
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
 }
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/personal-blog/config"
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
+	"github.com/personal-blog/repository/mysql"
+	"github.com/personal-blog/repository/redis"
+)
+
+// ErrInvalidReactionType 未启用的反应类型
+var ErrInvalidReactionType = errors.New("invalid reaction type")
+
+// Reactor 做出反应的访问者：登录用户按用户ID去重，匿名访客按访客标识去重
+type Reactor struct {
+	UserID  uint
+	Visitor string
+}
+
+// ReactionService 文章反应服务接口
+// 调用方负责确认访问者可以看到该文章
+type ReactionService interface {
+	React(ctx context.Context, postID uint, reactionType string, reactor *Reactor) (*models.ReactionState, error)
+	Unreact(ctx context.Context, postID uint, reactionType string, reactor *Reactor) (*models.ReactionState, error)
+	State(ctx context.Context, postID uint, reactor *Reactor) (*models.ReactionState, error)
+	// Counts 返回文章的实时反应总数，结果包含所有启用的反应类型
+	Counts(ctx context.Context, postIDs []uint) (map[uint]map[string]int64, error)
+	ListReactedPosts(ctx context.Context, userID uint, reactionType string, page, pageSize int) ([]models.Post, int64, error)
+	FlushCounts(ctx context.Context) error
+}
+
+type reactionService struct {
+	reactionRepo  mysql.ReactionRepository
+	reactionCache redis.ReactionCache
+}
+
+// NewReactionService 创建文章反应服务实例
+func NewReactionService(reactionRepo mysql.ReactionRepository, reactionCache redis.ReactionCache) ReactionService {
+	return &reactionService{
+		reactionRepo:  reactionRepo,
+		reactionCache: reactionCache,
+	}
+}
+
+// React 做出反应，已做出过同类反应时不重复计数
+func (s *reactionService) React(ctx context.Context, postID uint, reactionType string, reactor *Reactor) (*models.ReactionState, error) {
+	ctx, span := tracing.Start(ctx, "ReactionService.React")
+	defer span.End()
+
+	if !reactionEnabled(reactionType) {
+		return nil, ErrInvalidReactionType
+	}
+
+	var added bool
+	var err error
+	if reactor.UserID != 0 {
+		added, err = s.reactionRepo.Add(ctx, &models.PostReaction{PostID: postID, UserID: reactor.UserID, Type: reactionType})
+	} else {
+		ttl := time.Duration(config.Get().Reaction.AnonymousTTL) * time.Second
+		added, err = s.reactionCache.MarkReacted(ctx, postID, reactionType, reactor.Visitor, ttl)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if added {
+		if err := s.reactionCache.Incr(ctx, postID, reactionType, 1); err != nil {
+			return nil, err
+		}
+	}
+	return s.State(ctx, postID, reactor)
+}
+
+// Unreact 撤销反应，没有做出过该反应时不改变计数
+func (s *reactionService) Unreact(ctx context.Context, postID uint, reactionType string, reactor *Reactor) (*models.ReactionState, error) {
+	ctx, span := tracing.Start(ctx, "ReactionService.Unreact")
+	defer span.End()
+
+	if !reactionEnabled(reactionType) {
+		return nil, ErrInvalidReactionType
+	}
+
+	var removed bool
+	var err error
+	if reactor.UserID != 0 {
+		removed, err = s.reactionRepo.Remove(ctx, postID, reactor.UserID, reactionType)
+	} else {
+		removed, err = s.reactionCache.UnmarkReacted(ctx, postID, reactionType, reactor.Visitor)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if removed {
+		if err := s.reactionCache.Incr(ctx, postID, reactionType, -1); err != nil {
+			return nil, err
+		}
+	}
+	return s.State(ctx, postID, reactor)
+}
+
+// State 返回文章的反应总数以及访问者已做出的反应
+func (s *reactionService) State(ctx context.Context, postID uint, reactor *Reactor) (*models.ReactionState, error) {
+	ctx, span := tracing.Start(ctx, "ReactionService.State")
+	defer span.End()
+
+	counts, err := s.Counts(ctx, []uint{postID})
+	if err != nil {
+		return nil, err
+	}
+
+	var reacted []string
+	if reactor.UserID != 0 {
+		reacted, err = s.reactionRepo.ListTypes(ctx, postID, reactor.UserID)
+	} else {
+		reacted, err = s.reactionCache.ReactedTypes(ctx, postID, reactor.Visitor, reactionTypes())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &models.ReactionState{Counts: counts[postID], Reacted: reacted}, nil
+}
+
+// Counts 返回文章的实时反应总数，缓存中没有的文章从数据库加载
+func (s *reactionService) Counts(ctx context.Context, postIDs []uint) (map[uint]map[string]int64, error) {
+	ctx, span := tracing.Start(ctx, "ReactionService.Counts")
+	defer span.End()
+
+	counts, err := s.reactionCache.GetCounts(ctx, postIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 缓存中没有的文章从数据库加载，并计入尚未写入数据库的增量
+	var missing []uint
+	for _, id := range postIDs {
+		if _, ok := counts[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		stored, err := s.reactionRepo.Counts(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range missing {
+			base := make(map[string]int64)
+			for _, reactionType := range reactionTypes() {
+				base[reactionType] = 0
+			}
+			for reactionType, n := range stored[id] {
+				base[reactionType] = n
+			}
+			if counts[id], err = s.reactionCache.SeedCounts(ctx, id, base); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 只返回当前启用的反应类型，配置变更后新增的类型补0
+	result := make(map[uint]map[string]int64, len(postIDs))
+	for _, id := range postIDs {
+		result[id] = make(map[string]int64)
+		for _, reactionType := range reactionTypes() {
+			result[id][reactionType] = counts[id][reactionType]
+		}
+	}
+	return result, nil
+}
+
+func (s *reactionService) ListReactedPosts(ctx context.Context, userID uint, reactionType string, page, pageSize int) ([]models.Post, int64, error) {
+	ctx, span := tracing.Start(ctx, "ReactionService.ListReactedPosts")
+	defer span.End()
+
+	if !reactionEnabled(reactionType) {
+		return nil, 0, ErrInvalidReactionType
+	}
+	posts, total, err := s.reactionRepo.ListReactedPosts(ctx, userID, reactionType, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	return lockPosts(posts, &Viewer{UserID: userID}), total, nil
+}
+
+// FlushCounts 将缓存中尚未同步的反应数写入数据库
+// 由后台任务定期调用，进程退出前也会调用一次
+func (s *reactionService) FlushCounts(ctx context.Context) error {
+	ctx, span := tracing.Start(ctx, "ReactionService.FlushCounts")
+	defer span.End()
+
+	// 第一轮可能取到上次未完成的批次，第二轮再处理当前的待同步数据
+	for i := 0; i < 2; i++ {
+		batch, err := s.reactionCache.BeginFlush(ctx, "reaction-"+newViewBatchID())
+		if err != nil {
+			return err
+		}
+		if batch == nil {
+			return nil
+		}
+
+		if err := s.reactionRepo.ApplyCounts(ctx, batch.ID, batch.Counts); err != nil {
+			return err
+		}
+		if err := s.reactionCache.EndFlush(ctx, batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reactionTypes 返回启用的反应类型，like 始终排在第一个
+func reactionTypes() []string {
+	types := []string{models.ReactionLike}
+	for _, t := range config.Get().Reaction.Types {
+		if t != models.ReactionLike {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+func reactionEnabled(reactionType string) bool {
+	for _, t := range reactionTypes() {
+		if t == reactionType {
+			return true
+		}
+	}
+	return false
+}
+
+// NewVisitor 为没有访客Cookie的匿名访客生成标识，返回标识和写入Cookie的令牌
+// 标识由IP和User-Agent生成，清除Cookie后仍能识别为同一访客；Cookie保证IP变化后标识不变
+func NewVisitor(clientIP, userAgent string) (string, string) {
+	visitor := visitorKey(clientIP, userAgent)
+	return visitor, visitor + "." + signVisitor(visitor)
+}
+
+// VerifyVisitor 校验访客Cookie中的令牌，返回其中的访客标识
+func VerifyVisitor(token string) (string, bool) {
+	visitor, sig, ok := strings.Cut(token, ".")
+	if !ok || visitor == "" {
+		return "", false
+	}
+	return visitor, hmac.Equal([]byte(sig), []byte(signVisitor(visitor)))
+}
+
+// signVisitor 使用JWT密钥对访客标识签名，防止伪造其他访客的标识
+func signVisitor(visitor string) string {
+	mac := hmac.New(sha256.New, []byte(config.Get().JWT.Secret))
+	mac.Write([]byte("reaction-visitor:" + visitor))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
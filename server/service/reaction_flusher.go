@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/personal-blog/config"
+)
+
+// reactionFlushWorker 定期将缓存中的反应数写入数据库
+type reactionFlushWorker struct {
+	reactionService ReactionService
+}
+
+func newReactionFlushWorker(reactionService ReactionService) Worker {
+	return &reactionFlushWorker{reactionService: reactionService}
+}
+
+func (w *reactionFlushWorker) Name() string {
+	return "reaction-flusher"
+}
+
+func (w *reactionFlushWorker) Run(ctx context.Context) {
+	for {
+		interval := time.Duration(config.Get().Reaction.FlushInterval) * time.Second
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		if err := w.reactionService.FlushCounts(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("worker %s: flush reaction counts: %v", w.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
+	"github.com/personal-blog/pkg/utils"
+)
+
+const (
+	relatedCandidateLimit = 500 // 参与推荐计算的最近文章数
+	relatedCacheSize      = 20  // 每篇文章缓存的相关文章数，也是单次请求的上限
+
+	// 相关度 = 共同标签数 * relatedTagWeight + 同分类 * relatedCategoryWeight + 内容相似度 * relatedContentWeight
+	relatedTagWeight      = 2.0
+	relatedCategoryWeight = 1.0
+	relatedContentWeight  = 3.0
+)
+
+// RelatedPosts 返回与文章最相关的limit篇文章
+// 结果按文章缓存，任意文章新增、修改或删除后失效，UpdatePost 会立即为被修改的文章重新计算
+func (s *postService) RelatedPosts(ctx context.Context, id uint, limit int) ([]models.RelatedPost, error) {
+	ctx, span := tracing.Start(ctx, "PostService.RelatedPosts")
+	defer span.End()
+
+	version, err := s.postCache.PostListVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	related, err := s.postCache.GetRelated(ctx, version, id)
+	if err != nil {
+		return nil, err
+	}
+	if related == nil {
+		post, err := s.GetPostByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if related, err = s.computeRelated(ctx, version, post); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(related) > limit {
+		related = related[:limit]
+	}
+	return related, nil
+}
+
+// computeRelated 计算文章的相关文章并写入指定版本的缓存
+func (s *postService) computeRelated(ctx context.Context, version int64, post *models.Post) ([]models.RelatedPost, error) {
+	candidates, err := s.postRepo.ListRelatedCandidates(ctx, post.ID, relatedCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	related := scoreRelated(post, candidates, relatedCacheSize)
+	if err := s.postCache.SetRelated(ctx, version, post.ID, related); err != nil {
+		return nil, err
+	}
+	return related, nil
+}
+
+// scoreRelated 按共同标签、分类和标题摘要的TF-IDF相似度为候选文章打分，返回得分最高的limit篇
+func scoreRelated(post *models.Post, candidates []models.Post, limit int) []models.RelatedPost {
+	// 以目标文章和全部候选文章作为语料计算IDF
+	docs := make([][]string, 0, len(candidates)+1)
+	docs = append(docs, utils.Tokenize(post.Title+" "+post.Summary))
+	for _, p := range candidates {
+		docs = append(docs, utils.Tokenize(p.Title+" "+p.Summary))
+	}
+	vectors := utils.TFIDF(docs)
+
+	tags := make(map[uint]bool, len(post.Tags))
+	for _, t := range post.Tags {
+		tags[t.ID] = true
+	}
+
+	related := make([]models.RelatedPost, 0, len(candidates))
+	for i, p := range candidates {
+		var score float64
+		for _, t := range p.Tags {
+			if tags[t.ID] {
+				score += relatedTagWeight
+			}
+		}
+		if post.CategoryID != 0 && p.CategoryID == post.CategoryID {
+			score += relatedCategoryWeight
+		}
+		score += relatedContentWeight * utils.CosineSimilarity(vectors[0], vectors[i+1])
+		if score <= 0 {
+			continue
+		}
+
+		related = append(related, models.RelatedPost{
+			PostID:  p.ID,
+			Title:   p.Title,
+			Summary: p.Summary,
+			Cover:   p.Cover,
+			Score:   score,
+		})
+	}
+
+	// 得分相同时较新的文章优先，候选文章已按发布时间倒序排列
+	sort.SliceStable(related, func(i, j int) bool {
+		return related[i].Score > related[j].Score
+	})
+	if len(related) > limit {
+		related = related[:limit]
+	}
+	return related
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
+	"github.com/personal-blog/repository/mysql"
+	"github.com/personal-blog/repository/redis"
+	"gorm.io/gorm"
+)
+
+// slugPattern 系列slug只允许小写字母、数字和连字符
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// SeriesService 系列服务接口
+type SeriesService interface {
+	CreateSeries(ctx context.Context, series *models.Series) error
+	UpdateSeries(ctx context.Context, series *models.Series) error
+	DeleteSeries(ctx context.Context, id uint) error
+	GetSeries(ctx context.Context, id uint, includeDrafts bool) (*models.Series, error)
+	GetSeriesBySlug(ctx context.Context, slug string, includeDrafts bool) (*models.Series, error)
+	ListSeries(ctx context.Context, page, pageSize int) ([]models.Series, int64, error)
+	SetSeriesPosts(ctx context.Context, id uint, postIDs []uint) error
+	Navigation(ctx context.Context, postID uint) (*models.SeriesNavigation, error)
+}
+
+type seriesService struct {
+	seriesRepo mysql.SeriesRepository
+	postCache  redis.PostCache
+}
+
+// NewSeriesService 创建系列服务实例
+func NewSeriesService(seriesRepo mysql.SeriesRepository, postCache redis.PostCache) SeriesService {
+	return &seriesService{
+		seriesRepo: seriesRepo,
+		postCache:  postCache,
+	}
+}
+
+func (s *seriesService) CreateSeries(ctx context.Context, series *models.Series) error {
+	ctx, span := tracing.Start(ctx, "SeriesService.CreateSeries")
+	defer span.End()
+
+	if err := s.checkSlug(ctx, series); err != nil {
+		return err
+	}
+
+	series.CreatedAt = time.Now()
+	series.UpdatedAt = time.Now()
+	return s.seriesRepo.Create(ctx, series)
+}
+
+func (s *seriesService) UpdateSeries(ctx context.Context, series *models.Series) error {
+	ctx, span := tracing.Start(ctx, "SeriesService.UpdateSeries")
+	defer span.End()
+
+	if err := s.checkSlug(ctx, series); err != nil {
+		return err
+	}
+
+	series.UpdatedAt = time.Now()
+	if err := s.seriesRepo.Update(ctx, series); err != nil {
+		return err
+	}
+
+	// 文章详情中的系列导航包含系列标题
+	return s.postCache.InvalidatePostLists(ctx)
+}
+
+func (s *seriesService) DeleteSeries(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "SeriesService.DeleteSeries")
+	defer span.End()
+
+	if err := s.seriesRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	return s.postCache.InvalidatePostLists(ctx)
+}
+
+// GetSeries 获取系列及其文章，includeDrafts为false时只返回已发布的文章
+func (s *seriesService) GetSeries(ctx context.Context, id uint, includeDrafts bool) (*models.Series, error) {
+	ctx, span := tracing.Start(ctx, "SeriesService.GetSeries")
+	defer span.End()
+
+	series, err := s.seriesRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return series, s.loadEntries(ctx, series, includeDrafts)
+}
+
+func (s *seriesService) GetSeriesBySlug(ctx context.Context, slug string, includeDrafts bool) (*models.Series, error) {
+	ctx, span := tracing.Start(ctx, "SeriesService.GetSeriesBySlug")
+	defer span.End()
+
+	series, err := s.seriesRepo.FindBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return series, s.loadEntries(ctx, series, includeDrafts)
+}
+
+func (s *seriesService) ListSeries(ctx context.Context, page, pageSize int) ([]models.Series, int64, error) {
+	ctx, span := tracing.Start(ctx, "SeriesService.ListSeries")
+	defer span.End()
+
+	return s.seriesRepo.List(ctx, page, pageSize)
+}
+
+// SetSeriesPosts 按postIDs的顺序设置系列中的文章，用于添加、移除和调整顺序
+func (s *seriesService) SetSeriesPosts(ctx context.Context, id uint, postIDs []uint) error {
+	ctx, span := tracing.Start(ctx, "SeriesService.SetSeriesPosts")
+	defer span.End()
+
+	seen := make(map[uint]bool, len(postIDs))
+	for _, postID := range postIDs {
+		if seen[postID] {
+			return errors.New("duplicate post in series")
+		}
+		seen[postID] = true
+	}
+
+	if _, err := s.seriesRepo.FindByID(ctx, id); err != nil {
+		return err
+	}
+	if err := s.seriesRepo.SetPosts(ctx, id, postIDs); err != nil {
+		return err
+	}
+
+	// 系列中文章的导航都已变化
+	return s.postCache.InvalidatePostLists(ctx)
+}
+
+// Navigation 返回文章所在系列的导航，文章不属于任何系列时返回nil
+// 只计算已发布的文章，结果缓存在文章列表命名空间下，文章或系列变更后失效
+func (s *seriesService) Navigation(ctx context.Context, postID uint) (*models.SeriesNavigation, error) {
+	ctx, span := tracing.Start(ctx, "SeriesService.Navigation")
+	defer span.End()
+
+	version, err := s.postCache.PostListVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if nav, ok, err := s.postCache.GetSeriesNav(ctx, version, postID); err != nil || ok {
+		return nav, err
+	}
+
+	nav, err := s.buildNavigation(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.postCache.SetSeriesNav(ctx, version, postID, nav); err != nil {
+		return nil, err
+	}
+	return nav, nil
+}
+
+func (s *seriesService) buildNavigation(ctx context.Context, postID uint) (*models.SeriesNavigation, error) {
+	series, err := s.seriesRepo.FindByPostID(ctx, postID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.loadEntries(ctx, series, false); err != nil {
+		return nil, err
+	}
+
+	for i, entry := range series.Posts {
+		if entry.PostID != postID {
+			continue
+		}
+		nav := &models.SeriesNavigation{
+			SeriesID: series.ID,
+			Title:    series.Title,
+			Slug:     series.Slug,
+			Position: i + 1,
+			Total:    len(series.Posts),
+		}
+		if i > 0 {
+			prev := series.Posts[i-1]
+			nav.Prev = &prev
+		}
+		if i+1 < len(series.Posts) {
+			next := series.Posts[i+1]
+			nav.Next = &next
+		}
+		return nav, nil
+	}
+	// 文章未发布，不显示导航
+	return nil, nil
+}
+
+// loadEntries 加载系列中的文章，Position 按可见文章重新编号
+func (s *seriesService) loadEntries(ctx context.Context, series *models.Series, includeDrafts bool) error {
+	entries, err := s.seriesRepo.ListEntries(ctx, series.ID)
+	if err != nil {
+		return err
+	}
+
+	series.Posts = make([]models.SeriesEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !includeDrafts && entry.Status != models.PostStatusPublished {
+			continue
+		}
+		entry.Position = len(series.Posts) + 1
+		series.Posts = append(series.Posts, entry)
+	}
+	return nil
+}
+
+// checkSlug 校验slug格式并检查是否与其他系列重复
+func (s *seriesService) checkSlug(ctx context.Context, series *models.Series) error {
+	if !slugPattern.MatchString(series.Slug) {
+		return errors.New("invalid slug")
+	}
+	existing, err := s.seriesRepo.FindBySlug(ctx, series.Slug)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if existing != nil && existing.ID != series.ID {
+		return errors.New("series slug already exists")
+	}
+	return nil
+}
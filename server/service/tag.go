@@ -3,14 +3,21 @@ package service
 import (
 	"context"
 	"errors"
+	"math"
+	"sort"
 	"time"
-	"gorm.io/gorm"
 
 	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
+	"github.com/personal-blog/pkg/utils"
 	"github.com/personal-blog/repository/mysql"
 	"github.com/personal-blog/repository/redis"
+	"gorm.io/gorm"
 )
 
+// tagCloudLevels 标签云的权重级数
+const tagCloudLevels = 5
+
 // TagService 标签服务接口
 type TagService interface {
 	CreateTag(ctx context.Context, tag *models.Tag) error
@@ -20,38 +27,46 @@ type TagService interface {
 	ListTags(ctx context.Context, page, pageSize int) ([]models.Tag, int64, error)
 	GetPostTags(ctx context.Context, postID uint) ([]models.Tag, error)
 	CreateTagsIfNotExist(ctx context.Context, names []string) ([]models.Tag, error)
+	AddAlias(ctx context.Context, tagID uint, alias string) (*models.TagAlias, error)
+	DeleteAlias(ctx context.Context, tagID, aliasID uint) error
+	MergeTags(ctx context.Context, sourceIDs []uint, targetID uint) error
+	TagCloud(ctx context.Context, limit int) ([]models.TagCloudItem, error)
 }
 
 type tagService struct {
 	tagRepo   mysql.TagRepository
 	tagCache  redis.TagCache
 	postRepo  mysql.PostRepository
+	postCache redis.PostCache
 }
 
 // NewTagService 创建标签服务实例
-func NewTagService(tagRepo mysql.TagRepository, tagCache redis.TagCache, postRepo mysql.PostRepository) TagService {
+func NewTagService(tagRepo mysql.TagRepository, tagCache redis.TagCache, postRepo mysql.PostRepository, postCache redis.PostCache) TagService {
 	return &tagService{
-		tagRepo:  tagRepo,
-		tagCache: tagCache,
-		postRepo: postRepo,
+		tagRepo:   tagRepo,
+		tagCache:  tagCache,
+		postRepo:  postRepo,
+		postCache: postCache,
 	}
 }
 
 func (s *tagService) CreateTag(ctx context.Context, tag *models.Tag) error {
-	// 检查名称是否已存在
-	existing, err := s.tagRepo.FindByName(tag.Name)
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+	ctx, span := tracing.Start(ctx, "TagService.CreateTag")
+	defer span.End()
+
+	tag.Name = utils.NormalizeTagName(tag.Name)
+	tag.NormalizedName = utils.TagKey(tag.Name)
+
+	// 检查名称是否已存在，不同写法和别名视为同一名称
+	if err := s.checkKey(ctx, tag.NormalizedName, 0); err != nil {
 		return err
 	}
-	if existing != nil {
-		return errors.New("tag name already exists")
-	}
 
 	tag.CreatedAt = time.Now()
 	tag.UpdatedAt = time.Now()
 
 	// 创建标签
-	if err := s.tagRepo.Create(tag); err != nil {
+	if err := s.tagRepo.Create(ctx, tag); err != nil {
 		return err
 	}
 
@@ -61,38 +76,67 @@ func (s *tagService) CreateTag(ctx context.Context, tag *models.Tag) error {
 	}
 
 	// 清除标签列表缓存
-	return s.tagCache.Delete(ctx, 0)
+	return s.tagCache.DeleteList(ctx)
 }
 
+// UpdateTag 更新标签名称，规范化名称变化时旧名称自动成为别名
 func (s *tagService) UpdateTag(ctx context.Context, tag *models.Tag) error {
+	ctx, span := tracing.Start(ctx, "TagService.UpdateTag")
+	defer span.End()
+
+	tag.Name = utils.NormalizeTagName(tag.Name)
+	tag.NormalizedName = utils.TagKey(tag.Name)
+
 	// 检查名称是否已存在（排除自身）
-	existing, err := s.tagRepo.FindByName(tag.Name)
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+	if err := s.checkKey(ctx, tag.NormalizedName, tag.ID); err != nil {
 		return err
 	}
-	if existing != nil && existing.ID != tag.ID {
-		return errors.New("tag name already exists")
+
+	old, err := s.tagRepo.FindByID(ctx, tag.ID)
+	if err != nil {
+		return err
 	}
 
 	tag.UpdatedAt = time.Now()
 
 	// 更新标签
-	if err := s.tagRepo.Update(tag); err != nil {
+	if err := s.tagRepo.Update(ctx, tag); err != nil {
 		return err
 	}
 
-	// 更新缓存
-	if err := s.tagCache.Set(ctx, tag); err != nil {
+	if old.NormalizedName != tag.NormalizedName {
+		// 新名称原本是自身的别名时移除该别名，旧名称加入别名
+		if owner, err := s.tagRepo.FindAliasOwner(ctx, tag.NormalizedName); err == nil && owner.TagID == tag.ID {
+			if err := s.tagRepo.DeleteAlias(ctx, tag.ID, owner.ID); err != nil {
+				return err
+			}
+		}
+		alias := &models.TagAlias{TagID: tag.ID, Alias: old.NormalizedName, CreatedAt: time.Now()}
+		if err := s.tagRepo.AddAlias(ctx, alias); err != nil {
+			return err
+		}
+	}
+
+	// 缓存的标签包含别名，直接删除
+	if err := s.tagCache.Delete(ctx, tag.ID); err != nil {
+		return err
+	}
+
+	// 文章列表中包含标签信息
+	if err := s.postCache.InvalidatePostLists(ctx); err != nil {
 		return err
 	}
 
 	// 清除标签列表缓存
-	return s.tagCache.Delete(ctx, 0)
+	return s.tagCache.DeleteList(ctx)
 }
 
 func (s *tagService) DeleteTag(ctx context.Context, id uint) error {
+	ctx, span := tracing.Start(ctx, "TagService.DeleteTag")
+	defer span.End()
+
 	// 删除标签
-	if err := s.tagRepo.Delete(id); err != nil {
+	if err := s.tagRepo.Delete(ctx, id); err != nil {
 		return err
 	}
 
@@ -101,39 +145,36 @@ func (s *tagService) DeleteTag(ctx context.Context, id uint) error {
 		return err
 	}
 
+	// 文章列表中包含标签信息
+	if err := s.postCache.InvalidatePostLists(ctx); err != nil {
+		return err
+	}
+
 	// 清除标签列表缓存
-	return s.tagCache.Delete(ctx, 0)
+	return s.tagCache.DeleteList(ctx)
 }
 
 func (s *tagService) GetTagByID(ctx context.Context, id uint) (*models.Tag, error) {
-	// 先从缓存获取
-	tag, err := s.tagCache.Get(ctx, id)
-	if err != nil {
-		return nil, err
-	}
-	if tag != nil {
-		return tag, nil
-	}
+	ctx, span := tracing.Start(ctx, "TagService.GetTagByID")
+	defer span.End()
 
-	// 缓存未命中，从数据库获取
-	tag, err = s.tagRepo.FindByID(id)
-	if err != nil {
-		return nil, err
-	}
-
-	// 写入缓存
-	if err := s.tagCache.Set(ctx, tag); err != nil {
-		return nil, err
-	}
-
-	return tag, nil
+	// 缓存未命中时从数据库加载，并发请求合并为一次查询，不存在的记录会写入负缓存
+	return s.tagCache.GetOrLoad(ctx, id, func(ctx context.Context) (*models.Tag, error) {
+		return s.tagRepo.FindByID(ctx, id)
+	})
 }
 
 func (s *tagService) ListTags(ctx context.Context, page, pageSize int) ([]models.Tag, int64, error) {
-	return s.tagRepo.List(page, pageSize)
+	ctx, span := tracing.Start(ctx, "TagService.ListTags")
+	defer span.End()
+
+	return s.tagRepo.List(ctx, page, pageSize)
 }
 
 func (s *tagService) GetPostTags(ctx context.Context, postID uint) ([]models.Tag, error) {
+	ctx, span := tracing.Start(ctx, "TagService.GetPostTags")
+	defer span.End()
+
 	// 先从缓存获取
 	tags, err := s.tagCache.GetPostTags(ctx, postID)
 	if err != nil {
@@ -144,7 +185,7 @@ func (s *tagService) GetPostTags(ctx context.Context, postID uint) ([]models.Tag
 	}
 
 	// 从数据库获取
-	post, err := s.postRepo.FindByID(postID)
+	post, err := s.postRepo.FindByID(ctx, postID)
 	if err != nil {
 		return nil, err
 	}
@@ -158,5 +199,136 @@ func (s *tagService) GetPostTags(ctx context.Context, postID uint) ([]models.Tag
 }
 
 func (s *tagService) CreateTagsIfNotExist(ctx context.Context, names []string) ([]models.Tag, error) {
-	return s.tagRepo.FindOrCreateByNames(names)
+	ctx, span := tracing.Start(ctx, "TagService.CreateTagsIfNotExist")
+	defer span.End()
+
+	return s.tagRepo.FindOrCreateByNames(ctx, names)
+}
+
+// AddAlias 为标签添加别名，别名不能与任何标签的名称或其他别名重复
+func (s *tagService) AddAlias(ctx context.Context, tagID uint, alias string) (*models.TagAlias, error) {
+	ctx, span := tracing.Start(ctx, "TagService.AddAlias")
+	defer span.End()
+
+	key := utils.TagKey(alias)
+	if key == "" {
+		return nil, errors.New("alias is empty")
+	}
+	if _, err := s.tagRepo.FindByID(ctx, tagID); err != nil {
+		return nil, err
+	}
+	if err := s.checkKey(ctx, key, 0); err != nil {
+		return nil, err
+	}
+
+	a := &models.TagAlias{TagID: tagID, Alias: key, CreatedAt: time.Now()}
+	if err := s.tagRepo.AddAlias(ctx, a); err != nil {
+		return nil, err
+	}
+	return a, s.tagCache.Delete(ctx, tagID)
+}
+
+func (s *tagService) DeleteAlias(ctx context.Context, tagID, aliasID uint) error {
+	ctx, span := tracing.Start(ctx, "TagService.DeleteAlias")
+	defer span.End()
+
+	if err := s.tagRepo.DeleteAlias(ctx, tagID, aliasID); err != nil {
+		return err
+	}
+	return s.tagCache.Delete(ctx, tagID)
+}
+
+// MergeTags 将源标签合并到目标标签，源标签的文章改为使用目标标签，源标签名称成为目标标签的别名
+func (s *tagService) MergeTags(ctx context.Context, sourceIDs []uint, targetID uint) error {
+	ctx, span := tracing.Start(ctx, "TagService.MergeTags")
+	defer span.End()
+
+	seen := make(map[uint]bool, len(sourceIDs))
+	for _, id := range sourceIDs {
+		if id == targetID {
+			return errors.New("cannot merge a tag into itself")
+		}
+		if seen[id] {
+			return errors.New("duplicate source tag")
+		}
+		seen[id] = true
+	}
+
+	postIDs, err := s.tagRepo.Merge(ctx, sourceIDs, targetID)
+	if err != nil {
+		return err
+	}
+
+	// 清除受影响的文章和标签缓存
+	for _, postID := range postIDs {
+		if err := s.tagCache.DeletePostTags(ctx, postID); err != nil {
+			return err
+		}
+		if err := s.postCache.Delete(ctx, postID); err != nil {
+			return err
+		}
+	}
+	for _, id := range sourceIDs {
+		if err := s.tagCache.Delete(ctx, id); err != nil {
+			return err
+		}
+	}
+	if err := s.tagCache.Delete(ctx, targetID); err != nil {
+		return err
+	}
+
+	// 文章列表中包含标签信息
+	if err := s.postCache.InvalidatePostLists(ctx); err != nil {
+		return err
+	}
+
+	// 清除标签列表缓存
+	return s.tagCache.DeleteList(ctx)
+}
+
+// TagCloud 返回文章数最多的limit个标签，权重按文章数的对数分为1-5级
+func (s *tagService) TagCloud(ctx context.Context, limit int) ([]models.TagCloudItem, error) {
+	ctx, span := tracing.Start(ctx, "TagService.TagCloud")
+	defer span.End()
+
+	items, err := s.tagRepo.ListByPostCount(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return []models.TagCloudItem{}, nil
+	}
+
+	// 结果按文章数倒序，首尾即最大值和最小值
+	maxLog := math.Log(float64(items[0].PostCount))
+	minLog := math.Log(float64(items[len(items)-1].PostCount))
+	for i := range items {
+		if maxLog == minLog {
+			items[i].Weight = tagCloudLevels
+			continue
+		}
+		ratio := (math.Log(float64(items[i].PostCount)) - minLog) / (maxLog - minLog)
+		items[i].Weight = 1 + int(math.Round(ratio*(tagCloudLevels-1)))
+	}
+
+	// 标签云按名称排列
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+	return items, nil
+}
+
+// checkKey 检查规范化名称是否已被其他标签的名称或别名占用，excludeID 为正在修改的标签
+func (s *tagService) checkKey(ctx context.Context, key string, excludeID uint) error {
+	if key == "" {
+		return errors.New("tag name is empty")
+	}
+	existing, err := s.tagRepo.FindByKey(ctx, key)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if existing != nil && existing.ID != excludeID {
+		return errors.New("tag name already exists")
+	}
+	return nil
 }
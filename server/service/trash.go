@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/personal-blog/config"
+	"github.com/personal-blog/pkg/tracing"
+	"github.com/personal-blog/repository/mysql"
+	"github.com/personal-blog/repository/redis"
+)
+
+// purgeBatchSize 每个事务彻底删除的文章或评论数
+const purgeBatchSize = 100
+
+// TrashService 回收站服务接口
+type TrashService interface {
+	// Purge 彻底删除超过保留期的文章和评论，返回删除的文章数和评论数
+	Purge(ctx context.Context) (int, int, error)
+}
+
+type trashService struct {
+	repos        mysql.Factory
+	postCache    redis.PostCache
+	commentCache redis.CommentCache
+}
+
+// NewTrashService 创建回收站服务实例
+func NewTrashService(repos mysql.Factory, postCache redis.PostCache, commentCache redis.CommentCache) TrashService {
+	return &trashService{
+		repos:        repos,
+		postCache:    postCache,
+		commentCache: commentCache,
+	}
+}
+
+func (s *trashService) Purge(ctx context.Context) (int, int, error) {
+	ctx, span := tracing.Start(ctx, "TrashService.Purge")
+	defer span.End()
+
+	before := time.Now().Add(-retention())
+
+	// 先删除文章，文章下的评论随文章一起删除
+	posts := 0
+	for {
+		ids, err := s.repos.GetPostRepository().ListPurgeable(ctx, before, purgeBatchSize)
+		if err != nil {
+			return posts, 0, err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		err = s.repos.Transaction(ctx, func(repos mysql.Factory) error {
+			if err := repos.GetCommentRepository().PurgeByPosts(ctx, ids); err != nil {
+				return err
+			}
+			return repos.GetPostRepository().Purge(ctx, ids)
+		})
+		if err != nil {
+			return posts, 0, err
+		}
+		posts += len(ids)
+		if len(ids) < purgeBatchSize {
+			break
+		}
+	}
+
+	// 单独删除的评论，对它们的回复会成为顶级评论，因此需要清除所属文章的缓存
+	comments := 0
+	for {
+		batch, err := s.repos.GetCommentRepository().ListPurgeable(ctx, before, purgeBatchSize)
+		if err != nil || len(batch) == 0 {
+			return posts, comments, err
+		}
+		ids := make([]uint, 0, len(batch))
+		for _, comment := range batch {
+			ids = append(ids, comment.ID)
+		}
+		if err := s.repos.GetCommentRepository().Purge(ctx, ids); err != nil {
+			return posts, comments, err
+		}
+		comments += len(ids)
+
+		if err := invalidateComments(ctx, s.commentCache, batch); err != nil {
+			return posts, comments, err
+		}
+		for _, comment := range batch {
+			if err := s.postCache.Delete(ctx, comment.PostID); err != nil {
+				return posts, comments, err
+			}
+		}
+		if len(batch) < purgeBatchSize {
+			return posts, comments, nil
+		}
+	}
+}
+
+// trashTime 移入回收站的时间，截断到毫秒以便在各数据库中按删除时间精确匹配
+func trashTime() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
+
+// purgeTime 回收站中的内容被彻底删除的时间
+func purgeTime(deletedAt time.Time) time.Time {
+	return deletedAt.Add(retention())
+}
+
+func retention() time.Duration {
+	return time.Duration(config.Get().Trash.RetentionDays) * 24 * time.Hour
+}
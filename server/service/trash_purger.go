@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/personal-blog/config"
+)
+
+// trashPurgeWorker 定期彻底删除回收站中超过保留期的文章和评论
+type trashPurgeWorker struct {
+	trashService TrashService
+}
+
+func newTrashPurgeWorker(trashService TrashService) Worker {
+	return &trashPurgeWorker{trashService: trashService}
+}
+
+func (w *trashPurgeWorker) Name() string {
+	return "trash-purger"
+}
+
+func (w *trashPurgeWorker) Run(ctx context.Context) {
+	for {
+		interval := time.Duration(config.Get().Trash.PurgeInterval) * time.Second
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		posts, comments, err := w.trashService.Purge(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("worker %s: purge trash: %v", w.Name(), err)
+		}
+		if posts > 0 || comments > 0 {
+			log.Printf("worker %s: purged %d posts and %d comments", w.Name(), posts, comments)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/personal-blog/models"
+	"github.com/personal-blog/pkg/tracing"
 	"github.com/personal-blog/repository/mysql"
 	"github.com/personal-blog/repository/redis"
 	"github.com/personal-blog/middleware"
@@ -21,6 +22,8 @@ type UserService interface {
 	UpdateUser(ctx context.Context, user *models.User) error
 	ListUsers(ctx context.Context, page, pageSize int) ([]models.User, int64, error)
 	ChangePassword(ctx context.Context, userID uint, oldPassword, newPassword string) error
+	// SetRole 分配用户角色，调用方负责确认操作者是管理员；已签发的Token中的角色在重新登录后更新
+	SetRole(ctx context.Context, id uint, role string) error
 }
 
 type userService struct {
@@ -37,8 +40,11 @@ func NewUserService(userRepo mysql.UserRepository, userCache redis.UserCache) Us
 }
 
 func (s *userService) Register(ctx context.Context, user *models.User) error {
+	ctx, span := tracing.Start(ctx, "UserService.Register")
+	defer span.End()
+
 	// 检查用户名是否已存在
-	existingUser, err := s.userRepo.FindByUsername(user.Username)
+	existingUser, err := s.userRepo.FindByUsername(ctx, user.Username)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return err
 	}
@@ -47,7 +53,7 @@ func (s *userService) Register(ctx context.Context, user *models.User) error {
 	}
 
 	// 检查邮箱是否已存在
-	existingUser, err = s.userRepo.FindByEmail(user.Email)
+	existingUser, err = s.userRepo.FindByEmail(ctx, user.Email)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return err
 	}
@@ -62,16 +68,20 @@ func (s *userService) Register(ctx context.Context, user *models.User) error {
 	}
 	user.Password = string(hashedPassword)
 
-	// 设置默认值
+	// 设置默认值，注册的用户只能是普通用户
+	user.Role = models.RoleUser
 	user.Status = 1
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
-	return s.userRepo.Create(user)
+	return s.userRepo.Create(ctx, user)
 }
 
 func (s *userService) Login(ctx context.Context, username, password string) (*models.User, string, error) {
-	user, err := s.userRepo.FindByUsername(username)
+	ctx, span := tracing.Start(ctx, "UserService.Login")
+	defer span.End()
+
+	user, err := s.userRepo.FindByUsername(ctx, username)
 	if err != nil {
 		return nil, "", err
 	}
@@ -99,32 +109,21 @@ func (s *userService) Login(ctx context.Context, username, password string) (*mo
 }
 
 func (s *userService) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
-	// 先从缓存获取
-	user, err := s.userCache.Get(ctx, id)
-	if err != nil {
-		return nil, err
-	}
-	if user != nil {
-		return user, nil
-	}
-
-	// 缓存未命中，从数据库获取
-	user, err = s.userRepo.FindByID(id)
-	if err != nil {
-		return nil, err
-	}
-
-	// 写入缓存
-	if err := s.userCache.Set(ctx, user); err != nil {
-		return nil, err
-	}
+	ctx, span := tracing.Start(ctx, "UserService.GetUserByID")
+	defer span.End()
 
-	return user, nil
+	// 缓存未命中时从数据库加载，并发请求合并为一次查询，不存在的记录会写入负缓存
+	return s.userCache.GetOrLoad(ctx, id, func(ctx context.Context) (*models.User, error) {
+		return s.userRepo.FindByID(ctx, id)
+	})
 }
 
 func (s *userService) UpdateUser(ctx context.Context, user *models.User) error {
+	ctx, span := tracing.Start(ctx, "UserService.UpdateUser")
+	defer span.End()
+
 	user.UpdatedAt = time.Now()
-	if err := s.userRepo.Update(user); err != nil {
+	if err := s.userRepo.Update(ctx, user); err != nil {
 		return err
 	}
 
@@ -133,11 +132,17 @@ func (s *userService) UpdateUser(ctx context.Context, user *models.User) error {
 }
 
 func (s *userService) ListUsers(ctx context.Context, page, pageSize int) ([]models.User, int64, error) {
-	return s.userRepo.List(page, pageSize)
+	ctx, span := tracing.Start(ctx, "UserService.ListUsers")
+	defer span.End()
+
+	return s.userRepo.List(ctx, page, pageSize)
 }
 
 func (s *userService) ChangePassword(ctx context.Context, userID uint, oldPassword, newPassword string) error {
-	user, err := s.userRepo.FindByID(userID)
+	ctx, span := tracing.Start(ctx, "UserService.ChangePassword")
+	defer span.End()
+
+	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return err
 	}
@@ -154,10 +159,23 @@ func (s *userService) ChangePassword(ctx context.Context, userID uint, oldPasswo
 	user.Password = string(hashedPassword)
 	user.UpdatedAt = time.Now()
 
-	if err := s.userRepo.Update(user); err != nil {
+	if err := s.userRepo.Update(ctx, user); err != nil {
 		return err
 	}
 
 	// 更新缓存
 	return s.userCache.Set(ctx, user)
 }
+
+func (s *userService) SetRole(ctx context.Context, id uint, role string) error {
+	ctx, span := tracing.Start(ctx, "UserService.SetRole")
+	defer span.End()
+
+	if _, err := s.userRepo.FindByID(ctx, id); err != nil {
+		return err
+	}
+	if err := s.userRepo.Update(ctx, &models.User{ID: id, Role: role, UpdatedAt: time.Now()}); err != nil {
+		return err
+	}
+	return s.userCache.Delete(ctx, id)
+}
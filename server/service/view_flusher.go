@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/personal-blog/config"
+)
+
+// viewFlushWorker 定期将缓存中的浏览量写入数据库
+type viewFlushWorker struct {
+	postService PostService
+}
+
+func newViewFlushWorker(postService PostService) Worker {
+	return &viewFlushWorker{postService: postService}
+}
+
+func (w *viewFlushWorker) Name() string {
+	return "view-flusher"
+}
+
+// Run 每次等待前读取 view.flush_interval，配置热更新后下一轮即生效
+func (w *viewFlushWorker) Run(ctx context.Context) {
+	for {
+		interval := time.Duration(config.Get().View.FlushInterval) * time.Second
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+
+		if err := w.postService.FlushViewCounts(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("worker %s: flush view counts: %v", w.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/personal-blog/models"
+)
+
+// ErrPostPasswordRequired 密码保护的文章未提供密码或密码错误
+var ErrPostPasswordRequired = errors.New("post is password protected")
+
+// Viewer 当前访问者，nil表示匿名访客
+type Viewer struct {
+	UserID uint
+	Role   string
+}
+
+// canSeeAll 编辑和管理员可以查看所有文章
+func (v *Viewer) canSeeAll() bool {
+	return v != nil && (v.Role == models.RoleAdmin || v.Role == models.RoleEditor)
+}
+
+// owns 访问者是否可以不受状态和可见性限制地阅读文章
+func (v *Viewer) owns(post *models.Post) bool {
+	return v.canSeeAll() || (v != nil && v.UserID == post.UserID)
+}
+
+// PostAccess 读取单篇文章时的访问凭据
+type PostAccess struct {
+	Viewer   *Viewer
+	Preview  bool   // 持有该文章有效的预览链接
+	Password string // 密码保护文章的访问密码
+}
+
+// scopeQuery 在列表查询条件中加入访问者的可见范围
+// 匿名访客只能看到已发布且非不公开列出的文章，作者还能看到自己的全部文章，编辑和管理员不受限制
+func scopeQuery(q *models.PostQuery, viewer *Viewer) {
+	switch {
+	case viewer.canSeeAll():
+		q.PublicOnly = false
+		q.OwnerID = 0
+	case viewer != nil:
+		q.PublicOnly = true
+		q.OwnerID = viewer.UserID
+	default:
+		q.PublicOnly = true
+		q.OwnerID = 0
+	}
+}
+
+// lockPosts 列表中访问者无权直接阅读的密码保护文章只保留标题等信息
+func lockPosts(posts []models.Post, viewer *Viewer) []models.Post {
+	for i := range posts {
+		if posts[i].Visibility == models.VisibilityPassword && !viewer.owns(&posts[i]) {
+			posts[i].Content = ""
+			posts[i].Summary = ""
+			posts[i].Locked = true
+		}
+	}
+	return posts
+}
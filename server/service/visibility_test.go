@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/personal-blog/config"
+	"github.com/personal-blog/database"
+	"github.com/personal-blog/models"
+	"github.com/personal-blog/repository/mysql"
+	"github.com/personal-blog/repository/redis"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestPostService 使用进程内的SQLite数据库和内存缓存创建文章服务，每个测试相互独立
+func newTestPostService(t *testing.T) (PostService, *gorm.DB) {
+	t.Helper()
+
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	cfg := config.DatabaseConfig{
+		Driver: "sqlite",
+		DBName: fmt.Sprintf("file:service_%s?mode=memory&cache=shared", name),
+	}
+	db, err := database.Open(cfg, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatalf("create migrator: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+
+	// 读取路径不开启事务，不需要仓库工厂
+	caches := redis.NewMemoryFactory(1000)
+	srv := NewPostService(
+		mysql.NewPostRepository(db),
+		caches.GetPostCache(),
+		mysql.NewTagRepository(db),
+		mysql.NewCategoryRepository(db),
+		caches.GetTagCache(),
+		caches.GetAnalyticsCache(),
+		nil,
+		caches.GetCommentCache(),
+	)
+	return srv, db
+}
+
+// visibilityFixture 由两位作者和一位编辑组成的文章集合
+type visibilityFixture struct {
+	alice, bob, editor *models.User
+	public, draft      *models.Post
+	unlisted, locked   *models.Post
+	bobDraft           *models.Post
+}
+
+func newVisibilityFixture(t *testing.T, db *gorm.DB) *visibilityFixture {
+	t.Helper()
+
+	create := func(value interface{}) {
+		t.Helper()
+		if err := db.Create(value).Error; err != nil {
+			t.Fatalf("create %T: %v", value, err)
+		}
+	}
+	user := func(name, role string) *models.User {
+		u := &models.User{Username: name, Password: "x", Email: name + "@example.com", Role: role}
+		create(u)
+		return u
+	}
+	category := &models.Category{Name: "go"}
+	create(category)
+
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	post := func(title string, author *models.User, status int, visibility, password string) *models.Post {
+		t.Helper()
+		clock = clock.Add(time.Minute)
+		published := clock
+		p := &models.Post{
+			Title:       title,
+			Content:     title,
+			Visibility:  visibility,
+			Password:    password,
+			UserID:      author.ID,
+			CategoryID:  category.ID,
+			PublishedAt: &published,
+		}
+		if err := hashPostPassword(p); err != nil {
+			t.Fatalf("hash password: %v", err)
+		}
+		create(p)
+		// Status 带有默认值标签，草稿的零值需要在插入后单独写入
+		if status != models.PostStatusPublished {
+			if err := db.Model(p).UpdateColumn("status", status).Error; err != nil {
+				t.Fatalf("update status: %v", err)
+			}
+		}
+		return p
+	}
+
+	f := &visibilityFixture{
+		alice:  user("alice", models.RoleUser),
+		bob:    user("bob", models.RoleUser),
+		editor: user("eve", models.RoleEditor),
+	}
+	f.public = post("public", f.alice, models.PostStatusPublished, models.VisibilityPublic, "")
+	f.draft = post("draft", f.alice, 0, models.VisibilityPublic, "")
+	f.unlisted = post("unlisted", f.alice, models.PostStatusPublished, models.VisibilityUnlisted, "")
+	f.locked = post("locked", f.alice, models.PostStatusPublished, models.VisibilityPassword, "secret")
+	f.bobDraft = post("bob draft", f.bob, 0, models.VisibilityPublic, "")
+	return f
+}
+
+func viewerOf(u *models.User) *Viewer {
+	return &Viewer{UserID: u.ID, Role: u.Role}
+}
+
+func TestListPostsVisibility(t *testing.T) {
+	srv, db := newTestPostService(t)
+	f := newVisibilityFixture(t, db)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		viewer *Viewer
+		want   []*models.Post
+		locked bool // 密码保护文章是否隐藏正文
+	}{
+		{"anonymous", nil, []*models.Post{f.public, f.locked}, true},
+		{"other user", viewerOf(f.bob), []*models.Post{f.public, f.locked, f.bobDraft}, true},
+		{"author", viewerOf(f.alice), []*models.Post{f.public, f.draft, f.unlisted, f.locked}, false},
+		{"editor", viewerOf(f.editor), []*models.Post{f.public, f.draft, f.unlisted, f.locked, f.bobDraft}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posts, total, err := srv.ListPosts(ctx, 1, 10, &models.PostQuery{}, tt.viewer)
+			if err != nil {
+				t.Fatalf("ListPosts: %v", err)
+			}
+
+			var got, want []uint
+			for i := range posts {
+				got = append(got, posts[i].ID)
+				if posts[i].ID != f.locked.ID {
+					continue
+				}
+				if posts[i].Locked != tt.locked || (posts[i].Content == "") != tt.locked {
+					t.Errorf("password post locked = %v, content = %q, want locked %v", posts[i].Locked, posts[i].Content, tt.locked)
+				}
+			}
+			for _, p := range tt.want {
+				want = append(want, p.ID)
+			}
+			sort.Slice(got, func(i, j int) bool { return got[i] < got[j] })
+			if fmt.Sprint(got) != fmt.Sprint(want) || total != int64(len(want)) {
+				t.Errorf("ListPosts = %v (total %d), want %v", got, total, want)
+			}
+		})
+	}
+}
+
+func TestGetVisiblePost(t *testing.T) {
+	srv, db := newTestPostService(t)
+	f := newVisibilityFixture(t, db)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		post    *models.Post
+		access  PostAccess
+		wantErr error
+	}{
+		{"public anonymous", f.public, PostAccess{}, nil},
+		{"draft anonymous", f.draft, PostAccess{}, gorm.ErrRecordNotFound},
+		{"draft other user", f.draft, PostAccess{Viewer: viewerOf(f.bob)}, gorm.ErrRecordNotFound},
+		{"draft author", f.draft, PostAccess{Viewer: viewerOf(f.alice)}, nil},
+		{"draft editor", f.draft, PostAccess{Viewer: viewerOf(f.editor)}, nil},
+		{"draft preview", f.draft, PostAccess{Preview: true}, nil},
+		{"unlisted anonymous", f.unlisted, PostAccess{}, nil},
+		{"password missing", f.locked, PostAccess{}, ErrPostPasswordRequired},
+		{"password wrong", f.locked, PostAccess{Password: "guess"}, ErrPostPasswordRequired},
+		{"password correct", f.locked, PostAccess{Password: "secret"}, nil},
+		{"password author", f.locked, PostAccess{Viewer: viewerOf(f.alice)}, nil},
+		{"password editor", f.locked, PostAccess{Viewer: viewerOf(f.editor)}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			access := tt.access
+			post, err := srv.GetVisiblePost(ctx, tt.post.ID, &access)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetVisiblePost error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && post.ID != tt.post.ID {
+				t.Errorf("GetVisiblePost = post %d, want %d", post.ID, tt.post.ID)
+			}
+		})
+	}
+}
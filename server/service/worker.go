@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// Worker 后台任务接口
+// Run 应阻塞运行直到ctx被取消
+type Worker interface {
+	Name() string
+	Run(ctx context.Context)
+}
+
+// workerGroup 管理后台任务的启动与停止
+type workerGroup struct {
+	workers []Worker
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+}
+
+// add 注册后台任务，启动后注册的任务不会运行
+func (g *workerGroup) add(w Worker) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cancel != nil {
+		return
+	}
+	g.workers = append(g.workers, w)
+}
+
+// start 启动所有已注册的后台任务
+func (g *workerGroup) start() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g.cancel = cancel
+	for _, w := range g.workers {
+		g.wg.Add(1)
+		go func(w Worker) {
+			defer g.wg.Done()
+			log.Printf("worker %s started", w.Name())
+			w.Run(ctx)
+			log.Printf("worker %s stopped", w.Name())
+		}(w)
+	}
+}
+
+// stop 通知所有后台任务退出，并等待其结束或ctx超时
+func (g *workerGroup) stop(ctx context.Context) error {
+	g.mu.Lock()
+	cancel := g.cancel
+	g.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}